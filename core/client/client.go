@@ -6,10 +6,12 @@ import (
 	"encoding/hex"
 	"flag"
 	"fmt"
-	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/joho/godotenv"
 	"github.com/yoseplee/plum/core/ledger"
 	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/ledger/genesis"
 	"github.com/yoseplee/plum/core/plum"
 	"github.com/yoseplee/plum/core/util"
 	"github.com/yoseplee/plum/core/util/path"
@@ -17,6 +19,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -26,7 +30,14 @@ var targetFlag = flag.String("target", "", "target peer to send a message")
 var iterFlag = flag.Int("iter", 1, "set how many times to iterate")
 var roundFlag = flag.Uint64("round", 0, "set start(base) round on consensus")
 var speedFlag = flag.Uint("speed", 0, "set speed to send new request to a peer, max: 3")
-var operationFlag = flag.String("o", "", "operation to execute: triggerConsensus / getPeerState")
+var operationFlag = flag.String("o", "", "operation to execute: triggerConsensus / getPeerState / getReputationHistory / setLogLevel / subscribeEvents / getMessageLog")
+var peerFlag = flag.Uint("peer", 0, "peer whose reputation history to get")
+var fromFlag = flag.Uint64("from", 0, "lowest height of reputation history, 0 means the first block")
+var toFlag = flag.Uint64("to", 0, "highest height of reputation history, 0 means the current height")
+var levelFlag = flag.String("level", "", "log level of the peer to set: debug / info / warn / error, empty to get the current one")
+var phasesFlag = flag.String("phases", "", "comma separated phases of the messages to get from the message log, e.g. XBFTPrepare,XBFTCommit. empty means every phase")
+var sendersFlag = flag.String("senders", "", "comma separated ids of the senders of the messages to get from the message log. empty means every sender")
+var eventsFlag = flag.String("events", "", "comma separated types of events to subscribe, e.g. BlockAppended,RoundChangeTriggered. empty means every type")
 
 func main() {
 	var conn *grpc.ClientConn
@@ -60,6 +71,14 @@ func main() {
 		handleGetPeerState(farmerClient, latency)
 	case "getPeerStateStream":
 		handleGetPeerStateStream(farmerClient, latency)
+	case "getReputationHistory":
+		handleGetReputationHistory(farmerClient, latency)
+	case "setLogLevel":
+		handleSetLogLevel(farmerClient, latency)
+	case "subscribeEvents":
+		handleSubscribeEvents(farmerClient)
+	case "getMessageLog":
+		handleGetMessageLog(farmerClient, latency)
 	case "tps":
 		calculateTps(farmerClient, latency)
 	case "ping":
@@ -187,6 +206,116 @@ func handleGetPeerState(client plum.FarmerClient, latency time.Duration) {
 	log.Println(formatPeerState(r))
 }
 
+//handleGetReputationHistory prints the changes of reputation of a peer in csv, to be plotted
+func handleGetReputationHistory(client plum.FarmerClient, latency time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), latency)
+	defer cancel()
+
+	r, err := client.GetReputationHistory(ctx, &plum.ReputationHistoryRequest{
+		PeerId:     uint32(*peerFlag),
+		FromHeight: *fromFlag,
+		ToHeight:   *toFlag,
+	})
+	if err != nil {
+		log.Printf("could not get the reputation history: %v", err)
+		return
+	}
+
+	fmt.Println("height,round,peer,before,after,delta,reason")
+	for _, e := range r.GetEvents() {
+		fmt.Printf("%d,%d,%d,%v,%v,%v,%s\n", e.GetHeight(), e.GetRound(), e.GetPeerId(), e.GetBefore(), e.GetAfter(), e.GetDelta(), e.GetReason())
+	}
+}
+
+func handleSetLogLevel(client plum.FarmerClient, latency time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), latency)
+	defer cancel()
+
+	l, err := client.SetLogLevel(ctx, &plum.LogLevel{Level: *levelFlag})
+	if err != nil {
+		log.Printf("could not set the log level: %v", err)
+		return
+	}
+	fmt.Println("log level:", l.GetLevel())
+}
+
+//handleGetMessageLog prints the consensus messages of the round kept by the peer, a json object per line
+func handleGetMessageLog(client plum.FarmerClient, latency time.Duration) {
+	r := &plum.MessageLogRequest{Round: *roundFlag}
+	if *phasesFlag != "" {
+		for _, ph := range strings.Split(*phasesFlag, ",") {
+			r.Phases = append(r.Phases, strings.TrimSpace(ph))
+		}
+	}
+	if *sendersFlag != "" {
+		for _, s := range strings.Split(*sendersFlag, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+			if err != nil {
+				log.Fatalf("invalid sender: %s", s)
+			}
+			r.Senders = append(r.Senders, uint32(id))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), latency)
+	defer cancel()
+
+	dump, err := client.GetMessageLog(ctx, r)
+	if err != nil {
+		log.Printf("could not get the message log: %v", err)
+		return
+	}
+	m := jsonpb.Marshaler{}
+	for _, lm := range dump.GetMessages() {
+		s, err := m.MarshalToString(lm)
+		if err != nil {
+			log.Printf("could not marshal the message: %v", err)
+			continue
+		}
+		fmt.Println(s)
+	}
+}
+
+//handleSubscribeEvents prints the events of consensus in csv as they happen, until interrupted
+func handleSubscribeEvents(client plum.FarmerClient) {
+	f := &plum.EventFilter{}
+	if *eventsFlag != "" {
+		for _, name := range strings.Split(*eventsFlag, ",") {
+			t, ok := plum.EventType_value[strings.TrimSpace(name)]
+			if !ok {
+				log.Fatalf("unknown type of event: %s", name)
+			}
+			f.Types = append(f.Types, plum.EventType(t))
+		}
+	}
+
+	stream, err := client.SubscribeEvents(context.Background(), f)
+	if err != nil {
+		log.Fatalf("could not subscribe events: %v", err)
+	}
+
+	fmt.Println("seq,time,type,peer,height,round,phase,primary,digest,committee,reputation")
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Fatalf("could not receive events: %v", err)
+		}
+		var committee []uint32
+		for _, cm := range e.GetCommitteeMembers() {
+			committee = append(committee, cm.GetPeerId())
+		}
+		var reputation []string
+		for _, re := range e.GetReputationEvents() {
+			reputation = append(reputation, fmt.Sprintf("%d:%v", re.GetPeerId(), re.GetDelta()))
+		}
+		fmt.Printf("%d,%s,%s,%d,%d,%d,%s,%d,%x,%v,%v\n", e.GetSeq(), ptypes.TimestampString(e.GetTimestamp()), e.GetType(), e.GetPeerId(),
+			e.GetHeight(), e.GetRound(), e.GetPhase(), e.GetPrimaryId(), e.GetBlockDigest(), committee, reputation)
+	}
+}
+
 func formatPeerState(p *plum.PeerState) string {
 	var s string
 
@@ -201,10 +330,24 @@ func formatPeerState(p *plum.PeerState) string {
 	s += fmt.Sprintf("|%-17s| %-20s |\n", "Role", p.Role.String())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Round", p.GetConsensusRound())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Current Primary", p.GetCurrentPrimary())
-	s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus Phase", p.GetConsensusPhase().String())
-	s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Prepare]", p.Vote[int32(plum.PBFTPhase_PBFTPrepare)])
-	s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Commit]", p.Vote[int32(plum.PBFTPhase_PBFTPrepare)])
-	s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[RoundChange]", p.Vote[int32(plum.PBFTPhase_PBFTRoundChange)])
+	//only XBFT has thresholds in the state
+	if len(p.GetThresholds()) == 0 {
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus Phase", p.GetConsensusPhase().String())
+		s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Prepare]", p.Vote[int32(plum.PBFTPhase_PBFTPrepare)])
+		s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Commit]", p.Vote[int32(plum.PBFTPhase_PBFTCommit)])
+		s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[RoundChange]", p.Vote[int32(plum.PBFTPhase_PBFTRoundChange)])
+	} else {
+		var committee []uint32
+		for _, cm := range p.GetCommitteeMembers() {
+			committee = append(committee, cm.GetPeerId())
+		}
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus Phase", p.GetXbftPhase().String())
+		s += fmt.Sprintf("|%-17s| %-20v |\n", "Committee", committee)
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Cert[Prepare]", formatCertificate(p, plum.XBFTPhase_XBFTPrepare))
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Cert[Commit]", formatCertificate(p, plum.XBFTPhase_XBFTCommit))
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Cert[RoundChange]", formatCertificate(p, plum.XBFTPhase_XBFTRoundChange))
+		s += fmt.Sprintf("|%-17s| %-20f |\n", "Total Reputation", p.GetTotalReputation())
+	}
 	s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus State", p.GetConsensusState().String())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Block Height", p.GetBlockHeight())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Queue Length", p.GetQueueLength())
@@ -216,7 +359,13 @@ func formatPeerState(p *plum.PeerState) string {
 	return s
 }
 
+//formatCertificate returns the number of the messages collected for the phase against its threshold
+func formatCertificate(p *plum.PeerState, ph plum.XBFTPhase) string {
+	return fmt.Sprintf("%d / %d", p.GetCertificates()[int32(ph)], p.GetThresholds()[int32(ph)])
+}
+
 func handleTriggerConsensus(conn *grpc.ClientConn, latency time.Duration, nextBlockDigest []byte, privateKey ed25519.PrivateKey, nextBlock *plum.Block) {
+	chainID := genesis.ChainID(ledger.LoadGenesisBlock(path.GetInstance().GenesisBlockPath))
 	for i := 0; i < *iterFlag; i++ {
 		c := plum.NewConsensusClient(conn)
 		ctx, cancel := context.WithTimeout(context.Background(), latency)
@@ -228,9 +377,9 @@ func handleTriggerConsensus(conn *grpc.ClientConn, latency time.Duration, nextBl
 			PeerId: 0,
 		}
 
-		md, err := proto.Marshal(consensusMessage)
+		md, err := util.SigningBytes(chainID, consensusMessage)
 		if err != nil {
-			log.Printf("could not marshal the message: %v\n", err)
+			log.Printf("could not make signing bytes: %v\n", err)
 		}
 
 		sig := ed25519.Sign(privateKey, md)
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/yoseplee/plum/core/ledger"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/ledger/genesis"
+	"github.com/yoseplee/plum/core/peer"
+	"github.com/yoseplee/plum/core/peer/messageLog"
+	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/util"
+	"github.com/yoseplee/plum/core/util/path"
+	"google.golang.org/grpc"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+)
+
+//runCommand runs a sub command given as the first argument and reports whether there was one.
+//without a sub command, the program runs a peer
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "genesis":
+		genesisCommand(args[1:])
+	case "keygen":
+		keygenCommand(args[1:])
+	case "validator":
+		validatorCommand(args[1:])
+	case "replay":
+		replayCommand(args[1:])
+	default:
+		return false
+	}
+	return true
+}
+
+//genesisCommand makes a genesis block from the genesis spec.
+//usage: plum genesis -spec genesis.yaml -out genesis.block
+func genesisCommand(args []string) {
+	fs := flag.NewFlagSet("genesis", flag.ExitOnError)
+	specFlag := fs.String("spec", fmt.Sprintf("%s%s", path.GetInstance().PlumRoot, "/core/genesis.yaml"), "path to the genesis spec")
+	outFlag := fs.String("out", path.GetInstance().GenesisBlockPath, "path to write the genesis block")
+	fs.Parse(args)
+
+	spec, err := genesis.LoadSpec(*specFlag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	c, err := spec.Config()
+	if err != nil {
+		log.Fatalf("invalid genesis spec: %v", err)
+	}
+
+	gb, err := genesis.NewBlock(c)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := genesis.Store(gb, *outFlag); err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("genesis block of chain %s with %d validators is written to %s", c.GetChainId(), len(c.GetValidators()), *outFlag)
+	log.Printf("genesis block digest: %s", hex.EncodeToString(block.Digest(gb.GetHeader())))
+}
+
+//keygenCommand makes a key pair of a peer, writes the private key to the file and prints the public key for the genesis spec.
+//usage: plum keygen -out peer-0.key
+func keygenCommand(args []string) {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	outFlag := fs.String("out", "peer.key", "path to write the private key")
+	fs.Parse(args)
+
+	if _, err := os.Stat(*outFlag); err == nil {
+		log.Fatalf("%s already exists", *outFlag)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		log.Fatalf("could not generate key pair: %v", err)
+	}
+
+	if err := util.StorePrivateKey(priv, *outFlag); err != nil {
+		log.Fatalf("could not store private key: %v", err)
+	}
+	fmt.Println(hex.EncodeToString(pub))
+}
+
+//validatorCommand signs a validator update with the key of a validator and submits it to a peer.
+//the update is applied when more than 2/3 of the validators have submitted the same update with their keys.
+//usage: plum validator -type join -id 7 -pub <hex> -ipv4 localhost -port :50121 -signer 0 -key peer-0.key -peer localhost:50051
+func validatorCommand(args []string) {
+	fs := flag.NewFlagSet("validator", flag.ExitOnError)
+	typeFlag := fs.String("type", "join", "type of the update: join / leave / replace")
+	idFlag := fs.Uint("id", 0, "id of the joining validator")
+	pubFlag := fs.String("pub", "", "public key of the joining validator in hex")
+	repFlag := fs.Float64("rep", 0, "initial reputation of the joining validator, default for join and that of the target for replace if 0")
+	ipv4Flag := fs.String("ipv4", "", "ipv4 of the joining validator")
+	portFlag := fs.String("port", ":50051", "port of the joining validator")
+	containerFlag := fs.String("container", "", "container name of the joining validator")
+	targetFlag := fs.Uint("target", 0, "id of the leaving or replaced validator")
+	signerFlag := fs.Uint("signer", 0, "id of the validator signing the update")
+	keyFlag := fs.String("key", "", "path to the private key of the signer")
+	peerFlag := fs.String("peer", "localhost:50051", "address of the peer to submit the update")
+	nonceFlag := fs.Int64("nonce", -1, "number of the validator updates applied on the chain, read from the peer if -1")
+	fs.Parse(args)
+
+	u := &plum.ValidatorUpdate{TargetId: uint32(*targetFlag)}
+	switch *typeFlag {
+	case "join":
+		u.Type = plum.ValidatorUpdateType_ValidatorJoin
+	case "leave":
+		u.Type = plum.ValidatorUpdateType_ValidatorLeave
+	case "replace":
+		u.Type = plum.ValidatorUpdateType_ValidatorReplace
+	default:
+		log.Fatalf("invalid type of validator update: %s", *typeFlag)
+	}
+
+	if u.Type != plum.ValidatorUpdateType_ValidatorLeave {
+		pub, err := hex.DecodeString(*pubFlag)
+		if err != nil {
+			log.Fatalf("invalid public key: %v", err)
+		}
+		u.Validator = &plum.Validator{Id: uint32(*idFlag), PublicKey: pub, Reputation: *repFlag}
+		u.Ipv4 = *ipv4Flag
+		u.Port = *portFlag
+		u.ContainerName = *containerFlag
+	}
+
+	key, err := util.LoadPrivateKey(*keyFlag)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	chainID := genesis.ChainID(ledger.LoadGenesisBlock(path.GetInstance().GenesisBlockPath))
+
+	conn, err := grpc.Dial(*peerFlag, grpc.WithInsecure())
+	if err != nil {
+		log.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	//every signer should sign the same nonce, so it is better given explicitly while an update is being collected
+	if *nonceFlag < 0 {
+		ps, err := plum.NewFarmerClient(conn).GetPeerState(ctx, &plum.Empty{})
+		if err != nil {
+			log.Fatalf("could not read the nonce of validator updates: %v", err)
+		}
+		u.Nonce = ps.GetValidatorNonce()
+	} else {
+		u.Nonce = uint64(*nonceFlag)
+	}
+
+	md, err := util.SigningBytes(chainID, u)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	_, err = plum.NewPeerClient(conn).SubmitValidatorUpdate(ctx, &plum.SignedValidatorUpdate{
+		Update:     u,
+		Signatures: []*plum.ValidatorSignature{{SignerId: uint32(*signerFlag), Signature: ed25519.Sign(key, md)}},
+	})
+	if err != nil {
+		log.Fatalf("could not submit validator update: %v", err)
+	}
+	log.Printf("validator update of nonce %d is submitted to %s: %s", u.Nonce, *peerFlag, u)
+}
+
+//replayCommand replays the message log recorded by a peer on a peer offline, from the genesis to the given round and phase.
+//usage: plum replay -log ledger/peer-0/messages -id 0 -amount 4 -consensus XBFT -round 3 -phase XBFTCommit
+func replayCommand(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	logFlag := fs.String("log", "", "path to the message log directory of the peer to replay")
+	idFlag := fs.Uint("id", 0, "id of the peer which has recorded the log")
+	amountFlag := fs.Uint("amount", 4, "amount of peers participating in consensus")
+	consensusFlag := fs.String("consensus", "XBFT", "consensus type of the log: PBFT / XBFT")
+	keyFlag := fs.String("key", "", "path to the private key of the peer, which reproduces its selection in XBFT")
+	roundFlag := fs.Int64("round", -1, "round to stop at, -1 to replay the whole log")
+	phaseFlag := fs.String("phase", "", "phase of the round to stop at such as XBFTCommit, empty to stop as the round begins")
+	outFlag := fs.String("out", "", "directory to write the ledger of the replay, a temporary one if empty")
+	fs.Parse(args)
+
+	if *logFlag == "" {
+		log.Fatalf("no message log to replay")
+	}
+	source, err := messageLog.NewDiskLog(*logFlag)
+	if err != nil {
+		log.Fatalf("could not open the message log: %v", err)
+	}
+	lms := source.All()
+	source.Close()
+
+	var key ed25519.PrivateKey
+	if *keyFlag != "" {
+		if key, err = util.LoadPrivateKey(*keyFlag); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	var stop *peer.ReplayStop
+	if *roundFlag >= 0 {
+		stop = &peer.ReplayStop{Round: uint64(*roundFlag), Phase: *phaseFlag}
+	}
+
+	out := *outFlag
+	if out == "" {
+		if out, err = ioutil.TempDir("", "plum-replay"); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	err = replay(lms, out, uint32(*idFlag), uint32(*amountFlag), *consensusFlag, key, stop)
+	//the temporary ledger is removed here, as log.Fatal does not run deferred calls
+	if *outFlag == "" {
+		os.RemoveAll(out)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+//replay replays the messages by the peer of the id with its ledger in out, then prints the state of the peer
+func replay(lms []*plum.LoggedMessage, out string, id uint32, amount uint32, consensus string, key ed25519.PrivateKey, stop *peer.ReplayStop) error {
+	path.GetInstance().LedgerPath = out + "/"
+
+	profile := make(map[uint32]*peer.Connection)
+	for i := uint32(0); i < amount; i++ {
+		profile[i] = &peer.Connection{PeerId: i}
+	}
+
+	p := peer.GetInstance()
+	if err := p.InitReplay(id, profile, consensus, key); err != nil {
+		return err
+	}
+
+	n := p.Replay(lms, stop, os.Stdout)
+	log.Printf("replayed %d of %d messages", n, len(lms))
+
+	ps, err := p.State()
+	if err != nil {
+		return err
+	}
+	s, err := (&jsonpb.Marshaler{}).MarshalToString(ps)
+	if err != nil {
+		return err
+	}
+	fmt.Println(s)
+	return nil
+}
@@ -6,8 +6,8 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/yoseplee/plum/core/ledger/merkleTree"
+	"github.com/yoseplee/plum/core/logger"
 	"github.com/yoseplee/plum/core/plum"
-	"log"
 	"time"
 )
 
@@ -15,36 +15,89 @@ import (
 func Digest(bh *plum.Header) []byte {
 	m, err := proto.Marshal(bh)
 	if err != nil {
-		log.Println("could not marshall the message")
+		logger.Errorf("could not marshal the header: %v", err)
 	}
 	d := sha256.Sum256(m)
 	return d[:]
 }
 
+//NewBlock makes a block with the Legacy merkle tree
 func NewBlock(txs [][]byte, prevBlockHash []byte, id uint64) *plum.Block {
-	mTree := merkleTree.NewTree(txs)
+	return NewVersionedBlock(txs, prevBlockHash, id, merkleTree.Legacy)
+}
+
+//NewVersionedBlock makes a block whose merkle root is built in the format of v
+func NewVersionedBlock(txs [][]byte, prevBlockHash []byte, id uint64, v merkleTree.Version) *plum.Block {
+	mTree := merkleTree.NewVersionedTree(txs, v)
 	//assign
 	timestamp, err := ptypes.TimestampProto(time.Now())
 	if err != nil {
-		log.Println("could not convert timestamp for protobuf")
+		logger.Errorf("could not convert timestamp for protobuf")
 	}
 
 	b := plum.Block{
 		Header: &plum.Header{
-			Id:            id,
-			MerkleRoot:    mTree.Root.D,
-			PrevBlockHash: prevBlockHash,
-			Time:          timestamp,
+			Id:                id,
+			MerkleRoot:        mTree.Root(),
+			PrevBlockHash:     prevBlockHash,
+			Time:              timestamp,
+			MerkleTreeVersion: uint32(v),
 		},
 		Body: &plum.Body{
-			MerkleTree: mTree,
-			Txs:        txs,
+			Txs: txs,
 		},
 	}
 
 	return &b
 }
 
+//CommitteeDigest hashes the committee members and the round changed committee members of the block.
+//it is nil for a block without them, so that a block of PBFT has the same header as before
+func CommitteeDigest(b *plum.Block) []byte {
+	if len(b.GetCommitteeMembers()) == 0 && len(b.GetRoundChangedCommitteeMembers()) == 0 {
+		return nil
+	}
+	m, err := proto.Marshal(&plum.Block{
+		CommitteeMembers:             b.GetCommitteeMembers(),
+		RoundChangedCommitteeMembers: b.GetRoundChangedCommitteeMembers(),
+	})
+	if err != nil {
+		logger.Errorf("could not marshal the committee members: %v", err)
+	}
+	d := sha256.Sum256(m)
+	return d[:]
+}
+
+//SealCommittee writes the digest of the committee members into the header. it should be called whenever they are changed,
+//as the digest of the block changes with them
+func SealCommittee(b *plum.Block) {
+	b.Header.CommitteeDigest = CommitteeDigest(b)
+}
+
+//Verify recomputes the merkle tree from the transactions in the body and checks it against the root in the header.
+//the tree is built in the format written in the header.
+//the tree itself is not shipped with the block, therefore this is the only way to trust the merkle root of a received block.
+func Verify(b *plum.Block) bool {
+	if b.GetHeader() == nil {
+		return false
+	}
+	v := merkleTree.Version(b.GetHeader().GetMerkleTreeVersion())
+	if !v.Valid() {
+		logger.Warnf("unknown version of merkle tree: %v", v)
+		return false
+	}
+	root := merkleTree.NewVersionedTree(b.GetBody().GetTxs(), v).Root()
+	if bytes.Compare(root, b.GetHeader().GetMerkleRoot()) != 0 {
+		logger.Warnf("merkle root of the block is not matched with its transactions")
+		return false
+	}
+	if bytes.Compare(CommitteeDigest(b), b.GetHeader().GetCommitteeDigest()) != 0 {
+		logger.Warnf("committee members of the block are not matched with its header")
+		return false
+	}
+	return true
+}
+
 func NewGenesisBlock() *plum.Block {
 	var g *plum.Block
 	g = NewBlock(nil, nil, 0)
@@ -55,11 +108,11 @@ func CompareBlock(a, b *plum.Block) bool {
 	//marshal and compare
 	am, amErr := proto.Marshal(a)
 	if amErr != nil {
-		log.Fatalf("could not marshal the message: %v", a)
+		logger.Fatalf("could not marshal the message: %v", a)
 	}
 	bm, bmErr := proto.Marshal(b)
 	if bmErr != nil {
-		log.Fatalf("could not marshal the message: %v", b)
+		logger.Fatalf("could not marshal the message: %v", b)
 	}
 	if bytes.Compare(am, bm) != 0 {
 		return false
@@ -69,7 +122,7 @@ func CompareBlock(a, b *plum.Block) bool {
 
 func CompareBlockDigest(a, b []byte) bool {
 	if bytes.Compare(a, b) != 0 {
-		log.Println("failed to compare digests of the two block")
+		logger.Debugf("failed to compare digests of the two block")
 		return false
 	}
 	return true
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"github.com/yoseplee/plum/core/ledger/merkleTree"
 	"github.com/yoseplee/plum/core/plum"
 	"log"
 	"math/rand"
@@ -118,3 +119,77 @@ func TestCompareBlockDigest(t *testing.T) {
 		t.Errorf("invalid comparison: should be false")
 	}
 }
+
+func TestVerify(t *testing.T) {
+	b := NewBlock(generateTx(), nil, 1)
+	if got := Verify(b); got == false {
+		t.Errorf("invalid verification: should be true")
+	}
+
+	//tamper a transaction
+	b.Body.Txs[0] = []byte("tampered")
+	if got := Verify(b); got == true {
+		t.Errorf("invalid verification: should be false for a tampered transaction")
+	}
+
+	//empty block
+	if got := Verify(NewBlock(nil, nil, 0)); got == false {
+		t.Errorf("invalid verification: should be true for an empty block")
+	}
+}
+
+func TestVerify_Versioned(t *testing.T) {
+	txs := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+
+	//a block made before versioning is verified with the Legacy tree
+	legacy := NewBlock(txs, nil, 1)
+	if got := Verify(legacy); got == false {
+		t.Errorf("invalid verification: legacy block should be verified")
+	}
+
+	b := NewVersionedBlock(txs, nil, 1, merkleTree.RFC6962)
+	if got := Verify(b); got == false {
+		t.Errorf("invalid verification: versioned block should be verified")
+	}
+
+	if bytes.Compare(legacy.Header.MerkleRoot, b.Header.MerkleRoot) == 0 {
+		t.Errorf("merkle roots of different versions should differ")
+	}
+
+	//claiming another version than the one the root is built with
+	b.Header.MerkleTreeVersion = uint32(merkleTree.Legacy)
+	if got := Verify(b); got == true {
+		t.Errorf("invalid verification: should be false for a mismatched version")
+	}
+
+	b.Header.MerkleTreeVersion = 7
+	if got := Verify(b); got == true {
+		t.Errorf("invalid verification: should be false for an unknown version")
+	}
+}
+
+func TestVerify_Committee(t *testing.T) {
+	b := NewBlock(generateTx(), nil, 1)
+	b.CommitteeMembers = []*plum.CommitteeMembers{{PeerId: 1}, {PeerId: 2}}
+	if got := Verify(b); got == true {
+		t.Errorf("invalid verification: should be false for committee members not sealed in the header")
+	}
+
+	SealCommittee(b)
+	if got := Verify(b); got == false {
+		t.Errorf("invalid verification: should be true for sealed committee members")
+	}
+	digest := Digest(b.Header)
+
+	//shrink the committee
+	b.CommitteeMembers = b.CommitteeMembers[:1]
+	if got := Verify(b); got == true {
+		t.Errorf("invalid verification: should be false for changed committee members")
+	}
+
+	//sealing again changes the digest of the block
+	SealCommittee(b)
+	if bytes.Compare(Digest(b.Header), digest) == 0 {
+		t.Errorf("digest of the block should cover its committee members")
+	}
+}
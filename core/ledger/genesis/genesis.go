@@ -0,0 +1,293 @@
+package genesis
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/ledger/merkleTree"
+	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/reputation"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+//defaultReputation is given to a validator whose reputation is not written in the spec
+const defaultReputation = 1.0
+
+//defaults of the selection parameters, which XBFT has started with
+const (
+	defaultSelectionThreshold = 0.2
+	defaultReputationWeight   = 10.0
+	defaultMinCommitteeSize   = 4
+)
+
+//committeeSizeRules maps the rules written in the spec to the ones of the configuration
+var committeeSizeRules = map[string]plum.CommitteeSizeRule{
+	"":         plum.CommitteeSizeRule_FixedCommitteeSize,
+	"fixed":    plum.CommitteeSizeRule_FixedCommitteeSize,
+	"expected": plum.CommitteeSizeRule_ExpectedCommitteeSize,
+}
+
+//Spec is the human-editable form of the genesis configuration, written in yaml. see core/genesis.example.yaml
+type Spec struct {
+	ChainID         string          `yaml:"chainId"`
+	Timestamp       time.Time       `yaml:"timestamp"`
+	Validators      []ValidatorSpec `yaml:"validators"`
+	ConsensusParams ParamsSpec      `yaml:"consensusParams"`
+}
+
+//ValidatorSpec is a validator in the spec. public key is hex encoded
+type ValidatorSpec struct {
+	ID         uint32  `yaml:"id"`
+	PublicKey  string  `yaml:"publicKey"`
+	Reputation float64 `yaml:"reputation"`
+}
+
+//ParamsSpec is consensus parameters in the spec
+type ParamsSpec struct {
+	MerkleTreeVersion uint32               `yaml:"merkleTreeVersion"`
+	ReputationPolicy  ReputationPolicySpec `yaml:"reputationPolicy"`
+	Selection         SelectionSpec        `yaml:"selection"`
+}
+
+//SelectionSpec is how XBFT selects its committee in the spec. a parameter not written takes the default
+type SelectionSpec struct {
+	Threshold        *float64 `yaml:"threshold"`
+	ReputationWeight *float64 `yaml:"reputationWeight"`
+	//CommitteeSize is either fixed or expected
+	CommitteeSize    string  `yaml:"committeeSize"`
+	MinCommitteeSize *uint32 `yaml:"minCommitteeSize"`
+}
+
+//ReputationPolicySpec chooses how reputation changes in the spec. see core/reputation for the policies and their parameters
+type ReputationPolicySpec struct {
+	Name     string  `yaml:"name"`
+	Increase float64 `yaml:"increase"`
+	Decrease float64 `yaml:"decrease"`
+	Decay    float64 `yaml:"decay"`
+	Base     float64 `yaml:"base"`
+	Window   uint64  `yaml:"window"`
+	Min      float64 `yaml:"min"`
+	Max      float64 `yaml:"max"`
+}
+
+//LoadSpec reads the spec from the yaml file
+func LoadSpec(sp string) (*Spec, error) {
+	raw, err := ioutil.ReadFile(sp)
+	if err != nil {
+		return nil, fmt.Errorf("could not read genesis spec: %v", err)
+	}
+
+	s := &Spec{}
+	if err := yaml.UnmarshalStrict(raw, s); err != nil {
+		return nil, fmt.Errorf("could not parse genesis spec: %v", err)
+	}
+	return s, nil
+}
+
+//Config validates the spec and converts it into the genesis configuration.
+//validators are sorted by their id, so that the same spec always results in the same configuration
+func (s *Spec) Config() (*plum.GenesisConfig, error) {
+	if s.ChainID == "" {
+		return nil, errors.New("chain id is empty")
+	}
+
+	if s.Timestamp.IsZero() {
+		return nil, errors.New("timestamp is empty")
+	}
+
+	if len(s.Validators) == 0 {
+		return nil, errors.New("there is no validator")
+	}
+
+	if !merkleTree.Version(s.ConsensusParams.MerkleTreeVersion).Valid() {
+		return nil, fmt.Errorf("unknown merkle tree version: %d", s.ConsensusParams.MerkleTreeVersion)
+	}
+
+	//a spec without policy keeps its genesis block as before, which runs the default policy
+	var rp *plum.ReputationPolicyParams
+	if s.ConsensusParams.ReputationPolicy != (ReputationPolicySpec{}) {
+		rp = &plum.ReputationPolicyParams{
+			Name:     s.ConsensusParams.ReputationPolicy.Name,
+			Increase: s.ConsensusParams.ReputationPolicy.Increase,
+			Decrease: s.ConsensusParams.ReputationPolicy.Decrease,
+			Decay:    s.ConsensusParams.ReputationPolicy.Decay,
+			Base:     s.ConsensusParams.ReputationPolicy.Base,
+			Window:   s.ConsensusParams.ReputationPolicy.Window,
+			Min:      s.ConsensusParams.ReputationPolicy.Min,
+			Max:      s.ConsensusParams.ReputationPolicy.Max,
+		}
+	}
+	if _, err := reputation.New(rp); err != nil {
+		return nil, err
+	}
+
+	//a spec without selection keeps its genesis block as before as well
+	var sp *plum.SelectionParams
+	if s.ConsensusParams.Selection != (SelectionSpec{}) {
+		var err error
+		if sp, err = s.ConsensusParams.Selection.params(); err != nil {
+			return nil, err
+		}
+	}
+
+	ts, err := ptypes.TimestampProto(s.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp: %v", err)
+	}
+
+	c := &plum.GenesisConfig{
+		ChainId: s.ChainID,
+		Time:    ts,
+		ConsensusParams: &plum.ConsensusParams{
+			MerkleTreeVersion: s.ConsensusParams.MerkleTreeVersion,
+			ReputationPolicy:  rp,
+			Selection:         sp,
+		},
+	}
+
+	seen := make(map[uint32]bool)
+	for _, v := range s.Validators {
+		if seen[v.ID] {
+			return nil, fmt.Errorf("validator %d is duplicated", v.ID)
+		}
+		seen[v.ID] = true
+
+		pub, err := hex.DecodeString(v.PublicKey)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key of validator %d", v.ID)
+		}
+
+		rep := v.Reputation
+		if rep == 0 {
+			rep = defaultReputation
+		}
+		if rep < 0 {
+			return nil, fmt.Errorf("negative reputation of validator %d", v.ID)
+		}
+
+		c.Validators = append(c.Validators, &plum.Validator{
+			Id:         v.ID,
+			PublicKey:  pub,
+			Reputation: rep,
+		})
+	}
+	sort.Slice(c.Validators, func(i, j int) bool { return c.Validators[i].Id < c.Validators[j].Id })
+
+	return c, nil
+}
+
+//params converts the spec into the selection parameters, filling the defaults
+func (s SelectionSpec) params() (*plum.SelectionParams, error) {
+	sp := DefaultSelectionParams()
+	if s.Threshold != nil {
+		sp.Threshold = *s.Threshold
+	}
+	if s.ReputationWeight != nil {
+		sp.ReputationWeight = *s.ReputationWeight
+	}
+	if s.MinCommitteeSize != nil {
+		sp.MinCommitteeSize = *s.MinCommitteeSize
+	}
+
+	rule, ok := committeeSizeRules[s.CommitteeSize]
+	if !ok {
+		return nil, fmt.Errorf("unknown committee size rule: %s", s.CommitteeSize)
+	}
+	sp.CommitteeSizeRule = rule
+
+	if sp.Threshold < 0 || sp.ReputationWeight < 0 {
+		return nil, fmt.Errorf("negative selection threshold or reputation weight: %v, %v", sp.Threshold, sp.ReputationWeight)
+	}
+	if sp.MinCommitteeSize == 0 {
+		return nil, errors.New("minimum size of committee should be positive")
+	}
+	return sp, nil
+}
+
+//DefaultSelectionParams returns the selection parameters of a chain whose genesis does not write them
+func DefaultSelectionParams() *plum.SelectionParams {
+	return &plum.SelectionParams{
+		Threshold:         defaultSelectionThreshold,
+		ReputationWeight:  defaultReputationWeight,
+		CommitteeSizeRule: plum.CommitteeSizeRule_FixedCommitteeSize,
+		MinCommitteeSize:  defaultMinCommitteeSize,
+	}
+}
+
+//marshal encodes the configuration deterministically
+func marshal(c *plum.GenesisConfig) ([]byte, error) {
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//NewBlock makes the genesis block of the configuration.
+//the configuration is the only transaction of the block and the block takes its time from it,
+//therefore every peer makes exactly the same genesis block from the same configuration
+func NewBlock(c *plum.GenesisConfig) (*plum.Block, error) {
+	mc, err := marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal genesis configuration: %v", err)
+	}
+
+	v := merkleTree.Version(c.GetConsensusParams().GetMerkleTreeVersion())
+	b := block.NewVersionedBlock([][]byte{mc}, nil, 0, v)
+	b.Header.Time = c.GetTime()
+	return b, nil
+}
+
+//ConfigOf reads the configuration from the genesis block.
+//a genesis block made without configuration has no transaction, for which an error is returned
+func ConfigOf(b *plum.Block) (*plum.GenesisConfig, error) {
+	if b.GetHeader().GetId() != 0 {
+		return nil, fmt.Errorf("block of height %d is not a genesis block", b.GetHeader().GetId())
+	}
+
+	txs := b.GetBody().GetTxs()
+	if len(txs) != 1 {
+		return nil, errors.New("the genesis block has no configuration")
+	}
+
+	if !block.Verify(b) {
+		return nil, errors.New("the genesis block does not match its configuration")
+	}
+
+	c := &plum.GenesisConfig{}
+	if err := proto.Unmarshal(txs[0], c); err != nil {
+		return nil, fmt.Errorf("could not unmarshal genesis configuration: %v", err)
+	}
+	return c, nil
+}
+
+//ChainID returns the chain id of the genesis block.
+//a genesis block without configuration is identified by its digest instead
+func ChainID(b *plum.Block) string {
+	if c, err := ConfigOf(b); err == nil {
+		return c.GetChainId()
+	}
+	return hex.EncodeToString(block.Digest(b.GetHeader()))
+}
+
+//Store writes the genesis block into the file
+func Store(b *plum.Block, gbp string) error {
+	mb, err := proto.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("could not marshal genesis block: %v", err)
+	}
+
+	if err := ioutil.WriteFile(gbp, mb, os.FileMode(777)); err != nil {
+		return fmt.Errorf("could not store genesis block: %v", err)
+	}
+	return nil
+}
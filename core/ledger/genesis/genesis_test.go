@@ -0,0 +1,184 @@
+package genesis
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/ledger/merkleTree"
+	"github.com/yoseplee/plum/core/plum"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func newSpecForTest(n int) *Spec {
+	s := &Spec{
+		ChainID:         "plum-test",
+		Timestamp:       time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+		ConsensusParams: ParamsSpec{MerkleTreeVersion: uint32(merkleTree.RFC6962)},
+	}
+	for i := 0; i < n; i++ {
+		pub, _, _ := ed25519.GenerateKey(nil)
+		s.Validators = append(s.Validators, ValidatorSpec{ID: uint32(i), PublicKey: hex.EncodeToString(pub), Reputation: float64(i + 1)})
+	}
+	return s
+}
+
+func TestLoadSpec(t *testing.T) {
+	f, err := ioutil.TempFile("", "genesis-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	pub, _, _ := ed25519.GenerateKey(nil)
+	fmt.Fprintf(f, `chainId: plum-test
+timestamp: 2020-06-01T00:00:00Z
+validators:
+  - id: 0
+    publicKey: %s
+    reputation: 2.5
+  - id: 1
+    publicKey: %s
+consensusParams:
+  merkleTreeVersion: 1
+`, hex.EncodeToString(pub), hex.EncodeToString(pub))
+	f.Close()
+
+	s, err := LoadSpec(f.Name())
+	if err != nil {
+		t.Fatalf("could not load the spec: %v", err)
+	}
+
+	c, err := s.Config()
+	if err != nil {
+		t.Fatalf("could not make configuration: %v", err)
+	}
+	if c.GetChainId() != "plum-test" || len(c.GetValidators()) != 2 || c.GetConsensusParams().GetMerkleTreeVersion() != 1 {
+		t.Errorf("invalid configuration: %v", c)
+	}
+	if c.GetValidators()[0].GetReputation() != 2.5 || c.GetValidators()[1].GetReputation() != defaultReputation {
+		t.Errorf("invalid reputation of validators: %v", c.GetValidators())
+	}
+}
+
+func TestSpec_Config_Invalid(t *testing.T) {
+	tests := map[string]func(s *Spec){
+		"empty chain id":    func(s *Spec) { s.ChainID = "" },
+		"empty timestamp":   func(s *Spec) { s.Timestamp = time.Time{} },
+		"no validator":      func(s *Spec) { s.Validators = nil },
+		"duplicated id":     func(s *Spec) { s.Validators[1].ID = s.Validators[0].ID },
+		"invalid key":       func(s *Spec) { s.Validators[0].PublicKey = "plum" },
+		"negative rep":      func(s *Spec) { s.Validators[0].Reputation = -1 },
+		"unknown mt format": func(s *Spec) { s.ConsensusParams.MerkleTreeVersion = 100 },
+		"unknown policy":    func(s *Spec) { s.ConsensusParams.ReputationPolicy.Name = "plum" },
+		"invalid bound":     func(s *Spec) { s.ConsensusParams.ReputationPolicy = ReputationPolicySpec{Name: "bounded", Max: -1} },
+		"unknown size rule": func(s *Spec) { s.ConsensusParams.Selection.CommitteeSize = "plum" },
+		"zero committee":    func(s *Spec) { s.ConsensusParams.Selection.MinCommitteeSize = new(uint32) },
+	}
+	for name, f := range tests {
+		s := newSpecForTest(4)
+		f(s)
+		if _, err := s.Config(); err == nil {
+			t.Errorf("%s: spec should be invalid", name)
+		}
+	}
+}
+
+func TestSpec_Config_Selection(t *testing.T) {
+	s := newSpecForTest(4)
+	c, err := s.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GetConsensusParams().GetSelection() != nil {
+		t.Errorf("spec without selection should not write selection parameters")
+	}
+
+	//parameters not written take the defaults, zero is kept as it is written
+	weight := 0.0
+	s.ConsensusParams.Selection = SelectionSpec{ReputationWeight: &weight, CommitteeSize: "expected"}
+	c, err = s.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultSelectionParams()
+	want.ReputationWeight = 0
+	want.CommitteeSizeRule = plum.CommitteeSizeRule_ExpectedCommitteeSize
+	if got := c.GetConsensusParams().GetSelection(); !proto.Equal(got, want) {
+		t.Errorf("invalid selection parameters. got: %v, want: %v", got, want)
+	}
+}
+
+func TestNewBlock_Deterministic(t *testing.T) {
+	s := newSpecForTest(4)
+	c, err := s.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := NewBlock(c)
+
+	//same spec written in a different order
+	s.Validators[0], s.Validators[3] = s.Validators[3], s.Validators[0]
+	c, err = s.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-time.After(time.Millisecond)
+	b, _ := NewBlock(c)
+
+	if !bytes.Equal(block.Digest(a.GetHeader()), block.Digest(b.GetHeader())) {
+		t.Errorf("same spec should make the same genesis block")
+	}
+	if a.GetHeader().GetMerkleTreeVersion() != uint32(merkleTree.RFC6962) {
+		t.Errorf("genesis block should be built in the merkle tree version of the configuration")
+	}
+}
+
+func TestConfigOf(t *testing.T) {
+	c, err := newSpecForTest(4).Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewBlock(c)
+
+	got, err := ConfigOf(b)
+	if err != nil {
+		t.Fatalf("could not read configuration: %v", err)
+	}
+	if got.GetChainId() != c.GetChainId() || len(got.GetValidators()) != len(c.GetValidators()) {
+		t.Errorf("invalid configuration. got: %v, want: %v", got, c)
+	}
+
+	//configuration which does not match the merkle root
+	b.Body.Txs[0] = append(b.Body.Txs[0], 0)
+	if _, err := ConfigOf(b); err == nil {
+		t.Errorf("tampered configuration should not be read")
+	}
+
+	//genesis block without configuration
+	if _, err := ConfigOf(block.NewGenesisBlock()); err == nil {
+		t.Errorf("genesis block without configuration should have no configuration")
+	}
+}
+
+func TestChainID(t *testing.T) {
+	c, err := newSpecForTest(4).Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewBlock(c)
+	if got := ChainID(b); got != c.GetChainId() {
+		t.Errorf("invalid chain id. got: %s, want: %s", got, c.GetChainId())
+	}
+
+	//genesis block without configuration is identified by its digest
+	g := block.NewGenesisBlock()
+	if got, want := ChainID(g), hex.EncodeToString(block.Digest(g.GetHeader())); got != want {
+		t.Errorf("invalid chain id. got: %s, want: %s", got, want)
+	}
+}
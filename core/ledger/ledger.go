@@ -6,19 +6,37 @@ import (
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/ledger/genesis"
+	"github.com/yoseplee/plum/core/ledger/merkleTree"
+	"github.com/yoseplee/plum/core/logger"
 	"github.com/yoseplee/plum/core/plum"
 	"io/ioutil"
-	"log"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 )
 
+//snapshotsToKeep is the number of the latest snapshots kept in the file system, older ones are removed
+const snapshotsToKeep = 2
+
 type Ledger struct {
-	Genesis    *plum.Block
+	Genesis *plum.Block
+	//Headers holds headers from the height of base, the ones below it are only on the file system if pruning is on
 	Headers    []*plum.Header
 	Height     uint64
+	base       uint64
 	path       string
 	storeBlock bool
+	rwMutex    *sync.RWMutex
+
+	//snapshotInterval is how many heights there are between snapshots, 0 means no snapshot
+	snapshotInterval uint64
+	//pruneDepth is how many heights of blocks below the current height keep their bodies, 0 means no pruning
+	pruneDepth   uint64
+	prunedHeight uint64
+	//flushedHeight is the height up to which the files are synced to the disk by Flush
+	flushedHeight uint64
 }
 
 //NewLedger is to create a new ledger. lp stands for ledger path and gbp stands for genesis block path, therefore the two parameter should be path to them.
@@ -36,6 +54,13 @@ func NewLedger(lp, gbp string, storeBlock bool) *Ledger {
 		Height:     0,
 		path:       ledgerPath,
 		storeBlock: storeBlock,
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	if storeBlock {
+		if err := os.MkdirAll(ledgerPath, os.ModePerm); err != nil {
+			logger.Errorf("could not make ledger path %s: %v", ledgerPath, err)
+		}
 	}
 	l.Headers = append(l.Headers, gb.Header)
 	l.toFile(gb)
@@ -82,6 +107,38 @@ func (l *Ledger) String() string {
 	return s
 }
 
+//MerkleTreeVersion returns the format of merkle tree used in this chain, which is decided by its genesis block
+func (l *Ledger) MerkleTreeVersion() merkleTree.Version {
+	return merkleTree.Version(l.Genesis.GetHeader().GetMerkleTreeVersion())
+}
+
+//GenesisConfig returns the configuration the chain has started with
+func (l *Ledger) GenesisConfig() (*plum.GenesisConfig, error) {
+	return genesis.ConfigOf(l.Genesis)
+}
+
+//SetSnapshotInterval makes the ledger take a snapshot every n heights. 0 turns snapshots off
+func (l *Ledger) SetSnapshotInterval(n uint64) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+	l.snapshotInterval = n
+}
+
+//SetPruneDepth makes the ledger drop bodies of blocks older than n heights, keeping their headers and certificates.
+//only the headers of the last n heights are kept in memory as well. 0 turns pruning off
+func (l *Ledger) SetPruneDepth(n uint64) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+	l.pruneDepth = n
+}
+
+//PrunedHeight returns the height up to which the bodies of blocks are dropped
+func (l *Ledger) PrunedHeight() uint64 {
+	l.rwMutex.RLock()
+	defer l.rwMutex.RUnlock()
+	return l.prunedHeight
+}
+
 func (l *Ledger) Append(b *plum.Block) error {
 	//1) verify prev hash
 	bh := block.Digest(l.CurrentBlockHeader())
@@ -89,15 +146,111 @@ func (l *Ledger) Append(b *plum.Block) error {
 		return errors.New("the block has different previous block hash against the ledger")
 	}
 
+	//merkle tree of the block should be built in the format of the chain
+	if merkleTree.Version(b.Header.GetMerkleTreeVersion()) != l.MerkleTreeVersion() {
+		return fmt.Errorf("the block has merkle tree version %d against %d of the ledger", b.Header.GetMerkleTreeVersion(), l.MerkleTreeVersion())
+	}
+
 	//2) keep header into the ledger
+	l.rwMutex.Lock()
 	l.Headers = append(l.Headers, b.Header)
 	l.Height++
+	l.rwMutex.Unlock()
 
 	//3) save entire block into file system: Marshal/Unmarshal is needed
 	l.toFile(b)
+
+	//4) drop what is older than the prune depth
+	l.prune()
 	return nil
 }
 
+//prune drops bodies of the blocks older than the prune depth from the file system and their headers from memory.
+//the genesis block is never pruned
+func (l *Ledger) prune() {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	if l.pruneDepth == 0 || l.Height <= l.pruneDepth {
+		return
+	}
+	target := l.Height - l.pruneDepth
+
+	for h := l.prunedHeight + 1; h <= target; h++ {
+		if err := l.pruneBlockFile(h); err != nil {
+			logger.Errorf("could not prune block of height %d: %v", h, err)
+			return
+		}
+		l.prunedHeight = h
+	}
+
+	//header of the current height should always be in memory
+	if target > l.base {
+		l.Headers = append([]*plum.Header(nil), l.Headers[target-l.base:]...)
+		l.base = target
+	}
+}
+
+//pruneBlockFile rewrites the block file of the height without its body
+func (l *Ledger) pruneBlockFile(h uint64) error {
+	if l.storeBlock == false {
+		return nil
+	}
+
+	b, err := l.readBlockFile(h)
+	if err != nil {
+		return err
+	}
+	b.Body = nil
+	l.toFile(b)
+	return nil
+}
+
+//AppendWithCertificate appends the block then keeps the certificate which has finalized it along with the block
+func (l *Ledger) AppendWithCertificate(b *plum.Block, c *plum.CommitCertificate) error {
+	if c == nil {
+		return errors.New("the block has no commit certificate")
+	}
+
+	if c.GetHeight() != b.GetHeader().GetId() {
+		return fmt.Errorf("the certificate is of height %d against block of height %d", c.GetHeight(), b.GetHeader().GetId())
+	}
+
+	if bytes.Compare(c.GetBlockDigest(), block.Digest(b.GetHeader())) != 0 {
+		return errors.New("the certificate has different block digest against the block")
+	}
+
+	if err := l.Append(b); err != nil {
+		return err
+	}
+
+	l.certificateToFile(c)
+	return nil
+}
+
+func (l *Ledger) certificateToFile(c *plum.CommitCertificate) {
+	//if ledger options is not to store block, skip
+	if l.storeBlock == false {
+		return
+	}
+
+	m, mErr := proto.Marshal(c)
+	if mErr != nil {
+		logger.Errorf("could not marshal the certificate: %v", mErr)
+	}
+
+	err := ioutil.WriteFile(
+		fmt.Sprintf("%scert-%d.cert", l.path, c.GetHeight()),
+		m,
+		os.FileMode(777),
+	)
+
+	if err != nil {
+		logger.Errorf("could not store the certificate: %v", err)
+		return
+	}
+}
+
 func (l *Ledger) toFile(b *plum.Block) {
 	//if ledger options is not to store block, skip
 	if l.storeBlock == false {
@@ -106,7 +259,7 @@ func (l *Ledger) toFile(b *plum.Block) {
 
 	m, mErr := proto.Marshal(b)
 	if mErr != nil {
-		log.Println("could not marshall the message")
+		logger.Errorf("could not marshal the block: %v", mErr)
 	}
 
 	err := ioutil.WriteFile(
@@ -116,17 +269,131 @@ func (l *Ledger) toFile(b *plum.Block) {
 	)
 
 	if err != nil {
-		log.Println("could not store the block")
+		logger.Errorf("could not store the block: %v", err)
 		return
 	}
 }
 
+//SaveReputationRecord stores the change of reputation made by the block of the height of the record
+func (l *Ledger) SaveReputationRecord(r *plum.ReputationRecord) error {
+	if l.storeBlock == false {
+		return errors.New("the ledger does not store reputation records")
+	}
+
+	if r.GetHeight() == 0 || r.GetHeight() > l.CurrentHeight() {
+		return fmt.Errorf("there is no block of height %d, current height is %d", r.GetHeight(), l.CurrentHeight())
+	}
+
+	m, err := proto.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal the reputation record: %v", err)
+	}
+
+	if err := ioutil.WriteFile(fmt.Sprintf("%srep-%d.rep", l.path, r.GetHeight()), m, os.FileMode(777)); err != nil {
+		return fmt.Errorf("could not store the reputation record: %v", err)
+	}
+	return nil
+}
+
+//GetReputationRecord reads the change of reputation made by the block of the height
+func (l *Ledger) GetReputationRecord(id uint64) (*plum.ReputationRecord, error) {
+	if l.storeBlock == false {
+		return nil, errors.New("the ledger does not store reputation records")
+	}
+
+	if id == 0 || id > l.CurrentHeight() {
+		return nil, fmt.Errorf("there is no block of height %d, current height is %d", id, l.CurrentHeight())
+	}
+
+	rr, err := ioutil.ReadFile(fmt.Sprintf("%srep-%d.rep", l.path, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not read reputation record of %d th block: %v", id, err)
+	}
+
+	r := &plum.ReputationRecord{}
+	if err := proto.Unmarshal(rr, r); err != nil {
+		return nil, fmt.Errorf("could not unmarshal reputation record of %d th block: %v", id, err)
+	}
+	return r, nil
+}
+
+//Recover loads the chain stored in the file system by a previous run, so that the ledger resumes from the height it has stopped at.
+//blocks are read from height 1 until one is missing, each of them should link to the previous one. it returns the recovered height
+func (l *Ledger) Recover() (uint64, error) {
+	if l.storeBlock == false {
+		return 0, nil
+	}
+
+	headers := []*plum.Header{l.Genesis.GetHeader()}
+	var prunedHeight uint64
+	for h := uint64(1); ; h++ {
+		b, err := l.readBlockFile(h)
+		if err != nil {
+			break
+		}
+
+		if !bytes.Equal(b.GetHeader().GetPrevBlockHash(), block.Digest(headers[len(headers)-1])) {
+			return 0, fmt.Errorf("stored block of height %d does not link to the previous one", h)
+		}
+
+		//pruned blocks are at the bottom of the chain
+		if b.GetBody() == nil {
+			prunedHeight = h
+		}
+		headers = append(headers, b.GetHeader())
+	}
+
+	l.rwMutex.Lock()
+	l.Headers = headers
+	l.Height = uint64(len(headers) - 1)
+	l.base = 0
+	l.prunedHeight = prunedHeight
+	l.rwMutex.Unlock()
+
+	//headers below the prune depth are dropped from memory
+	l.prune()
+	return l.Height, nil
+}
+
 func (l *Ledger) CurrentBlockHeader() *plum.Header {
-	return l.Headers[l.Height]
+	l.rwMutex.RLock()
+	defer l.rwMutex.RUnlock()
+	return l.Headers[l.Height-l.base]
+}
+
+//GetHeaderById returns the header of the height, from memory or from the file system if it is pruned
+func (l *Ledger) GetHeaderById(id uint64) (*plum.Header, error) {
+	l.rwMutex.RLock()
+	if id > l.Height {
+		l.rwMutex.RUnlock()
+		return nil, fmt.Errorf("there is no block of height %d, current height is %d", id, l.Height)
+	}
+	if id >= l.base {
+		h := l.Headers[id-l.base]
+		l.rwMutex.RUnlock()
+		return h, nil
+	}
+	l.rwMutex.RUnlock()
+
+	b, err := l.GetBlockById(id)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetHeader(), nil
+}
+
+//CurrentHeight returns the height of the ledger, safe to be called while blocks are being appended
+func (l *Ledger) CurrentHeight() uint64 {
+	l.rwMutex.RLock()
+	defer l.rwMutex.RUnlock()
+	return l.Height
 }
 
 func (l *Ledger) SetHeaders(h []*plum.Header) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
 	l.Headers = h
+	l.base = 0
 	newHeight := uint64(len(h) - 1)
 	if newHeight < 0 {
 		newHeight = 0
@@ -136,18 +403,18 @@ func (l *Ledger) SetHeaders(h []*plum.Header) {
 
 func (l *Ledger) LoadHeaders() []*plum.Header {
 	var headers []*plum.Header
-	log.Println("path: ", l.path)
+	logger.Debugf("load headers from %s", l.path)
 	var i uint64
 	for {
 		rb, err := ioutil.ReadFile(fmt.Sprintf("%sblock-%d.block", l.path, i))
 		if err != nil {
-			log.Println("could not read file")
+			logger.Debugf("no more block file to load: %v", err)
 			break
 		}
 		b := &plum.Block{}
 		mErr := proto.Unmarshal(rb, b)
 		if mErr != nil {
-			log.Fatalf("could not unmarshal properly: %d th block\n", i)
+			logger.Fatalf("could not unmarshal properly: %d th block", i)
 		}
 		headers = append(headers, b.Header)
 		i++
@@ -160,18 +427,207 @@ func LoadGenesisBlock(gbp string) *plum.Block {
 
 	rb, fErr := ioutil.ReadFile(genesisBlockPath)
 	if fErr != nil {
-		log.Fatalf("could not read genesis block properly: %v", fErr)
+		logger.Fatalf("could not read genesis block properly: %v", fErr)
 	}
 	gb := &plum.Block{}
 	mErr := proto.Unmarshal(rb, gb)
 	if mErr != nil {
-		log.Fatalf("could not unmarshal properly: %v", mErr)
+		logger.Fatalf("could not unmarshal properly: %v", mErr)
 	}
 	return gb
 }
 
+//GetBlockById reads a block of the height from the file system. it works only if the ledger stores blocks.
+//a pruned block is returned without its body
 func (l *Ledger) GetBlockById(id uint64) (*plum.Block, error) {
-	return nil, nil
+	if id == 0 {
+		return l.Genesis, nil
+	}
+
+	if l.storeBlock == false {
+		return nil, errors.New("the ledger does not store blocks")
+	}
+
+	if id > l.CurrentHeight() {
+		return nil, fmt.Errorf("there is no block of height %d, current height is %d", id, l.CurrentHeight())
+	}
+
+	return l.readBlockFile(id)
+}
+
+//IsPruned reports whether the body of the block of the height has been dropped
+func (l *Ledger) IsPruned(id uint64) bool {
+	return id != 0 && id <= l.PrunedHeight()
+}
+
+func (l *Ledger) readBlockFile(id uint64) (*plum.Block, error) {
+	rb, err := ioutil.ReadFile(fmt.Sprintf("%sblock-%d.block", l.path, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not read %d th block: %v", id, err)
+	}
+
+	b := &plum.Block{}
+	if err := proto.Unmarshal(rb, b); err != nil {
+		return nil, fmt.Errorf("could not unmarshal %d th block: %v", id, err)
+	}
+	return b, nil
+}
+
+//GetCertificate reads the commit certificate which has finalized the block of the height
+func (l *Ledger) GetCertificate(id uint64) (*plum.CommitCertificate, error) {
+	if id == 0 {
+		return nil, errors.New("genesis block has no commit certificate")
+	}
+
+	if l.storeBlock == false {
+		return nil, errors.New("the ledger does not store certificates")
+	}
+
+	if id > l.CurrentHeight() {
+		return nil, fmt.Errorf("there is no block of height %d, current height is %d", id, l.CurrentHeight())
+	}
+
+	rc, err := ioutil.ReadFile(fmt.Sprintf("%scert-%d.cert", l.path, id))
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate of %d th block: %v", id, err)
+	}
+
+	c := &plum.CommitCertificate{}
+	if err := proto.Unmarshal(rc, c); err != nil {
+		return nil, fmt.Errorf("could not unmarshal certificate of %d th block: %v", id, err)
+	}
+	return c, nil
+}
+
+func (l *Ledger) GetBlockAll() []*plum.Block {
+	var blocks []*plum.Block
+	for i := uint64(0); i <= l.CurrentHeight(); i++ {
+		b, err := l.GetBlockById(i)
+		if err != nil {
+			logger.Errorf("%v", err)
+			break
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks
 }
 
-func (l *Ledger) GetBlockAll() []*plum.Block { return nil }
+//Flush syncs the files of the blocks appended since the last flush, along with their certificates and reputation records
+//and the latest snapshot, to the disk. files are written without sync on append, so it should be called before the peer stops
+func (l *Ledger) Flush() error {
+	if l.storeBlock == false {
+		return nil
+	}
+
+	height := l.CurrentHeight()
+	var paths []string
+	for h := l.flushedHeight + 1; h <= height; h++ {
+		paths = append(paths,
+			fmt.Sprintf("%sblock-%d.block", l.path, h),
+			fmt.Sprintf("%scert-%d.cert", l.path, h),
+			fmt.Sprintf("%srep-%d.rep", l.path, h),
+		)
+	}
+	if heights := l.snapshotHeights(); len(heights) != 0 {
+		paths = append(paths, fmt.Sprintf("%ssnapshot-%d.snapshot", l.path, heights[len(heights)-1]))
+	}
+	//the directory holds the names of the new files
+	paths = append(paths, l.path)
+
+	for _, path := range paths {
+		if err := syncFile(path); err != nil {
+			return fmt.Errorf("could not flush %s: %v", path, err)
+		}
+	}
+	l.flushedHeight = height
+	return nil
+}
+
+//syncFile commits the file to the disk. a file which does not exist is skipped, as a block may have no certificate or record
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+//SnapshotDue reports whether a snapshot should be taken at the current height
+func (l *Ledger) SnapshotDue() bool {
+	l.rwMutex.RLock()
+	defer l.rwMutex.RUnlock()
+	return l.snapshotInterval != 0 && l.Height != 0 && l.Height%l.snapshotInterval == 0
+}
+
+//SaveSnapshot stores the snapshot into the file system, then removes the ones older than the last few
+func (l *Ledger) SaveSnapshot(s *plum.Snapshot) error {
+	if l.storeBlock == false {
+		return errors.New("the ledger does not store snapshots")
+	}
+
+	h, err := l.GetHeaderById(s.GetHeight())
+	if err != nil {
+		return err
+	}
+	if !proto.Equal(h, s.GetHeader()) {
+		return fmt.Errorf("the snapshot has different header against the ledger at height %d", s.GetHeight())
+	}
+
+	m, err := proto.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("could not marshal the snapshot: %v", err)
+	}
+
+	if err := ioutil.WriteFile(fmt.Sprintf("%ssnapshot-%d.snapshot", l.path, s.GetHeight()), m, os.FileMode(777)); err != nil {
+		return fmt.Errorf("could not store the snapshot: %v", err)
+	}
+
+	heights := l.snapshotHeights()
+	for i := 0; i < len(heights)-snapshotsToKeep; i++ {
+		if err := os.Remove(fmt.Sprintf("%ssnapshot-%d.snapshot", l.path, heights[i])); err != nil {
+			logger.Warnf("could not remove the snapshot of height %d: %v", heights[i], err)
+		}
+	}
+	return nil
+}
+
+//LatestSnapshot reads the snapshot of the highest height from the file system
+func (l *Ledger) LatestSnapshot() (*plum.Snapshot, error) {
+	heights := l.snapshotHeights()
+	if len(heights) == 0 {
+		return nil, errors.New("there is no snapshot")
+	}
+
+	rs, err := ioutil.ReadFile(fmt.Sprintf("%ssnapshot-%d.snapshot", l.path, heights[len(heights)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the snapshot: %v", err)
+	}
+
+	s := &plum.Snapshot{}
+	if err := proto.Unmarshal(rs, s); err != nil {
+		return nil, fmt.Errorf("could not unmarshal the snapshot: %v", err)
+	}
+	return s, nil
+}
+
+//snapshotHeights returns heights of the snapshots in the file system in ascending order
+func (l *Ledger) snapshotHeights() []uint64 {
+	files, err := ioutil.ReadDir(l.path)
+	if err != nil {
+		return nil
+	}
+
+	var heights []uint64
+	for _, f := range files {
+		var h uint64
+		if _, err := fmt.Sscanf(f.Name(), "snapshot-%d.snapshot", &h); err == nil {
+			heights = append(heights, h)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
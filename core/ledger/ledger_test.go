@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/ledger/merkleTree"
+	"github.com/yoseplee/plum/core/plum"
 	"github.com/yoseplee/plum/core/util/path"
 	"log"
 	"math/rand"
@@ -31,7 +33,7 @@ func generateTx() [][]byte {
 }
 
 func flushBlocks() {
-	deleteCmd := fmt.Sprintf("%s %s%s", "yes y | rm", os.ExpandEnv("$PLUM_ROOT"), "/ledger_store/*")
+	deleteCmd := fmt.Sprintf("%s %s%s", "rm -rf", os.ExpandEnv("$PLUM_ROOT"), "/ledger_store/*")
 	_, err := exec.Command("/bin/sh", "-c", deleteCmd).Output()
 	if err != nil {
 		log.Fatalf("could not delete all the blocks: %v", err)
@@ -89,3 +91,275 @@ func TestLoadGenesisBlock(t *testing.T) {
 		t.Errorf("invalid genesis block")
 	}
 }
+
+func TestLedger_Append_MerkleTreeVersion(t *testing.T) {
+	el := NewLedger(path.GetInstance().LedgerPath, path.GetInstance().GenesisBlockPath, false)
+	ph := block.Digest(el.CurrentBlockHeader())
+
+	//the genesis block decides the version of the chain
+	other := merkleTree.RFC6962
+	if el.MerkleTreeVersion() == other {
+		other = merkleTree.Legacy
+	}
+	if err := el.Append(block.NewVersionedBlock(generateTx(), ph, el.Height+1, other)); err == nil {
+		t.Errorf("a block with different merkle tree version should not be appended")
+	}
+
+	if err := el.Append(block.NewVersionedBlock(generateTx(), ph, el.Height+1, el.MerkleTreeVersion())); err != nil {
+		t.Errorf("could not append properly: %v", err)
+	}
+}
+
+func TestLedger_GetBlockById(t *testing.T) {
+	ph := block.Digest(l.CurrentBlockHeader())
+	b := block.NewBlock(generateTx(), ph, l.Height+1)
+	if err := l.Append(b); err != nil {
+		t.Errorf("could not append properly: %v", err)
+	}
+
+	got, err := l.GetBlockById(l.Height)
+	if err != nil {
+		t.Errorf("could not get block by id: %v", err)
+	}
+	if !block.CompareBlock(b, got) {
+		t.Errorf("invalid block read from the ledger")
+	}
+
+	if _, err := l.GetBlockById(l.Height + 1); err == nil {
+		t.Errorf("a block higher than the ledger should not be read")
+	}
+
+	//a ledger which does not store blocks
+	el := NewLedger(path.GetInstance().LedgerPath, path.GetInstance().GenesisBlockPath, false)
+	if g, err := el.GetBlockById(0); err != nil || g.GetHeader().GetId() != 0 {
+		t.Errorf("genesis block should be always read")
+	}
+	if _, err := el.GetBlockById(1); err == nil {
+		t.Errorf("a ledger without storing blocks should not read blocks")
+	}
+}
+
+func TestLedger_AppendWithCertificate(t *testing.T) {
+	ph := block.Digest(l.CurrentBlockHeader())
+	b := block.NewVersionedBlock(generateTx(), ph, l.Height+1, l.MerkleTreeVersion())
+
+	if err := l.AppendWithCertificate(b, nil); err == nil {
+		t.Errorf("a block without certificate should not be appended")
+	}
+
+	if err := l.AppendWithCertificate(b, &plum.CommitCertificate{Height: b.Header.Id + 1, BlockDigest: block.Digest(b.Header)}); err == nil {
+		t.Errorf("a block with certificate of different height should not be appended")
+	}
+
+	if err := l.AppendWithCertificate(b, &plum.CommitCertificate{Height: b.Header.Id, BlockDigest: ph}); err == nil {
+		t.Errorf("a block with certificate of different digest should not be appended")
+	}
+
+	c := &plum.CommitCertificate{
+		Height:      b.Header.Id,
+		BlockDigest: block.Digest(b.Header),
+		PbftCommits: []*plum.PBFTRequest{{Message: &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTCommit, PeerId: 1}}},
+	}
+	if err := l.AppendWithCertificate(b, c); err != nil {
+		t.Errorf("could not append properly: %v", err)
+	}
+
+	got, err := l.GetCertificate(l.Height)
+	if err != nil {
+		t.Errorf("could not get certificate: %v", err)
+	}
+	if !proto.Equal(c, got) {
+		t.Errorf("invalid certificate read from the ledger")
+	}
+
+	if _, err := l.GetCertificate(0); err == nil {
+		t.Errorf("genesis block should not have certificate")
+	}
+}
+
+func TestLedger_Prune(t *testing.T) {
+	pl := NewLedger(fmt.Sprintf("%sprune/", path.GetInstance().LedgerPath), path.GetInstance().GenesisBlockPath, true)
+	depth := uint64(5)
+	pl.SetPruneDepth(depth)
+
+	for i := 0; i < 20; i++ {
+		b := block.NewVersionedBlock(generateTx(), block.Digest(pl.CurrentBlockHeader()), pl.Height+1, pl.MerkleTreeVersion())
+		if err := pl.Append(b); err != nil {
+			t.Fatalf("could not append properly: %v", err)
+		}
+	}
+
+	if got, want := pl.PrunedHeight(), pl.Height-depth; got != want {
+		t.Errorf("invalid pruned height. got: %d, want: %d", got, want)
+	}
+	if got, want := uint64(len(pl.Headers)), depth+1; got != want {
+		t.Errorf("invalid number of headers in memory. got: %d, want: %d", got, want)
+	}
+
+	for i := uint64(1); i <= pl.Height; i++ {
+		b, err := pl.GetBlockById(i)
+		if err != nil {
+			t.Fatalf("could not get block of height %d: %v", i, err)
+		}
+		if pruned := len(b.GetBody().GetTxs()) == 0; pruned != pl.IsPruned(i) {
+			t.Errorf("block of height %d should be pruned: %v", i, pl.IsPruned(i))
+		}
+
+		h, err := pl.GetHeaderById(i)
+		if err != nil {
+			t.Fatalf("could not get header of height %d: %v", i, err)
+		}
+		if h.GetId() != i {
+			t.Errorf("invalid header. got: %d, want: %d", h.GetId(), i)
+		}
+	}
+
+	//headers loaded from the file system chain up to the genesis block
+	headers := pl.LoadHeaders()
+	for i := 1; i < len(headers); i++ {
+		if bytes.Compare(headers[i].GetPrevBlockHash(), block.Digest(headers[i-1])) != 0 {
+			t.Errorf("header of height %d does not chain to the previous one after pruning", i)
+		}
+	}
+}
+
+func TestLedger_Snapshot(t *testing.T) {
+	sl := NewLedger(fmt.Sprintf("%ssnapshot/", path.GetInstance().LedgerPath), path.GetInstance().GenesisBlockPath, true)
+	sl.SetSnapshotInterval(3)
+
+	if _, err := sl.LatestSnapshot(); err == nil {
+		t.Errorf("there should be no snapshot yet")
+	}
+
+	for i := 0; i < 10; i++ {
+		b := block.NewVersionedBlock(generateTx(), block.Digest(sl.CurrentBlockHeader()), sl.Height+1, sl.MerkleTreeVersion())
+		if err := sl.Append(b); err != nil {
+			t.Fatalf("could not append properly: %v", err)
+		}
+		if !sl.SnapshotDue() {
+			continue
+		}
+		s := &plum.Snapshot{
+			Height:         sl.Height,
+			Header:         sl.CurrentBlockHeader(),
+			AppState:       &plum.AppState{ConsensusRound: sl.Height},
+			ReputationBook: map[uint32]float64{0: float64(sl.Height)},
+		}
+		if err := sl.SaveSnapshot(s); err != nil {
+			t.Fatalf("could not save the snapshot: %v", err)
+		}
+	}
+
+	s, err := sl.LatestSnapshot()
+	if err != nil {
+		t.Fatalf("could not load the latest snapshot: %v", err)
+	}
+	if s.GetHeight() != 9 || s.GetAppState().GetConsensusRound() != 9 || s.GetReputationBook()[0] != 9 {
+		t.Errorf("invalid latest snapshot: %v", s)
+	}
+	if got := len(sl.snapshotHeights()); got != snapshotsToKeep {
+		t.Errorf("invalid number of snapshots kept. got: %d, want: %d", got, snapshotsToKeep)
+	}
+
+	//snapshot should be of the chain of the ledger
+	forged := &plum.Snapshot{Height: 9, Header: sl.Genesis.GetHeader()}
+	if err := sl.SaveSnapshot(forged); err == nil {
+		t.Errorf("snapshot with different header should not be saved")
+	}
+}
+
+func TestLedger_ReputationRecord(t *testing.T) {
+	rl := NewLedger(fmt.Sprintf("%sreputation/", path.GetInstance().LedgerPath), path.GetInstance().GenesisBlockPath, true)
+	b := block.NewVersionedBlock(generateTx(), block.Digest(rl.CurrentBlockHeader()), rl.Height+1, rl.MerkleTreeVersion())
+	if err := rl.Append(b); err != nil {
+		t.Fatalf("could not append properly: %v", err)
+	}
+
+	r := &plum.ReputationRecord{
+		Height: 1,
+		Events: []*plum.ReputationEvent{{PeerId: 1, Type: plum.ReputationEventType_ReputationIncrease, Before: 1.0, After: 1.01}},
+	}
+	if err := rl.SaveReputationRecord(r); err != nil {
+		t.Fatalf("could not save the reputation record: %v", err)
+	}
+	got, err := rl.GetReputationRecord(1)
+	if err != nil {
+		t.Fatalf("could not get the reputation record: %v", err)
+	}
+	if !proto.Equal(got, r) {
+		t.Errorf("invalid reputation record. got: %v, want: %v", got, r)
+	}
+
+	//record should be of a block in the ledger
+	if err := rl.SaveReputationRecord(&plum.ReputationRecord{Height: 2}); err == nil {
+		t.Errorf("reputation record beyond the height should not be saved")
+	}
+	if _, err := rl.GetReputationRecord(0); err == nil {
+		t.Errorf("genesis block should have no reputation record")
+	}
+}
+
+func TestLedger_Recover(t *testing.T) {
+	rp := fmt.Sprintf("%srecover/", path.GetInstance().LedgerPath)
+	rl := NewLedger(rp, path.GetInstance().GenesisBlockPath, true)
+	rl.SetPruneDepth(3)
+	for i := 0; i < 10; i++ {
+		b := block.NewVersionedBlock(generateTx(), block.Digest(rl.CurrentBlockHeader()), rl.Height+1, rl.MerkleTreeVersion())
+		if err := rl.Append(b); err != nil {
+			t.Fatalf("could not append properly: %v", err)
+		}
+	}
+
+	//a ledger made on the same path again should resume from the stored chain
+	restarted := NewLedger(rp, path.GetInstance().GenesisBlockPath, true)
+	height, err := restarted.Recover()
+	if err != nil {
+		t.Fatalf("could not recover the ledger: %v", err)
+	}
+	if height != rl.Height || restarted.CurrentHeight() != rl.Height {
+		t.Errorf("invalid recovered height. got: %d, want: %d", height, rl.Height)
+	}
+	if !proto.Equal(restarted.CurrentBlockHeader(), rl.CurrentBlockHeader()) {
+		t.Errorf("recovered ledger has different current header")
+	}
+	if got, want := restarted.PrunedHeight(), rl.PrunedHeight(); got != want {
+		t.Errorf("invalid recovered pruned height. got: %d, want: %d", got, want)
+	}
+
+	//appending continues on top of the recovered chain
+	b := block.NewVersionedBlock(generateTx(), block.Digest(restarted.CurrentBlockHeader()), restarted.Height+1, restarted.MerkleTreeVersion())
+	if err := restarted.Append(b); err != nil {
+		t.Errorf("could not append on the recovered ledger: %v", err)
+	}
+
+	//a broken link is not recovered
+	tampered, _ := rl.readBlockFile(5)
+	tampered.Header.PrevBlockHash = []byte("tampered")
+	rl.toFile(tampered)
+	if _, err := NewLedger(rp, path.GetInstance().GenesisBlockPath, true).Recover(); err == nil {
+		t.Errorf("chain with a broken link should not be recovered")
+	}
+}
+
+func TestLedger_Flush(t *testing.T) {
+	fp := fmt.Sprintf("%sflush/", path.GetInstance().LedgerPath)
+	fl := NewLedger(fp, path.GetInstance().GenesisBlockPath, true)
+	for i := 0; i < 3; i++ {
+		b := block.NewVersionedBlock(generateTx(), block.Digest(fl.CurrentBlockHeader()), fl.Height+1, fl.MerkleTreeVersion())
+		if err := fl.Append(b); err != nil {
+			t.Fatalf("could not append properly: %v", err)
+		}
+	}
+
+	//blocks without certificates nor records are flushed as well
+	if err := fl.Flush(); err != nil {
+		t.Fatalf("could not flush the ledger: %v", err)
+	}
+	if fl.flushedHeight != fl.CurrentHeight() {
+		t.Errorf("invalid flushed height. got: %d, want: %d", fl.flushedHeight, fl.CurrentHeight())
+	}
+
+	if err := NewLedger(fp, path.GetInstance().GenesisBlockPath, false).Flush(); err != nil {
+		t.Errorf("ledger which does not store blocks has nothing to flush: %v", err)
+	}
+}
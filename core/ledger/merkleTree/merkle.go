@@ -2,29 +2,63 @@ package merkleTree
 
 import (
 	"crypto/sha256"
-	"github.com/yoseplee/plum/core/plum"
 	"math"
 )
 
-func NewNode(l, r *plum.MerkleNode, d []byte) *plum.MerkleNode {
-	var n plum.MerkleNode
-	hash := sha256.New()
+//Version is the format of a merkle tree. a chain picks one in its genesis block and keeps it
+type Version uint32
 
-	if l == nil && r == nil {
-		n.L = nil
-		n.R = nil
-		hash.Write(d)
-		n.D = hash.Sum(nil)
-	} else {
-		n.L = l
-		n.R = r
+const (
+	//Legacy hashes leaves and inner nodes the same way and duplicates the last leaf on odd counts.
+	//different lists of data can end up with the same root, so it is only kept to verify the chains made with it
+	Legacy Version = 0
+	//RFC6962 prefixes leaves with 0x00 and inner nodes with 0x01, and promotes an unpaired node without duplicating it
+	RFC6962 Version = 1
+)
 
-		hash.Write(l.D)
-		hash.Write(r.D)
-		n.D = hash.Sum(nil)
-	}
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+//Tree is a flat, array-based merkle tree.
+//all the nodes are kept in a single slice level by level, leaves first and the root last.
+//levels holds the offset of the first node of each level in nodes.
+type Tree struct {
+	version Version
+	nodes   [][]byte
+	levels  []int
+}
+
+//hashLeaf hashes a datum into a terminal node
+func hashLeaf(d []byte) []byte {
+	h := sha256.Sum256(d)
+	return h[:]
+}
+
+//hashNode hashes the two children into an inner node
+func hashNode(l, r []byte) []byte {
+	hash := sha256.New()
+	hash.Write(l)
+	hash.Write(r)
+	return hash.Sum(nil)
+}
+
+//hashRFC6962Leaf hashes a datum into a terminal node with the leaf prefix
+func hashRFC6962Leaf(d []byte) []byte {
+	hash := sha256.New()
+	hash.Write([]byte{leafPrefix})
+	hash.Write(d)
+	return hash.Sum(nil)
+}
 
-	return &n
+//hashRFC6962Node hashes the two children into an inner node with the inner node prefix
+func hashRFC6962Node(l, r []byte) []byte {
+	hash := sha256.New()
+	hash.Write([]byte{nodePrefix})
+	hash.Write(l)
+	hash.Write(r)
+	return hash.Sum(nil)
 }
 
 func appendLast(d [][]byte) [][]byte {
@@ -41,39 +75,111 @@ func isEven(d [][]byte) bool {
 	}
 }
 
-func NewTree(d [][]byte) *plum.MerkleTree {
-	var t plum.MerkleTree
-	var n []plum.MerkleNode
+//Valid reports whether v is a known version of the tree
+func (v Version) Valid() bool {
+	switch v {
+	case Legacy, RFC6962:
+		return true
+	default:
+		return false
+	}
+}
+
+func (v Version) String() string {
+	switch v {
+	case Legacy:
+		return "Legacy"
+	case RFC6962:
+		return "RFC6962"
+	default:
+		return "Unknown"
+	}
+}
+
+//NewTree builds a tree over d in the Legacy format.
+func NewTree(d [][]byte) *Tree {
+	return NewVersionedTree(d, Legacy)
+}
+
+//NewVersionedTree builds a tree over d in the format of v.
+//on upper levels, a node without its pair is carried up to the next level as it is.
+//for RFC6962, this is equivalent to splitting the data at the largest power of two smaller than its size.
+func NewVersionedTree(d [][]byte, v Version) *Tree {
+	t := &Tree{version: v}
 
-	if d == nil {
-		t.Root = &plum.MerkleNode{}
-		return &t
+	if len(d) == 0 {
+		return t
 	}
 
-	if !isEven(d) {
+	leaf, node := hashLeaf, hashNode
+	if v == RFC6962 {
+		leaf, node = hashRFC6962Leaf, hashRFC6962Node
+	} else if !isEven(d) {
 		d = appendLast(d)
 	}
 
+	t.nodes = make([][]byte, 0, 2*len(d))
+	t.levels = append(t.levels, 0)
 	for _, datum := range d {
-		n = append(n, *NewNode(nil, nil, datum))
+		t.nodes = append(t.nodes, leaf(datum))
 	}
 
 	for {
-		var level []plum.MerkleNode
-		if len(n) == 1 {
+		start := t.levels[len(t.levels)-1]
+		end := len(t.nodes)
+		if end-start == 1 {
 			break
 		}
 
-		for i := 0; i < len(n); i += 2 {
-			if len(n) == i+1 {
-				level = append(level, n[i])
+		t.levels = append(t.levels, end)
+		for i := start; i < end; i += 2 {
+			if end == i+1 {
+				t.nodes = append(t.nodes, t.nodes[i])
 				continue
 			}
-			level = append(level, *NewNode(&n[i], &n[i+1], nil))
+			t.nodes = append(t.nodes, node(t.nodes[i], t.nodes[i+1]))
+		}
+	}
+
+	return t
+}
+
+//Version returns the format of the tree
+func (t *Tree) Version() Version {
+	return t.version
+}
+
+//Root returns the root hash of the tree.
+//for a tree without data, it is nil in the Legacy format and the hash of an empty string in RFC6962
+func (t *Tree) Root() []byte {
+	if len(t.nodes) == 0 {
+		if t.version == RFC6962 {
+			h := sha256.Sum256(nil)
+			return h[:]
 		}
-		n = level
+		return nil
+	}
+	return t.nodes[len(t.nodes)-1]
+}
+
+//Depth returns the number of levels in the tree including leaves and the root
+func (t *Tree) Depth() int {
+	return len(t.levels)
+}
+
+//Level returns hashes at the i th level, where level 0 is the leaves
+func (t *Tree) Level(i int) [][]byte {
+	if i < 0 || i >= len(t.levels) {
+		return nil
 	}
+	end := len(t.nodes)
+	if i+1 < len(t.levels) {
+		end = t.levels[i+1]
+	}
+	return t.nodes[t.levels[i]:end]
+}
 
-	t.Root = &n[0]
-	return &t
+//Leaves returns hashes of the terminal nodes
+func (t *Tree) Leaves() [][]byte {
+	return t.Level(0)
 }
@@ -3,7 +3,7 @@ package merkleTree
 import (
 	"bytes"
 	"crypto/sha256"
-	"github.com/yoseplee/plum/core/plum"
+	"encoding/hex"
 	"reflect"
 	"testing"
 )
@@ -24,36 +24,25 @@ func Test_Init(t *testing.T) {
 	}
 }
 
-func TestNewNode(t *testing.T) {
-	sha := sha256.New()
-	want := &plum.MerkleNode{
-		L: nil,
-		R: nil,
-	}
-	sha.Write(tData)
-	data := sha.Sum(nil)
-	want.D = data
-	sha.Reset()
-
-	if got := NewNode(nil, nil, tData); reflect.DeepEqual(got, want) == false {
+func TestHashLeaf(t *testing.T) {
+	want := sha256.Sum256(tData)
+	if got := hashLeaf(tData); bytes.Compare(got, want[:]) != 0 {
 		t.Errorf("failed to make a new terminal node\n")
 	}
+}
 
-	want = &plum.MerkleNode{
-		L: &plum.MerkleNode{L: nil, R: nil, D: sha.Sum(tData)},
-		R: &plum.MerkleNode{L: nil, R: nil, D: sha.Sum(tData)},
-	}
-	sha.Write(want.L.D)
-	sha.Write(want.R.D)
-	want.D = sha.Sum(nil)
-	sha.Reset()
-
-	got := NewNode(&plum.MerkleNode{L: nil, R: nil, D: sha.Sum(tData)}, &plum.MerkleNode{L: nil, R: nil, D: sha.Sum(tData)}, tData)
+func TestHashNode(t *testing.T) {
+	sha := sha256.New()
+	l := hashLeaf(tData)
+	r := hashLeaf(tData)
+	sha.Write(l)
+	sha.Write(r)
+	want := sha.Sum(nil)
 
-	if reflect.DeepEqual(got, want) != true {
+	if got := hashNode(l, r); bytes.Compare(got, want) != 0 {
 		t.Errorf("failed to make a new node\n")
-		t.Errorf("want: %s\n", want.String())
-		t.Errorf("got: %s\n", got.String())
+		t.Errorf("want: %s\n", hex.EncodeToString(want))
+		t.Errorf("got: %s\n", hex.EncodeToString(got))
 	}
 }
 
@@ -90,7 +79,7 @@ func TestNewTreeTwoNodes(t *testing.T) {
 
 	tMem := [][]byte{[]byte("a"), []byte("b")}
 	tree := NewTree(tMem)
-	got := tree.Root.D
+	got := tree.Root()
 
 	if bytes.Compare(want, got) != 0 {
 		t.Errorf("invalid root of the tree")
@@ -137,7 +126,7 @@ func TestNewTreeFiveNodes(t *testing.T) {
 	want := root
 
 	tree := NewTree(mempool)
-	got := tree.Root.D
+	got := tree.Root()
 
 	if bytes.Compare(want, got) != 0 {
 		t.Errorf("invaild root of the tree")
@@ -149,7 +138,155 @@ func TestNewTreeNilTxs(t *testing.T) {
 		t.Errorf("failed to make a new tree when data is empty")
 	}
 
-	if got := NewTree(nil).Root; got == nil {
-		t.Errorf("failed to make a new tree when data is empty")
+	if got := NewTree(nil).Root(); got != nil {
+		t.Errorf("root of an empty tree should be nil")
+	}
+}
+
+func TestTree_Level(t *testing.T) {
+	tree := NewTree(mempool)
+
+	//5 data -> 6 leaves(last one duplicated) -> 3 -> 2 -> 1
+	wantDepth := 4
+	if got := tree.Depth(); got != wantDepth {
+		t.Errorf("invalid depth of the tree. got: %d, want: %d", got, wantDepth)
+	}
+
+	wantWidths := []int{6, 3, 2, 1}
+	for i, want := range wantWidths {
+		if got := len(tree.Level(i)); got != want {
+			t.Errorf("invalid width of level %d. got: %d, want: %d", i, got, want)
+		}
+	}
+
+	for i, leaf := range tree.Leaves()[:len(hMempool)] {
+		if bytes.Compare(leaf, hMempool[i]) != 0 {
+			t.Errorf("invalid leaf at %d", i)
+		}
+	}
+
+	if bytes.Compare(tree.Level(tree.Depth() - 1)[0], tree.Root()) != 0 {
+		t.Errorf("the last level should be the root")
+	}
+
+	if got := tree.Level(tree.Depth()); got != nil {
+		t.Errorf("level out of range should be nil")
+	}
+}
+
+//rfc6962Root is the recursive definition of MTH in RFC 6962 section 2.1, used as the reference of the flat tree
+func rfc6962Root(d [][]byte) []byte {
+	switch len(d) {
+	case 0:
+		h := sha256.Sum256(nil)
+		return h[:]
+	case 1:
+		return hashRFC6962Leaf(d[0])
+	}
+	k := 1
+	for k*2 < len(d) {
+		k *= 2
+	}
+	return hashRFC6962Node(rfc6962Root(d[:k]), rfc6962Root(d[k:]))
+}
+
+func TestNewVersionedTree_LegacyVectors(t *testing.T) {
+	//the Legacy format should keep producing the roots of the chains made before versioning
+	two := [][]byte{[]byte("a"), []byte("b")}
+	if bytes.Compare(NewVersionedTree(two, Legacy).Root(), NewTree(two).Root()) != 0 {
+		t.Errorf("legacy tree differs from the unversioned tree")
+	}
+
+	//same vector with TestNewTreeFiveNodes
+	l := hashNode(hashNode(hMempool[0], hMempool[1]), hashNode(hMempool[2], hMempool[3]))
+	e := hashNode(hMempool[4], hMempool[4])
+	want := hashNode(l, e)
+	if got := NewVersionedTree(mempool, Legacy).Root(); bytes.Compare(want, got) != 0 {
+		t.Errorf("invalid root of the legacy tree")
+	}
+
+	if got := NewVersionedTree(nil, Legacy).Root(); got != nil {
+		t.Errorf("root of an empty legacy tree should be nil")
+	}
+}
+
+func TestNewVersionedTree_RFC6962Vectors(t *testing.T) {
+	//test vectors of certificate transparency
+	leaves := [][]byte{
+		{},
+		{0x00},
+		{0x10},
+		{0x20, 0x21},
+		{0x30, 0x31},
+		{0x40, 0x41, 0x42, 0x43},
+		{0x50, 0x51, 0x52, 0x53, 0x54, 0x55, 0x56, 0x57},
+		{0x60, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f},
+	}
+	roots := []string{
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+
+	for n, want := range roots {
+		if got := hex.EncodeToString(NewVersionedTree(leaves[:n], RFC6962).Root()); got != want {
+			t.Errorf("invalid root of %d leaves. got: %s, want: %s", n, got, want)
+		}
+	}
+}
+
+func TestNewVersionedTree_RFC6962Unbalanced(t *testing.T) {
+	var d [][]byte
+	for i := 0; i < 70; i++ {
+		d = append(d, []byte{byte(i)})
+		want := rfc6962Root(d)
+		if got := NewVersionedTree(d, RFC6962).Root(); bytes.Compare(want, got) != 0 {
+			t.Errorf("flat tree differs from the recursive definition for %d leaves", len(d))
+		}
+	}
+}
+
+func TestNewVersionedTree_SecondPreimage(t *testing.T) {
+	abc := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	abcc := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("c")}
+
+	//odd number of leaves: the duplicated last leaf collides in Legacy
+	if bytes.Compare(NewVersionedTree(abc, Legacy).Root(), NewVersionedTree(abcc, Legacy).Root()) != 0 {
+		t.Errorf("legacy tree is expected to collide on the duplicated leaf")
+	}
+	if bytes.Compare(NewVersionedTree(abc, RFC6962).Root(), NewVersionedTree(abcc, RFC6962).Root()) == 0 {
+		t.Errorf("RFC6962 tree should not collide on the duplicated leaf")
+	}
+
+	//inner nodes as leaves: concatenated children collide in Legacy
+	abcd := [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")}
+	for _, v := range []Version{Legacy, RFC6962} {
+		level := NewVersionedTree(abcd, v).Leaves()
+		forged := [][]byte{
+			append(append([]byte{}, level[0]...), level[1]...),
+			append(append([]byte{}, level[2]...), level[3]...),
+		}
+		collided := bytes.Compare(NewVersionedTree(abcd, v).Root(), NewVersionedTree(forged, v).Root()) == 0
+		if v == Legacy && !collided {
+			t.Errorf("legacy tree is expected to collide on inner nodes given as leaves")
+		}
+		if v == RFC6962 && collided {
+			t.Errorf("RFC6962 tree should not collide on inner nodes given as leaves")
+		}
+	}
+}
+
+func TestVersion_Valid(t *testing.T) {
+	if !Legacy.Valid() || !RFC6962.Valid() {
+		t.Errorf("known versions should be valid")
+	}
+	if Version(2).Valid() {
+		t.Errorf("unknown version should not be valid")
 	}
 }
@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Level is the severity of a log. logs under the level set by SetLevel are dropped
+type Level int32
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+	Fatal
+)
+
+var levelNames = map[Level]string{
+	Debug: "debug",
+	Info:  "info",
+	Warn:  "warn",
+	Error: "error",
+	Fatal: "fatal",
+}
+
+func (l Level) String() string {
+	if n, ok := levelNames[l]; ok {
+		return n
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+//ParseLevel returns the level of the name such as debug or info
+func ParseLevel(name string) (Level, error) {
+	for l, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return l, nil
+		}
+	}
+	return Info, fmt.Errorf("unknown log level: %s", name)
+}
+
+//Fields are the key-value pairs attached to a log
+type Fields map[string]interface{}
+
+var (
+	level                = int32(Info)
+	jsonFormat           = int32(0)
+	mutex                = &sync.Mutex{}
+	out        io.Writer = os.Stderr
+)
+
+//SetLevel changes the level of logs to be written, which can be done while the peer runs
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+//GetLevel returns the current level of logs
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&level))
+}
+
+//SetJSON makes logs written as a json object per line, otherwise as text
+func SetJSON(on bool) {
+	v := int32(0)
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&jsonFormat, v)
+}
+
+//SetOutput changes where logs are written, which is stderr by default
+func SetOutput(w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	out = w
+}
+
+//Logger writes logs with its fields
+type Logger struct {
+	fields Fields
+}
+
+//With returns a logger with the fields
+func With(fields Fields) *Logger {
+	return (&Logger{}).With(fields)
+}
+
+//With returns a logger with the fields added to the ones of this logger
+func (l *Logger) With(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Logger{fields: merged}
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.write(Debug, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.write(Info, format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.write(Warn, format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.write(Error, format, args...)
+}
+
+//Fatalf writes the log then exits the process
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.write(Fatal, format, args...)
+	os.Exit(1)
+}
+
+//Panicf writes the log as an error then panics with the message
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	l.write(Error, format, args...)
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) write(lv Level, format string, args ...interface{}) {
+	if lv < GetLevel() {
+		return
+	}
+
+	msg := strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+	var line []byte
+	if atomic.LoadInt32(&jsonFormat) == 1 {
+		line = l.json(time.Now(), lv, msg)
+	} else {
+		line = l.text(time.Now(), lv, msg)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	out.Write(line)
+}
+
+func (l *Logger) json(t time.Time, lv Level, msg string) []byte {
+	entry := make(map[string]interface{}, len(l.fields)+3)
+	for k, v := range l.fields {
+		entry[k] = v
+	}
+	entry["time"] = t.Format(time.RFC3339Nano)
+	entry["level"] = lv.String()
+	entry["msg"] = msg
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{"time": entry["time"], "level": entry["level"], "msg": msg})
+	}
+	return append(b, '\n')
+}
+
+func (l *Logger) text(t time.Time, lv Level, msg string) []byte {
+	var sb strings.Builder
+	sb.WriteString(t.Format("2006/01/02 15:04:05.000000"))
+	sb.WriteString(fmt.Sprintf(" %-5s %s", strings.ToUpper(lv.String()), msg))
+
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf(" %s=%v", k, l.fields[k]))
+	}
+	sb.WriteString("\n")
+	return []byte(sb.String())
+}
+
+//std is the logger without fields, used by the package level functions
+var std = &Logger{}
+
+func Debugf(format string, args ...interface{}) {
+	std.write(Debug, format, args...)
+}
+
+func Infof(format string, args ...interface{}) {
+	std.write(Info, format, args...)
+}
+
+func Warnf(format string, args ...interface{}) {
+	std.write(Warn, format, args...)
+}
+
+func Errorf(format string, args ...interface{}) {
+	std.write(Error, format, args...)
+}
+
+//Fatalf writes the log then exits the process
+func Fatalf(format string, args ...interface{}) {
+	std.write(Fatal, format, args...)
+	os.Exit(1)
+}
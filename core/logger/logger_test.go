@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for l, name := range levelNames {
+		got, err := ParseLevel(strings.ToUpper(name))
+		if err != nil || got != l {
+			t.Errorf("invalid level of %s. got: %v, want: %v", name, got, l)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("unknown level should be rejected")
+	}
+}
+
+func TestLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+	defer SetLevel(GetLevel())
+
+	SetLevel(Warn)
+	Infof("dropped")
+	Warnf("written")
+	if got := buf.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "written") {
+		t.Errorf("logs under the level should be dropped. got: %s", got)
+	}
+
+	buf.Reset()
+	SetLevel(Debug)
+	Debugf("written")
+	if buf.Len() == 0 {
+		t.Errorf("debug log should be written after the level is changed")
+	}
+}
+
+func TestLogger_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetJSON(true)
+	defer SetOutput(os.Stderr)
+	defer SetJSON(false)
+
+	l := With(Fields{"peer": 1, "round": 2}).With(Fields{"round": 3})
+	l.Infof("append block of height %d", 4)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log is not a json object: %v, %s", err, buf.String())
+	}
+	want := map[string]interface{}{"peer": 1.0, "round": 3.0, "level": "info", "msg": "append block of height 4"}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("invalid %s of the log. got: %v, want: %v", k, entry[k], v)
+		}
+	}
+}
+
+func TestLogger_Text(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+
+	With(Fields{"round": 3, "peer": 1}).Warnf("committee size is lacking\n")
+	if got := buf.String(); !strings.HasSuffix(got, "WARN  committee size is lacking peer=1 round=3\n") {
+		t.Errorf("invalid text log. got: %q", got)
+	}
+}
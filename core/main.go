@@ -3,12 +3,18 @@ package main
 import (
 	"flag"
 	"fmt"
+	"github.com/yoseplee/plum/core/logger"
+	"github.com/yoseplee/plum/core/metrics"
 	"github.com/yoseplee/plum/core/peer"
+	"github.com/yoseplee/plum/core/trace"
 	"github.com/yoseplee/plum/core/util"
 	"github.com/yoseplee/plum/core/util/path"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 var (
@@ -18,6 +24,15 @@ var (
 	dockerModeFlag    = flag.Bool("docker", true, "option for docker environment")
 	peerAmountFlag    = flag.Int("amount", -1, "set amount of peers participating in consensus")
 	consensusTypeFlag = flag.String("consensus", "XBFT", "option for consensusType")
+	snapshotFlag      = flag.Uint64("snapshot", 0, "take a snapshot every given heights, 0 to disable")
+	pruneFlag         = flag.Uint64("prune", 0, "drop bodies of blocks older than given heights, 0 to disable")
+	keyFlag           = flag.String("key", "", "path to the private key of this peer made by the keygen command")
+	quorumFlag        = flag.Int("quorum", 0, "number of ready validators to start consensus, 0 for 2f+1")
+	metricsFlag       = flag.String("metrics", "", "address to serve prometheus metrics such as :9090, empty to disable")
+	logLevelFlag      = flag.String("loglevel", "info", "level of logs: debug / info / warn / error")
+	logJSONFlag       = flag.Bool("logjson", false, "write logs as a json object per line")
+	traceFlag         = flag.String("trace", "", "path to the file to write spans of consensus phases, empty to disable")
+	msgLogFlag        = flag.Uint64("msglog", peer.DefaultMessageLogRetention, "keep consensus messages of given heights below the committed height, 0 to keep all")
 )
 
 type profile struct {
@@ -32,13 +47,13 @@ func loadProfile(pr profile, amount int) map[uint32]*peer.Connection {
 	}
 
 	if amount > len(pr.Profile) || amount < -1 {
-		log.Fatalf("invalid amount: %v, profile length: %v", amount, len(pr.Profile))
+		logger.Fatalf("invalid amount: %v, profile length: %v", amount, len(pr.Profile))
 	}
 
 	profile := make(map[uint32]*peer.Connection)
 
 	if *localOptFlag == true && *dockerModeFlag == false {
-		log.Printf("profile is set for local-dockerless test mode")
+		logger.Infof("profile is set for local-dockerless test mode")
 		basePort := 50051
 		for i := 0; i < amount; i++ {
 			port := fmt.Sprintf(":%d", basePort+(10*i))
@@ -53,7 +68,7 @@ func loadProfile(pr profile, amount int) map[uint32]*peer.Connection {
 	}
 
 	if *localOptFlag == true && *dockerModeFlag == true {
-		log.Println("profile is set for local-docker mode")
+		logger.Infof("profile is set for local-docker mode")
 		port := ":50051"
 		for i := 0; i < amount; i++ {
 			profile[uint32(i)] = &peer.Connection{
@@ -67,7 +82,7 @@ func loadProfile(pr profile, amount int) map[uint32]*peer.Connection {
 	}
 
 	if *localOptFlag == false && *dockerModeFlag == true {
-		log.Printf("profile is set for distributed docker mode")
+		logger.Infof("profile is set for distributed docker mode")
 		for _, p := range pr.Profile {
 			for _, v := range p {
 				mv := v.(map[interface{}]interface{})
@@ -84,7 +99,7 @@ func loadProfile(pr profile, amount int) map[uint32]*peer.Connection {
 	}
 
 	if *localOptFlag == false && *dockerModeFlag == false {
-		log.Printf("profile is set for distributed process mode")
+		logger.Infof("profile is set for distributed process mode")
 		for i, p := range pr.Profile {
 			if i == amount {
 				break
@@ -103,31 +118,41 @@ func loadProfile(pr profile, amount int) map[uint32]*peer.Connection {
 		return profile
 	}
 
-	log.Fatalln("invalid options to make a new profile")
+	logger.Fatalf("invalid options to make a new profile")
 	return nil
 }
 
 func main() {
+	if runCommand(os.Args[1:]) {
+		return
+	}
 
 	flag.Parse()
 
+	level, err := logger.ParseLevel(*logLevelFlag)
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+	logger.SetLevel(level)
+	logger.SetJSON(*logJSONFlag)
+
 	profile := profile{}
 
 	yamlFile, readErr := ioutil.ReadFile(path.GetInstance().ProfilePath)
 	if readErr != nil {
-		log.Fatalf("could not read profile.yaml file: %v", readErr)
+		logger.Fatalf("could not read profile.yaml file: %v", readErr)
 	}
 
 	yamlParseErr := yaml.Unmarshal(yamlFile, &profile)
 	if yamlParseErr != nil {
-		log.Fatalf("could not unmarshal profile.yaml file: %v", yamlParseErr)
+		logger.Fatalf("could not unmarshal profile.yaml file: %v", yamlParseErr)
 	}
 
 	peerInstance := peer.GetInstance()
 
 	ipv4, err := util.GetExternalIP()
 	if err != nil {
-		log.Fatalf("could not get external ip: %v", err)
+		logger.Fatalf("could not get external ip: %v", err)
 	}
 
 	loadedProfile := loadProfile(profile, *peerAmountFlag)
@@ -137,18 +162,42 @@ func main() {
 	}
 
 	//Init the peer
-	peerInstance.InitAndRun(uint32(*idFlag), ipv4, *localPortOpTFlag, loadedProfile, *consensusTypeFlag)
+	peerInstance.Init(uint32(*idFlag), ipv4, *localPortOpTFlag, loadedProfile, *consensusTypeFlag)
+	peerInstance.L.SetSnapshotInterval(*snapshotFlag)
+	peerInstance.L.SetPruneDepth(*pruneFlag)
+	peerInstance.MessageLog.SetRetention(*msgLogFlag)
+	peerInstance.SetReadyQuorum(*quorumFlag)
+	if *keyFlag != "" {
+		key, err := util.LoadPrivateKey(*keyFlag)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+		if err := peerInstance.SetPrivateKey(key); err != nil {
+			logger.Fatalf("%v", err)
+		}
+	}
+	if *traceFlag != "" {
+		e, err := trace.NewFileExporter(*traceFlag)
+		if err != nil {
+			logger.Fatalf("could not open the trace file: %v", err)
+		}
+		trace.SetExporter(e)
+	}
+	if *metricsFlag != "" {
+		go metrics.Serve(*metricsFlag)
+	}
+	peerInstance.Run()
 
 	//logging will be printed via below
 	switch *idFlag {
 	case -1:
-		log.Printf("There is no id for this peer... set default: %d\n", *idFlag)
+		logger.Warnf("there is no id for this peer... set default: %d", *idFlag)
 	default:
-		log.Printf("peer id is set to: %d\n", peerInstance.ID)
+		logger.Infof("peer id is set to: %d", peerInstance.ID)
 	}
 
-	log.Println("on ipv4: ", peerInstance.Ipv4)
-	log.Println("on port: ", peerInstance.Port)
+	logger.Infof("on ipv4: %s", peerInstance.Ipv4)
+	logger.Infof("on port: %s", peerInstance.Port)
 
 	//go func() {
 	//	for {
@@ -159,5 +208,15 @@ func main() {
 
 	s := peer.NewServer()
 	s.RegisterServers()
-	s.Run()
+	go s.Run()
+
+	//stop gracefully on a signal, so that the peer can be started again with the same id and resume from the disk
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	logger.Infof("got signal %v", <-sig)
+	s.GracefulStop(time.Second * 5)
+	peerInstance.Stop()
+	if err := trace.Shutdown(); err != nil {
+		logger.Errorf("could not close the trace file: %v", err)
+	}
 }
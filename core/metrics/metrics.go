@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yoseplee/plum/core/logger"
+	"net/http"
+)
+
+const namespace = "plum"
+
+//labels of the metrics. consensus is PBFT or XBFT, and phase is the name of the phase of the message
+const (
+	labelConsensus = "consensus"
+	labelPhase     = "phase"
+	labelPeer      = "peer"
+)
+
+var (
+	//MessagesReceived counts the consensus messages served by this peer
+	MessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "transport",
+		Name:      "messages_received_total",
+		Help:      "Number of consensus messages received per phase.",
+	}, []string{labelConsensus, labelPhase})
+
+	//MessagesSent counts the consensus messages this peer has tried to send, including the ones to itself
+	MessagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "transport",
+		Name:      "messages_sent_total",
+		Help:      "Number of consensus messages sent per phase.",
+	}, []string{labelConsensus, labelPhase})
+
+	//SendErrors counts the consensus messages which have failed to be sent
+	SendErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "transport",
+		Name:      "send_errors_total",
+		Help:      "Number of consensus messages failed to be sent per phase.",
+	}, []string{labelConsensus, labelPhase})
+
+	//SignatureFailures counts the consensus messages whose signature is invalid
+	SignatureFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consensus",
+		Name:      "signature_failures_total",
+		Help:      "Number of consensus messages with an invalid signature.",
+	}, []string{labelConsensus})
+
+	//Equivocations counts the validators found to sign different digests for the same height, round and phase
+	Equivocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consensus",
+		Name:      "equivocations_total",
+		Help:      "Number of equivocations detected in consensus messages.",
+	}, []string{labelConsensus})
+
+	//RoundChanges counts the round changes triggered by the timeout of this peer
+	RoundChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consensus",
+		Name:      "round_changes_total",
+		Help:      "Number of round changes triggered by timeout.",
+	}, []string{labelConsensus})
+
+	//CommitLatency observes how long it takes to commit a block of a height since its first candidate block
+	CommitLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "consensus",
+		Name:      "commit_latency_seconds",
+		Help:      "Time from the first candidate block of a height to the append of its block.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{labelConsensus})
+
+	//Height is the height of the ledger
+	Height = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ledger",
+		Name:      "height",
+		Help:      "Current height of the ledger.",
+	})
+
+	//Reputation is the reputation of each validator in the reputation book of this peer
+	Reputation = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "reputation",
+		Name:      "value",
+		Help:      "Reputation of each validator.",
+	}, []string{labelPeer})
+)
+
+//RegisterDepth exposes the number of messages waiting in a queue or heap, which is read by depth on every scrape
+func RegisterDepth(name string, depth func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   "dealer",
+		Name:        "pending_messages",
+		Help:        "Number of messages waiting in the queues and heaps of the dealer.",
+		ConstLabels: prometheus.Labels{"queue": name},
+	}, depth)
+}
+
+//Serve exposes the metrics over http on the address at /metrics. it blocks, so it should be run as a goroutine
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	logger.Infof("metrics are served on %s/metrics", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logger.Errorf("could not serve metrics: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package peer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/util"
+	"sort"
+	"time"
+)
+
+//quorumCheckInterval is how often the primary checks whether a quorum of validators is ready to start consensus
+const quorumCheckInterval = time.Second
+
+//bootstrapHash replaces the vrf hash of the validator for the committee of the first round.
+//it is made from the genesis block, so that every peer computes the same without exchanging messages
+func bootstrapHash(genesisDigest []byte, id uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, id)
+	h := sha256.Sum256(append(append([]byte(nil), genesisDigest...), b...))
+	return h[:]
+}
+
+//bootstrapCommittee derives the committee of the first round of XBFT from the genesis block.
+//the validators whose selection value over the bootstrap hash is larger than the threshold are the committee,
+//topped up to the minimum size of committee by the highest values. members are in descending order of the value
+func (p *peer) bootstrapCommittee() []*plum.CommitteeMembers {
+	digest := block.Digest(p.L.Genesis.GetHeader())
+
+	var cms []*plum.CommitteeMembers
+	for _, id := range p.validatorIDs() {
+		cms = append(cms, &plum.CommitteeMembers{
+			PeerId:         id,
+			Round:          0,
+			SelectionValue: p.selectionValue(bootstrapHash(digest, id), id),
+		})
+	}
+	sort.SliceStable(cms, func(i, j int) bool { return cms[i].SelectionValue > cms[j].SelectionValue })
+
+	size := p.minimumCommitteeSize()
+	for size < len(cms) && p.Selection(cms[size].SelectionValue) {
+		size++
+	}
+	if size > len(cms) {
+		size = len(cms)
+	}
+	return cms[:size]
+}
+
+//setBootstrapCommittee makes the committee of the first round and its primary known to this peer
+func (p *peer) setBootstrapCommittee() {
+	p.setCommitteeMembers(p.bootstrapCommittee())
+	primary, _ := findXBFTPrimary(p.D.committeeMembers)
+	p.setXBFTPrimary(primary)
+	p.assignRoleByCommitteeMember()
+	if p.ID == primary {
+		p.Role = plum.ConsensusRole_Primary
+	}
+	p.log().Infof("bootstrap committee of primary %d:%s", primary, util.CommitteeMembersString(p.D.committeeMembers))
+}
+
+//readyValidators returns the number of validators which have said hello as ready, including this peer
+func (p *peer) readyValidators() int {
+	n := 0
+	for _, id := range p.validatorIDs() {
+		if p.isReady(id) {
+			n++
+		}
+	}
+	return n
+}
+
+//quorum returns the number of validators which should be ready to start consensus, 2f+1 unless it is set
+func (p *peer) quorum() int {
+	if p.readyQuorum > 0 && p.readyQuorum <= p.validatorCount() {
+		return p.readyQuorum
+	}
+	return p.toleranceBase()*2 + 1
+}
+
+//waitForQuorum blocks until a quorum of validators is ready
+func (p *peer) waitForQuorum() {
+	t := time.NewTicker(quorumCheckInterval)
+	defer t.Stop()
+	for {
+		ready := p.readyValidators()
+		if ready >= p.quorum() {
+			p.log().Infof("%d of %d validators are ready", ready, p.validatorCount())
+			return
+		}
+		p.log().Infof("waiting for validators to be ready: %d / %d", ready, p.quorum())
+		<-t.C
+	}
+}
+
+//armRoundTimer sets the timer of the first phase of the round on a validator, unless consensus has already set one.
+//if the primary does not start the round, the round changes to the next primary
+func (p *peer) armRoundTimer() {
+	if !p.isValidator(p.ID) || p.K.SetPhase >= 0 {
+		return
+	}
+	p.log().Infof("wait for the primary to start round %d", p.ConsensusRound)
+	switch p.D.ConsensusType {
+	case "PBFT":
+		p.SetTimer(plum.PBFTPhase_PBFTNewRound)
+	case "XBFT":
+		p.SetTimer(plum.XBFTPhase_XBFTPrePrepare)
+	}
+}
@@ -0,0 +1,169 @@
+package peer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"math"
+)
+
+//verifyCommitCertificate checks that the certificate finalizes the block, using only the block, the certificate and the public keys of peers.
+//every message should be signed by its sender over the digest of the block, and distinct signers should exceed the threshold of the phase.
+//the block should be the next one of the ledger, so that the validator set and reputation of this peer are the ones in force at its height
+func (p *peer) verifyCommitCertificate(b *plum.Block, c *plum.CommitCertificate) error {
+	if c == nil {
+		return errors.New("empty commit certificate")
+	}
+
+	digest := block.Digest(b.GetHeader())
+	if c.GetHeight() != b.GetHeader().GetId() || bytes.Compare(c.GetBlockDigest(), digest) != 0 {
+		return fmt.Errorf("the certificate is not of the block of height %d", b.GetHeader().GetId())
+	}
+	if next := p.L.CurrentHeight() + 1; c.GetHeight() != next {
+		return fmt.Errorf("the certificate of height %d is verified at height %d", c.GetHeight(), next)
+	}
+
+	if len(c.GetPbftCommits()) != 0 {
+		return p.verifyPBFTCommitCertificate(digest, c)
+	}
+	return p.verifyXBFTCommitCertificate(b, digest, c)
+}
+
+func (p *peer) verifyPBFTCommitCertificate(digest []byte, c *plum.CommitCertificate) error {
+	signers := make(map[uint32]bool)
+	for _, m := range c.GetPbftCommits() {
+		if m.GetMessage().GetPhase() != plum.PBFTPhase_PBFTCommit {
+			return fmt.Errorf("invalid phase %s in the commit certificate", m.GetMessage().GetPhase())
+		}
+		if bytes.Compare(m.GetMessage().GetDigest(), digest) != 0 || m.GetMessage().GetHeight()+1 != c.GetHeight() {
+			return fmt.Errorf("commit of peer %d is for a different block", m.GetMessage().GetPeerId())
+		}
+		if err := p.verifySigner(m.GetMessage().GetPeerId(), m); err != nil {
+			return err
+		}
+		signers[m.GetMessage().GetPeerId()] = true
+	}
+
+	//2f+1 of the validator set in force at the height
+	threshold := p.toleranceBase() * 2
+	if len(signers) <= threshold {
+		return fmt.Errorf("not enough commits: %d signers, threshold %d", len(signers), threshold)
+	}
+	return nil
+}
+
+//verifyXBFTCommitCertificate checks the committed certificate against the committee members written in the block,
+//then checks the select messages against the reputation book which is the one before the block is appended.
+//the committee members are covered by the digest, as they are bound to the header
+func (p *peer) verifyXBFTCommitCertificate(b *plum.Block, digest []byte, c *plum.CommitCertificate) error {
+	if bytes.Compare(block.CommitteeDigest(b), b.GetHeader().GetCommitteeDigest()) != 0 {
+		return errors.New("committee members are not bound to the header")
+	}
+	if err := p.verifyCommittee(b); err != nil {
+		return err
+	}
+
+	committee := b.GetCommitteeMembers()
+	committed := make(map[uint32]bool)
+	for _, m := range c.GetXbftCommitted().GetCert() {
+		sender := m.GetMessage().GetPeerId()
+		if m.GetMessage().GetPhase() != plum.XBFTPhase_XBFTCommit {
+			return fmt.Errorf("invalid phase %s in the committed certificate", m.GetMessage().GetPhase())
+		}
+		if bytes.Compare(m.GetMessage().GetDigest(), digest) != 0 || m.GetMessage().GetHeight()+1 != c.GetHeight() {
+			return fmt.Errorf("commit of peer %d is for a different block", sender)
+		}
+		if _, err := findCommitteeMemberById(sender, committee); err != nil {
+			return fmt.Errorf("commit of peer %d which is not a committee member", sender)
+		}
+		if err := p.verifySigner(sender, m); err != nil {
+			return err
+		}
+		committed[sender] = true
+	}
+
+	threshold := int(math.Floor(p.xbftToleranceBase(committee) * 2))
+	if len(committed) <= threshold {
+		return fmt.Errorf("not enough commits: %d signers, threshold %d", len(committed), threshold)
+	}
+
+	selected := make(map[uint32]bool)
+	var reputationSum float64
+	for _, m := range c.GetXbftSelected().GetCert() {
+		sender := m.GetMessage().GetPeerId()
+		if m.GetMessage().GetPhase() != plum.XBFTPhase_XBFTSelect {
+			return fmt.Errorf("invalid phase %s in the select messages", m.GetMessage().GetPhase())
+		}
+		if bytes.Compare(m.GetMessage().GetDigest(), digest) != 0 || m.GetMessage().GetHeight()+1 != c.GetHeight() {
+			return fmt.Errorf("select of peer %d is for a different block", sender)
+		}
+		if err := p.verifySigner(sender, m); err != nil {
+			return err
+		}
+		if selected[sender] {
+			continue
+		}
+		selected[sender] = true
+		reputationSum += p.ReputationBook[sender]
+	}
+
+	if reputationSum/p.RepSum() <= 0.5 {
+		return fmt.Errorf("not enough reputation for selection: %v of %v", reputationSum, p.RepSum())
+	}
+	return nil
+}
+
+//verifyCommittee checks the committee members of the block against the validator set in force at its height.
+//the members should be distinct validators, at least as many as the fixed minimum size of committee
+func (p *peer) verifyCommittee(b *plum.Block) error {
+	members := make(map[uint32]bool)
+	for _, cm := range b.GetCommitteeMembers() {
+		if !p.isValidator(cm.GetPeerId()) {
+			return fmt.Errorf("committee member %d is not a validator", cm.GetPeerId())
+		}
+		if members[cm.GetPeerId()] {
+			return fmt.Errorf("committee member %d is duplicated", cm.GetPeerId())
+		}
+		members[cm.GetPeerId()] = true
+	}
+
+	size := int(p.selection.GetMinCommitteeSize())
+	if n := p.validatorCount(); size > n {
+		size = n
+	}
+	if len(members) < size {
+		return fmt.Errorf("committee of %d members is smaller than %d", len(members), size)
+	}
+
+	//the ones penalized by the block
+	for _, cm := range b.GetRoundChangedCommitteeMembers() {
+		if !p.isValidator(cm.GetPeerId()) {
+			return fmt.Errorf("round changed committee member %d is not a validator", cm.GetPeerId())
+		}
+	}
+	return nil
+}
+
+//verifySigner checks that the sender is a validator and the message is signed by it
+func (p *peer) verifySigner(sender uint32, m interface{}) error {
+	if a, ok := p.AddressBook[sender]; !ok || len(a.PublicKey) == 0 || !p.isValidator(sender) {
+		return fmt.Errorf("unknown signer %d", sender)
+	}
+	if !p.VerifyConsensusMessageSignature(m) {
+		return fmt.Errorf("invalid signature of peer %d", sender)
+	}
+	return nil
+}
+
+//certificateRound returns the round in which the block of the certificate has been committed
+func certificateRound(c *plum.CommitCertificate) uint64 {
+	if len(c.GetPbftCommits()) != 0 {
+		return c.GetPbftCommits()[0].GetMessage().GetRound()
+	}
+	if cert := c.GetXbftCommitted().GetCert(); len(cert) != 0 {
+		return cert[0].GetMessage().GetRound()
+	}
+	return 0
+}
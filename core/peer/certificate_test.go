@@ -0,0 +1,189 @@
+package peer
+
+import (
+	"crypto/ed25519"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"testing"
+)
+
+//setSigningKeysForTest replaces the public keys in the address book with the ones this test holds private keys of
+func setSigningKeysForTest() map[uint32]ed25519.PrivateKey {
+	p := GetInstance()
+	keys := make(map[uint32]ed25519.PrivateKey)
+	for id, a := range p.AddressBook {
+		if id == p.ID {
+			keys[id] = p.PrivateKey
+			continue
+		}
+		pub, priv, _ := ed25519.GenerateKey(nil)
+		a.PublicKey = pub
+		keys[id] = priv
+	}
+	return keys
+}
+
+func pbftCommitCertificateForTest(b *plum.Block, keys map[uint32]ed25519.PrivateKey, signers int) *plum.CommitCertificate {
+	c := &plum.CommitCertificate{Height: b.GetHeader().GetId(), BlockDigest: block.Digest(b.GetHeader())}
+	for id := uint32(0); id < uint32(signers); id++ {
+		m := &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTCommit, Height: b.GetHeader().GetId() - 1, Digest: c.BlockDigest, PeerId: id}
+		c.PbftCommits = append(c.PbftCommits, &plum.PBFTRequest{Message: m, Signature: sign(keys[id], GetInstance().chainID, m)})
+	}
+	return c
+}
+
+func xbftMessageForTest(ph plum.XBFTPhase, b *plum.Block, id uint32, key ed25519.PrivateKey) *plum.XBFTRequest {
+	m := &plum.XBFTMessage{Phase: ph, Height: b.GetHeader().GetId() - 1, Digest: block.Digest(b.GetHeader()), PeerId: id}
+	return &plum.XBFTRequest{Message: m, Signature: sign(key, GetInstance().chainID, m)}
+}
+
+func TestPeer_verifyCommitCertificate_PBFT(t *testing.T) {
+	p := GetInstance()
+	keys := setSigningKeysForTest()
+	b := block.NewBlock(p.RetrieveTxs(), nil, p.L.CurrentHeight()+1)
+	threshold := p.PBFTThreshold[plum.PBFTPhase_PBFTCommit]
+
+	if err := p.verifyCommitCertificate(b, pbftCommitCertificateForTest(b, keys, threshold+1)); err != nil {
+		t.Errorf("valid certificate is not verified: %v", err)
+	}
+
+	if err := p.verifyCommitCertificate(b, pbftCommitCertificateForTest(b, keys, threshold)); err == nil {
+		t.Errorf("certificate lacking commits should not be verified")
+	}
+
+	//a single peer repeating its commit does not count more than once
+	c := pbftCommitCertificateForTest(b, keys, threshold)
+	c.PbftCommits = append(c.PbftCommits, c.PbftCommits[0])
+	if err := p.verifyCommitCertificate(b, c); err == nil {
+		t.Errorf("certificate with duplicated commits should not be verified")
+	}
+
+	//certificate of another block
+	other := block.NewBlock(p.RetrieveTxs(), nil, p.L.CurrentHeight()+1)
+	if err := p.verifyCommitCertificate(other, pbftCommitCertificateForTest(b, keys, threshold+1)); err == nil {
+		t.Errorf("certificate of another block should not be verified")
+	}
+
+	//forged signature
+	c = pbftCommitCertificateForTest(b, keys, threshold+1)
+	c.PbftCommits[1].Signature = c.PbftCommits[0].Signature
+	if err := p.verifyCommitCertificate(b, c); err == nil {
+		t.Errorf("certificate with forged signature should not be verified")
+	}
+
+	//the validator set of this peer is not the one in force at another height
+	later := block.NewBlock(p.RetrieveTxs(), nil, p.L.CurrentHeight()+2)
+	if err := p.verifyCommitCertificate(later, pbftCommitCertificateForTest(later, keys, threshold+1)); err == nil {
+		t.Errorf("certificate of a block other than the next should not be verified")
+	}
+}
+
+func TestPeer_verifyCommitCertificate_XBFT(t *testing.T) {
+	p := GetInstance()
+	keys := setSigningKeysForTest()
+	saved := make(map[uint32]float64)
+	for id, r := range p.ReputationBook {
+		saved[id] = r
+	}
+	defer func() { p.ReputationBook = saved }()
+	p.initReputation()
+
+	b := block.NewBlock(p.RetrieveTxs(), nil, p.L.CurrentHeight()+1)
+	b.CommitteeMembers = []*plum.CommitteeMembers{{PeerId: 0}, {PeerId: 1}, {PeerId: 2}, {PeerId: 3}}
+	block.SealCommittee(b)
+
+	c := &plum.CommitCertificate{
+		Height:        b.GetHeader().GetId(),
+		BlockDigest:   block.Digest(b.GetHeader()),
+		XbftCommitted: &plum.Certificate{},
+		XbftSelected:  &plum.Certificate{},
+	}
+	for _, cm := range b.CommitteeMembers {
+		c.XbftCommitted.Cert = append(c.XbftCommitted.Cert, xbftMessageForTest(plum.XBFTPhase_XBFTCommit, b, cm.PeerId, keys[cm.PeerId]))
+	}
+	for id := uint32(0); id < 4; id++ {
+		c.XbftSelected.Cert = append(c.XbftSelected.Cert, xbftMessageForTest(plum.XBFTPhase_XBFTSelect, b, id, keys[id]))
+	}
+
+	if err := p.verifyCommitCertificate(b, c); err != nil {
+		t.Errorf("valid certificate is not verified: %v", err)
+	}
+
+	//select messages from 3 of 7 peers with equal reputation does not exceed 50%
+	lacking := &plum.CommitCertificate{
+		Height:        c.Height,
+		BlockDigest:   c.BlockDigest,
+		XbftCommitted: c.XbftCommitted,
+		XbftSelected:  &plum.Certificate{Cert: append(c.XbftSelected.Cert[:3:3], c.XbftSelected.Cert[0])},
+	}
+	if err := p.verifyCommitCertificate(b, lacking); err == nil {
+		t.Errorf("certificate lacking reputation of selection should not be verified")
+	}
+
+	//commit from a peer which is not a committee member
+	outsider := &plum.CommitCertificate{
+		Height:        c.Height,
+		BlockDigest:   c.BlockDigest,
+		XbftCommitted: &plum.Certificate{Cert: append(c.XbftCommitted.Cert[:3:3], xbftMessageForTest(plum.XBFTPhase_XBFTCommit, b, 5, keys[5]))},
+		XbftSelected:  c.XbftSelected,
+	}
+	if err := p.verifyCommitCertificate(b, outsider); err == nil {
+		t.Errorf("certificate with a commit from outside of the committee should not be verified")
+	}
+
+	//a committee shrunk after the commits lowers the threshold, but it is not bound to the signed digest
+	shrunk := proto.Clone(b).(*plum.Block)
+	shrunk.CommitteeMembers = shrunk.CommitteeMembers[:1]
+	if err := p.verifyCommitCertificate(shrunk, c); err == nil {
+		t.Errorf("certificate with committee members not bound to the header should not be verified")
+	}
+
+	//committee members of the block should be validators, as many as the minimum size of committee
+	small := block.NewBlock(p.RetrieveTxs(), nil, p.L.CurrentHeight()+1)
+	small.CommitteeMembers = []*plum.CommitteeMembers{{PeerId: 0}}
+	block.SealCommittee(small)
+	if err := p.verifyCommittee(small); err == nil {
+		t.Errorf("committee smaller than the minimum should not be verified")
+	}
+	small.CommitteeMembers = append(b.CommitteeMembers[:3:3], &plum.CommitteeMembers{PeerId: 100})
+	if err := p.verifyCommittee(small); err == nil {
+		t.Errorf("committee with a peer which is not a validator should not be verified")
+	}
+}
+
+func TestPeer_verifyCertificate(t *testing.T) {
+	p := GetInstance()
+	keys := setSigningKeysForTest()
+	b := block.NewBlock(p.RetrieveTxs(), nil, p.L.CurrentHeight()+1)
+	digests, thresholds := p.D.CandidateBlockDigests, p.XBFTThreshold[0]
+	p.D.CandidateBlockDigests = map[uint32][]byte{0: block.Digest(b.GetHeader())}
+	defer func() {
+		p.D.CandidateBlockDigests, p.XBFTThreshold[0] = digests, thresholds
+	}()
+	p.setXBFTThreshold(0, []*plum.CommitteeMembers{{PeerId: 0}, {PeerId: 1}, {PeerId: 2}, {PeerId: 3}})
+	threshold := p.XBFTThreshold[0][plum.XBFTPhase_XBFTPrepare]
+
+	c := &plum.Certificate{}
+	for id := uint32(0); id <= uint32(threshold); id++ {
+		c.Cert = append(c.Cert, xbftMessageForTest(plum.XBFTPhase_XBFTPrepare, b, id, keys[id]))
+	}
+	if !p.verifyCertificate(c) {
+		t.Errorf("certificate of more signers than the threshold should be verified")
+	}
+
+	//a signer repeated is counted once
+	repeated := &plum.Certificate{}
+	for i := 0; i <= threshold; i++ {
+		repeated.Cert = append(repeated.Cert, xbftMessageForTest(plum.XBFTPhase_XBFTPrepare, b, 1, keys[1]))
+	}
+	if p.verifyCertificate(repeated) {
+		t.Errorf("certificate of a signer repeated should not be verified")
+	}
+
+	other := block.NewBlock(p.RetrieveTxs(), []byte("other"), p.L.CurrentHeight()+1)
+	c.Cert[0] = xbftMessageForTest(plum.XBFTPhase_XBFTPrepare, other, 0, keys[0])
+	if p.verifyCertificate(c) {
+		t.Errorf("certificate with a message on another block should not be verified")
+	}
+}
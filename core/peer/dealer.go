@@ -4,11 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/metrics"
 	"github.com/yoseplee/plum/core/peer/heap"
 	"github.com/yoseplee/plum/core/peer/mq"
 	"github.com/yoseplee/plum/core/plum"
 	"github.com/yoseplee/vrf"
-	"log"
+	"sync"
 )
 
 const (
@@ -32,13 +33,20 @@ type Dealer struct {
 	CandidateBlockDigests       map[uint32][]byte                                 //for XBFT
 	CandidateBlockCertificates  map[uint32]map[plum.XBFTPhase][]*plum.XBFTRequest //for XBFT
 	CandidateCommitteeMembers   map[uint32][]*plum.CommitteeMembers               //for XBFT
+	PBFTCommitMessages          []*plum.PBFTRequest                               //for PBFT
+	pbftVoters                  map[pbftVoteKey]map[uint32]bool                   //for PBFT
+	SelectMessages              map[uint32][]*plum.XBFTRequest                    //for XBFT
 	receivedReputationSum       map[uint32]float64
 	roundChangeReputationSum    float64
 	roundChangeCommitteeMembers []*plum.CommitteeMembers
 	roundChangeCertificate      []*plum.XBFTRequest
 	committeeMembers            []*plum.CommitteeMembers
 	totalReputationAtRound      float64
+	signedMessages              map[slot]interface{}
+	aheadMessages               map[uint32]interface{}
+	stateMutex                  *sync.RWMutex //guards the state of XBFT written by the dealer and the keeper against readers of the peer state
 	stopSig                     chan struct{}
+	done                        chan struct{}
 }
 
 func NewDealer(consensusType string) *Dealer {
@@ -55,13 +63,20 @@ func NewDealer(consensusType string) *Dealer {
 		CandidateBlockDigests:      make(map[uint32][]byte),
 		CandidateBlockCertificates: make(map[uint32]map[plum.XBFTPhase][]*plum.XBFTRequest),
 		CandidateCommitteeMembers:  make(map[uint32][]*plum.CommitteeMembers),
+		SelectMessages:             make(map[uint32][]*plum.XBFTRequest),
 		receivedReputationSum:      make(map[uint32]float64),
+		signedMessages:             make(map[slot]interface{}),
+		pbftVoters:                 make(map[pbftVoteKey]map[uint32]bool),
+		aheadMessages:              make(map[uint32]interface{}),
+		stateMutex:                 &sync.RWMutex{},
 		stopSig:                    make(chan struct{}),
+		done:                       make(chan struct{}),
 	}
 	return d
 }
 
 func (d *Dealer) Run() {
+	defer close(d.done)
 	switch d.ConsensusType {
 	case "PBFT":
 		d.startPBFT()
@@ -69,10 +84,34 @@ func (d *Dealer) Run() {
 		d.totalReputationAtRound = GetInstance().RepSum()
 		d.startXBFT()
 	default:
-		log.Fatalf("invalid type of consensus")
+		GetInstance().log().Fatalf("invalid type of consensus: %s", d.ConsensusType)
 	}
 }
 
+//Stop stops the dealer and waits until it finishes handling the message in hand. it should be called after Run
+func (d *Dealer) Stop() {
+	close(d.stopSig)
+	<-d.done
+}
+
+//xbftCertificateState returns the number of messages collected in the certificates of the current primary and the thresholds of them,
+//taken under the lock of the dealer so that they are not read while consensus writes them
+func (d *Dealer) xbftCertificateState() (certificates map[int32]int32, thresholds map[int32]int32) {
+	p := GetInstance()
+	d.stateMutex.RLock()
+	defer d.stateMutex.RUnlock()
+
+	primary := p.XBFTPrimary
+	certificates = make(map[int32]int32)
+	thresholds = make(map[int32]int32)
+	for _, ph := range []plum.XBFTPhase{plum.XBFTPhase_XBFTPrepare, plum.XBFTPhase_XBFTCommit, plum.XBFTPhase_XBFTRoundChange} {
+		certificates[int32(ph)] = int32(len(d.CandidateBlockCertificates[primary][ph]))
+		thresholds[int32(ph)] = int32(p.XBFTThreshold[primary][ph])
+	}
+	certificates[int32(plum.XBFTPhase_XBFTRoundChange)] = int32(len(d.roundChangeCertificate))
+	return certificates, thresholds
+}
+
 func (d *Dealer) committeeMemberAtPrePrepare(peerID uint32) bool {
 	for _, k := range d.committeeMembers {
 		if k.GetPeerId() == peerID {
@@ -83,16 +122,33 @@ func (d *Dealer) committeeMemberAtPrePrepare(peerID uint32) bool {
 }
 
 func (d *Dealer) setCandidateBlock(cb *plum.Block) {
+	GetInstance().startCommit()
 	d.CandidateBlock = cb
 	d.CandidateBlockDigest = block.Digest(d.CandidateBlock.Header)
 }
 
 func (d *Dealer) setXBFTCandidateBlock(peerID uint32, cb *plum.Block) {
+	GetInstance().startCommit()
 	cbd := block.Digest(cb.Header)
 	d.CandidateBlocks[peerID] = cb
 	d.CandidateBlockDigests[peerID] = cbd
 }
 
+//resetXBFTRoundState clears all the candidates, certificates and collected reputation of the round
+func (d *Dealer) resetXBFTRoundState() {
+	d.ReservedPrepareMessage = make(map[uint32][]*plum.XBFTRequest)
+	d.ReservedCommitMessage = make(map[uint32][]*plum.XBFTRequest)
+	d.CandidateBlocks = make(map[uint32]*plum.Block)
+	d.CandidateBlockDigests = make(map[uint32][]byte)
+	d.CandidateBlockCertificates = make(map[uint32]map[plum.XBFTPhase][]*plum.XBFTRequest)
+	d.CandidateCommitteeMembers = make(map[uint32][]*plum.CommitteeMembers)
+	d.SelectMessages = make(map[uint32][]*plum.XBFTRequest)
+	d.receivedReputationSum = make(map[uint32]float64)
+	d.totalReputationAtRound = GetInstance().RepSum()
+	d.roundChangeReputationSum = 0.0
+	d.roundChangeCommitteeMembers = nil
+}
+
 func (d *Dealer) roundCheck(message interface{}) bool {
 	p := GetInstance()
 	switch m := message.(type) {
@@ -130,9 +186,9 @@ func (d *Dealer) roundCheck(message interface{}) bool {
 
 		// fixing round change logic
 		//if m.GetMessage().GetPhase() == plum.XBFTPhase_XBFTPrePrepare && m.GetMessage().GetHeight() == p.L.Height && m.GetMessage().GetRoundChangeCertificate() != nil {
-		//	log.Println("update consensus round according to the round change message with certificate from", p.ConsensusRound, " to", m.GetMessage().GetRound())
+		//	p.log().Infof("update consensus round according to the round change message with certificate from %d to %d", p.ConsensusRound, m.GetMessage().GetRound())
 		//	p.ConsensusRound = m.GetMessage().GetRound()
-		//	//log.Println("immediately start pre prepare message")
+		//	//p.log().Debugf("immediately start pre prepare message")
 		//	//handleXBFTPrePrepare(m)
 		//	return true
 		//}
@@ -150,7 +206,7 @@ func (d *Dealer) roundCheck(message interface{}) bool {
 		}
 		return true
 	default:
-		log.Panic("invalid message type on round check")
+		p.log().Panicf("invalid message type on round check")
 		return false
 	}
 }
@@ -159,33 +215,28 @@ func (d *Dealer) roundCheck(message interface{}) bool {
 //it resets state of the peer, increase round then multicasts round change message
 func (d *Dealer) triggerXBFTRoundChange() {
 	p := GetInstance()
-	//discard request messages at current round in the heap
-	d.discardAllTheRemainedMessagesAtTheRound()
-
-	log.Println("round change triggered: ", p.ConsensusRound, "->", p.ConsensusRound+1)
-	p.ConsensusRound++
-	p.XBFTPhase = plum.XBFTPhase_XBFTRoundChange
+	d.enterXBFTRoundChange()
 
 	var pi, vrfHash []byte
 	var proveErr error
 	if d.CandidateBlocks[p.XBFTPrimary].GetRoundChangedCommitteeMembers() == nil {
 		pi, vrfHash, proveErr = vrf.Prove(p.PublicKey, p.PrivateKey, p.L.CurrentBlockHeader().MerkleRoot)
 		if proveErr != nil {
-			log.Println("could not prove:", proveErr)
+			p.log().Errorf("could not prove: %v", proveErr)
 			return
 		}
 	} else {
 		//the block has already failed -> 2nd tie break rule should be used
 		pi, vrfHash, proveErr = vrf.Prove(p.PublicKey, p.PrivateKey, p.L.CurrentBlockHeader().PrevBlockHash)
 		if proveErr != nil {
-			log.Println("could not prove:", proveErr)
+			p.log().Errorf("could not prove: %v", proveErr)
 			return
 		}
 	}
 
 	selectionValue := p.selectionValue(vrfHash, p.ID)
 
-	if Selection(selectionValue) {
+	if p.Selection(selectionValue) {
 		p.TentativeSelectedCount++
 	}
 
@@ -229,6 +280,20 @@ func (d *Dealer) triggerXBFTRoundChange() {
 	go SendAllExceptThisPeer(roundChangeMessage)
 }
 
+//enterXBFTRoundChange moves this peer to the round change phase of the next round
+func (d *Dealer) enterXBFTRoundChange() {
+	p := GetInstance()
+	//discard request messages at current round in the heap
+	d.discardAllTheRemainedMessagesAtTheRound()
+
+	p.log().Infof("round change triggered: %d -> %d", p.ConsensusRound, p.ConsensusRound+1)
+	p.finishRoundTrace("round change")
+	p.ConsensusRound++
+	metrics.RoundChanges.WithLabelValues("XBFT").Inc()
+	p.emit(plum.EventType_RoundChangeTriggered, nil)
+	p.setXBFTPhase(plum.XBFTPhase_XBFTRoundChange)
+}
+
 func (d *Dealer) discardAllTheRemainedMessagesAtTheRound() {
 	p := GetInstance()
 	switch p.D.ConsensusType {
@@ -243,7 +308,7 @@ func (d *Dealer) discardAllTheRemainedMessagesAtTheRound() {
 			if t.GetMessage().GetRound() == p.ConsensusRound {
 				_, err := p.D.ReservedPBFTMessage.Pop()
 				if err != nil {
-					log.Printf("could not discard the all remained messages at round %d %v", p.ConsensusRound, err)
+					p.log().Errorf("could not discard the all remained messages at round %d: %v", p.ConsensusRound, err)
 				}
 			} else {
 				break
@@ -260,7 +325,7 @@ func (d *Dealer) discardAllTheRemainedMessagesAtTheRound() {
 			if t.GetMessage().GetRound() == p.ConsensusRound {
 				_, err := p.D.ReservedXBFTMessage.Pop()
 				if err != nil {
-					log.Printf("could not discard the all remained messages at round %d %v", p.ConsensusRound, err)
+					p.log().Errorf("could not discard the all remained messages at round %d: %v", p.ConsensusRound, err)
 				}
 			} else {
 				break
@@ -269,11 +334,38 @@ func (d *Dealer) discardAllTheRemainedMessagesAtTheRound() {
 	}
 }
 
-func vote(phase interface{}) {
-	switch ph := phase.(type) {
-	case plum.PBFTPhase:
-		GetInstance().PBFTVote[ph]++
+//pbftVoteKey is what a PBFT vote is cast for. a signer is counted once for a key
+type pbftVoteKey struct {
+	round  uint64
+	phase  plum.PBFTPhase
+	digest string
+}
+
+//vote counts the signer of the message for its round, phase and digest, then PBFTVote of the phase becomes the number of the signers.
+//it reports false if the signer has voted for them already, so that a message sent again is not counted twice
+func vote(m *plum.PBFTRequest) bool {
+	p := GetInstance()
+	k := pbftVoteKey{m.GetMessage().GetRound(), m.GetMessage().GetPhase(), string(m.GetMessage().GetDigest())}
+	voters, ok := p.D.pbftVoters[k]
+	if !ok {
+		voters = make(map[uint32]bool)
+		p.D.pbftVoters[k] = voters
+	}
+
+	signer := m.GetMessage().GetPeerId()
+	if voters[signer] {
+		p.log().Debugf("duplicate vote of peer %d on %s at round %d", signer, k.phase, k.round)
+		return false
 	}
+	voters[signer] = true
+	p.PBFTVote[k.phase] = len(voters)
+	return true
+}
+
+//resetPBFTVote clears the votes with their signers
+func (p *peer) resetPBFTVote() {
+	p.PBFTVote = make(map[plum.PBFTPhase]int)
+	p.D.pbftVoters = make(map[pbftVoteKey]map[uint32]bool)
 }
 
 func findCommitteeMemberById(id uint32, cms []*plum.CommitteeMembers) (*plum.CommitteeMembers, error) {
@@ -313,12 +405,40 @@ func findXBFTPrimary(committeeMembers []*plum.CommitteeMembers) (uint32, float64
 	return highestKey, highestValue
 }
 
-func makeCert(primaryID uint32, ph plum.XBFTPhase, m *plum.XBFTRequest) {
+//makeCert adds the message to the certificate of the primary in the phase.
+//it reports false if the signer of the message is in the certificate already, so that a signer is counted once
+func makeCert(primaryID uint32, ph plum.XBFTPhase, m *plum.XBFTRequest) bool {
 	p := GetInstance()
 	if p.D.CandidateBlockCertificates[primaryID] == nil {
 		p.D.CandidateBlockCertificates[primaryID] = make(map[plum.XBFTPhase][]*plum.XBFTRequest)
 	}
+	if signerIndex(p.D.CandidateBlockCertificates[primaryID][ph], m.GetMessage().GetPeerId()) >= 0 {
+		p.log().Debugf("duplicate %s of peer %d for primary %d", ph, m.GetMessage().GetPeerId(), primaryID)
+		return false
+	}
 	p.D.CandidateBlockCertificates[primaryID][ph] = append(p.D.CandidateBlockCertificates[primaryID][ph], m)
+	return true
+}
+
+//signerIndex returns the index of the message signed by the peer, or -1 if there is none
+func signerIndex(ms []*plum.XBFTRequest, id uint32) int {
+	for i, m := range ms {
+		if m.GetMessage().GetPeerId() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+//removeCommitteeMember returns the committee members without the peer
+func removeCommitteeMember(cms []*plum.CommitteeMembers, id uint32) []*plum.CommitteeMembers {
+	var kept []*plum.CommitteeMembers
+	for _, cm := range cms {
+		if cm.GetPeerId() != id {
+			kept = append(kept, cm)
+		}
+	}
+	return kept
 }
 
 func pCert(peerID uint32) ([]*plum.XBFTRequest, bool) {
@@ -365,7 +485,7 @@ func verifySelect(peerID uint32, proof []byte, seed []byte, selectionValue float
 	p := GetInstance()
 	verified, err := vrf.Verify(p.AddressBook[peerID].PublicKey, proof, seed)
 	if err != nil {
-		log.Println("invalid proof", err)
+		GetInstance().log().Warnf("invalid proof: %v", err)
 		return false
 	}
 	return verified
@@ -0,0 +1,152 @@
+package peer
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"sync"
+)
+
+//eventBufferSize is how many events a subscriber can fall behind. events are dropped for a subscriber whose buffer is full,
+//so that a slow subscriber never blocks consensus
+const eventBufferSize = 1024
+
+//eventBus delivers the events of this peer to the subscribers
+type eventBus struct {
+	seq         uint64
+	nextID      int
+	subscribers map[int]*subscriber
+	mutex       *sync.Mutex
+}
+
+type subscriber struct {
+	types   map[plum.EventType]bool
+	events  chan *plum.Event
+	dropped uint64
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subscribers: make(map[int]*subscriber),
+		mutex:       &sync.Mutex{},
+	}
+}
+
+//subscribe returns the id of the subscription and the channel of events of the types, every type if none is given
+func (b *eventBus) subscribe(types []plum.EventType) (int, <-chan *plum.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	s := &subscriber{
+		types:  make(map[plum.EventType]bool),
+		events: make(chan *plum.Event, eventBufferSize),
+	}
+	for _, t := range types {
+		s.types[t] = true
+	}
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = s
+	return id, s.events
+}
+
+//unsubscribe stops delivering events to the subscription and closes its channel
+func (b *eventBus) unsubscribe(id int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if s, ok := b.subscribers[id]; ok {
+		if s.dropped != 0 {
+			GetInstance().log().Warnf("subscriber %d has missed %d events", id, s.dropped)
+		}
+		close(s.events)
+		delete(b.subscribers, id)
+	}
+}
+
+//count returns the number of the subscriptions
+func (b *eventBus) count() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.subscribers)
+}
+
+//publish numbers the event and delivers it to the subscribers without waiting for them
+func (b *eventBus) publish(e *plum.Event) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.seq++
+	e.Seq = b.seq
+	for id, s := range b.subscribers {
+		if len(s.types) != 0 && !s.types[e.GetType()] {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			//warned once, the number of the dropped is told when the subscription ends
+			if s.dropped == 0 {
+				GetInstance().log().Warnf("subscriber %d falls behind, its events are dropped", id)
+			}
+			s.dropped++
+		}
+	}
+}
+
+//emit publishes the event of the type, stamped with the time, this peer and where it is in consensus.
+//the round is the current one unless the event is of another round
+func (p *peer) emit(t plum.EventType, e *plum.Event) {
+	if e == nil {
+		e = &plum.Event{}
+	}
+	e.Type = t
+	e.Timestamp = ptypes.TimestampNow()
+	e.PeerId = p.ID
+	e.Height = p.L.CurrentHeight()
+	if e.Round == 0 {
+		e.Round = p.ConsensusRound
+	}
+	p.events.publish(e)
+}
+
+func (p *peer) setPBFTPhase(phase plum.PBFTPhase) {
+	if p.PBFTPhase == phase {
+		return
+	}
+	p.PBFTPhase = phase
+	p.emit(plum.EventType_PhaseChanged, &plum.Event{Phase: phase.String()})
+}
+
+func (p *peer) setXBFTPhase(phase plum.XBFTPhase) {
+	if p.XBFTPhase == phase {
+		return
+	}
+	p.XBFTPhase = phase
+	p.emit(plum.EventType_PhaseChanged, &plum.Event{Phase: phase.String()})
+}
+
+//setPrimary changes the primary of PBFT
+func (p *peer) setPrimary(id uint32) {
+	if p.Primary == id {
+		return
+	}
+	p.Primary = id
+	p.emit(plum.EventType_PrimaryChanged, &plum.Event{PrimaryId: id})
+}
+
+//setCommitteeMembers makes the committee selected for the round known to this peer
+func (p *peer) setCommitteeMembers(cms []*plum.CommitteeMembers) {
+	p.D.committeeMembers = cms
+	p.emit(plum.EventType_CommitteeSelected, &plum.Event{CommitteeMembers: cms})
+}
+
+//emitBlockAppended tells the block appended, and the change of reputation made by it if any
+func (p *peer) emitBlockAppended(b *plum.Block, round uint64, events []*plum.ReputationEvent) {
+	p.emit(plum.EventType_BlockAppended, &plum.Event{Round: round, BlockDigest: block.Digest(b.GetHeader())})
+	if len(events) != 0 {
+		p.emit(plum.EventType_ReputationUpdated, &plum.Event{Round: round, ReputationEvents: events})
+	}
+}
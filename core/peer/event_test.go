@@ -0,0 +1,62 @@
+package peer
+
+import (
+	"github.com/yoseplee/plum/core/plum"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	b := newEventBus()
+	allID, all := b.subscribe(nil)
+	_, blocks := b.subscribe([]plum.EventType{plum.EventType_BlockAppended})
+
+	b.publish(&plum.Event{Type: plum.EventType_PhaseChanged})
+	b.publish(&plum.Event{Type: plum.EventType_BlockAppended})
+
+	if len(all) != 2 {
+		t.Errorf("subscriber without a filter should receive every event. got: %d", len(all))
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("subscriber should receive the events of its filter only. got: %d", len(blocks))
+	}
+	if e := <-blocks; e.GetSeq() != 2 {
+		t.Errorf("invalid sequence of event. got: %d, want: %d", e.GetSeq(), 2)
+	}
+
+	//a full subscriber does not block publishing
+	for i := 0; i < eventBufferSize; i++ {
+		b.publish(&plum.Event{Type: plum.EventType_PhaseChanged})
+	}
+	if len(all) != eventBufferSize {
+		t.Errorf("invalid number of buffered events. got: %d, want: %d", len(all), eventBufferSize)
+	}
+
+	b.unsubscribe(allID)
+	for range all {
+	}
+	if b.count() != 1 {
+		t.Errorf("invalid number of subscriptions. got: %d, want: %d", b.count(), 1)
+	}
+}
+
+func TestPeer_emitBlockAppended(t *testing.T) {
+	p := GetInstance()
+	round := p.ConsensusRound
+	p.ConsensusRound = round + 10
+	defer func() { p.ConsensusRound = round }()
+
+	id, events := p.events.subscribe([]plum.EventType{plum.EventType_BlockAppended, plum.EventType_ReputationUpdated})
+	defer p.events.unsubscribe(id)
+
+	//the events of a block are of the round it is committed in, which may differ from the current one
+	p.emitBlockAppended(p.NewCandidateBlock(), round+3, []*plum.ReputationEvent{{PeerId: 1}})
+	for i := 0; i < 2; i++ {
+		if e := <-events; e.GetRound() != round+3 {
+			t.Errorf("invalid round of %s. got: %d, want: %d", e.GetType(), e.GetRound(), round+3)
+		}
+	}
+	p.emit(plum.EventType_BlockAppended, nil)
+	if e := <-events; e.GetRound() != round+10 {
+		t.Errorf("event without a round should be of the current round. got: %d, want: %d", e.GetRound(), round+10)
+	}
+}
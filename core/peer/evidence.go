@@ -0,0 +1,301 @@
+package peer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/metrics"
+	"github.com/yoseplee/plum/core/plum"
+	"time"
+)
+
+//equivocationPenalty is what reputation of a validator is multiplied by for each equivocation proven in a block
+const equivocationPenalty = 0.5
+
+//reasonEquivocated is the reason of the penalty written in the reputation records
+const reasonEquivocated = "equivocated"
+
+//evidenceTxPrefix marks a transaction as an evidence of equivocation. other transactions never start with 0x00
+var evidenceTxPrefix = []byte{0x00, 'e', 'v'}
+
+//slot is what a validator signs a single digest for. a message of another digest for the same slot is an equivocation
+type slot struct {
+	sender  uint32
+	height  uint64
+	round   uint64
+	phase   string
+	primary uint32
+}
+
+//slotOf returns the slot of the message and its digest. messages without a digest take no slot
+func slotOf(message interface{}) (slot, []byte, bool) {
+	switch m := message.(type) {
+	case *plum.PBFTRequest:
+		msg := m.GetMessage()
+		return slot{msg.GetPeerId(), msg.GetHeight(), msg.GetRound(), msg.GetPhase().String(), 0}, msg.GetDigest(), len(msg.GetDigest()) != 0
+	case *plum.XBFTRequest:
+		msg := m.GetMessage()
+		return slot{msg.GetPeerId(), msg.GetHeight(), msg.GetRound(), msg.GetPhase().String(), msg.GetPrimaryId()}, msg.GetDigest(), len(msg.GetDigest()) != 0
+	default:
+		return slot{}, nil, false
+	}
+}
+
+//slashedSlot returns the slot as it is recorded along with the slash of its sender
+func (s slot) slashedSlot() *plum.SlashedSlot {
+	return &plum.SlashedSlot{Sender: s.sender, Height: s.height, Round: s.round, Phase: s.phase, Primary: s.primary}
+}
+
+//slotOfSlashed returns the slot recorded along with a slash
+func slotOfSlashed(ss *plum.SlashedSlot) slot {
+	return slot{ss.GetSender(), ss.GetHeight(), ss.GetRound(), ss.GetPhase(), ss.GetPrimary()}
+}
+
+//newEvidence makes the evidence of the two conflicting messages, leaving out the blocks which the signatures do not cover
+func newEvidence(first, second interface{}) *plum.Evidence {
+	switch f := first.(type) {
+	case *plum.PBFTRequest:
+		s := second.(*plum.PBFTRequest)
+		return &plum.Evidence{Pbft: []*plum.PBFTRequest{
+			{Message: f.GetMessage(), Signature: f.GetSignature()},
+			{Message: s.GetMessage(), Signature: s.GetSignature()},
+		}}
+	case *plum.XBFTRequest:
+		s := second.(*plum.XBFTRequest)
+		return &plum.Evidence{Xbft: []*plum.XBFTRequest{
+			{Message: f.GetMessage(), Signature: f.GetSignature()},
+			{Message: s.GetMessage(), Signature: s.GetSignature()},
+		}}
+	default:
+		return nil
+	}
+}
+
+//conflicts returns the two messages of the evidence
+func conflicts(e *plum.Evidence) ([]interface{}, error) {
+	switch {
+	case len(e.GetPbft()) == 2 && len(e.GetXbft()) == 0:
+		return []interface{}{e.GetPbft()[0], e.GetPbft()[1]}, nil
+	case len(e.GetXbft()) == 2 && len(e.GetPbft()) == 0:
+		return []interface{}{e.GetXbft()[0], e.GetXbft()[1]}, nil
+	default:
+		return nil, errors.New("evidence should have two messages of a consensus")
+	}
+}
+
+//evidenceSlot returns the slot of the evidence without verifying it
+func evidenceSlot(e *plum.Evidence) (slot, bool) {
+	ms, err := conflicts(e)
+	if err != nil {
+		return slot{}, false
+	}
+	s, _, ok := slotOf(ms[0])
+	return s, ok
+}
+
+//verifyEvidence checks that the two messages of the evidence are signed by a validator for the same slot with different digests,
+//then returns the slot
+func (p *peer) verifyEvidence(e *plum.Evidence) (slot, error) {
+	ms, err := conflicts(e)
+	if err != nil {
+		return slot{}, err
+	}
+	first, firstDigest, ok := slotOf(ms[0])
+	second, secondDigest, ok2 := slotOf(ms[1])
+	if !ok || !ok2 {
+		return slot{}, errors.New("message without digest")
+	}
+	if first != second {
+		return slot{}, errors.New("messages of different slots")
+	}
+	if bytes.Equal(firstDigest, secondDigest) {
+		return slot{}, errors.New("messages of the same digest")
+	}
+
+	if !p.isValidator(first.sender) {
+		return slot{}, fmt.Errorf("peer %d is not a validator", first.sender)
+	}
+	a, ok := p.AddressBook[first.sender]
+	if !ok {
+		return slot{}, fmt.Errorf("public key of peer %d is unknown", first.sender)
+	}
+	for _, m := range e.GetPbft() {
+		if !verifySignature(a.PublicKey, p.chainID, m.GetMessage(), m.GetSignature()) {
+			return slot{}, fmt.Errorf("invalid signature of peer %d", first.sender)
+		}
+	}
+	for _, m := range e.GetXbft() {
+		if !verifySignature(a.PublicKey, p.chainID, m.GetMessage(), m.GetSignature()) {
+			return slot{}, fmt.Errorf("invalid signature of peer %d", first.sender)
+		}
+	}
+	return first, nil
+}
+
+//detectEquivocation keeps the digest each validator has signed for a slot. a signed message of another digest for the same slot
+//makes an evidence, which is kept to be included in a block and forwarded to the other peers
+func (d *Dealer) detectEquivocation(message interface{}) {
+	p := GetInstance()
+	s, digest, ok := slotOf(message)
+	if !ok {
+		return
+	}
+	signed, ok := d.signedMessages[s]
+	if !ok {
+		d.signedMessages[s] = message
+		return
+	}
+	if _, signedDigest, _ := slotOf(signed); bytes.Equal(signedDigest, digest) {
+		return
+	}
+
+	e := newEvidence(signed, message)
+	p.log().Warnf("peer %d has signed different digests at height %d round %d on %s", s.sender, s.height, s.round, s.phase)
+	metrics.Equivocations.WithLabelValues(d.ConsensusType).Inc()
+	if p.addPendingEvidence(e) {
+		p.forwardEvidence(e)
+	}
+}
+
+//pruneSignedMessages drops the slots below the height, whose messages are no longer handled
+func (d *Dealer) pruneSignedMessages(height uint64) {
+	for s := range d.signedMessages {
+		if s.height < height {
+			delete(d.signedMessages, s)
+		}
+	}
+}
+
+func encodeEvidenceTx(e *plum.Evidence) ([]byte, error) {
+	m, err := proto.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), evidenceTxPrefix...), m...), nil
+}
+
+//decodeEvidenceTx returns the evidence in the transaction, or false if it is not one
+func decodeEvidenceTx(tx []byte) (*plum.Evidence, bool) {
+	if !bytes.HasPrefix(tx, evidenceTxPrefix) {
+		return nil, false
+	}
+	e := &plum.Evidence{}
+	if err := proto.Unmarshal(tx[len(evidenceTxPrefix):], e); err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+//addPendingEvidence keeps the evidence until it is included in a block. it reports false if the evidence is already pending or applied
+func (p *peer) addPendingEvidence(e *plum.Evidence) bool {
+	p.pendingMutex.Lock()
+	defer p.pendingMutex.Unlock()
+	if s, ok := evidenceSlot(e); ok && p.slashed[s] {
+		return false
+	}
+	for _, pending := range p.pendingEvidence {
+		if proto.Equal(pending, e) {
+			return false
+		}
+	}
+	p.pendingEvidence = append(p.pendingEvidence, e)
+	return true
+}
+
+//pendingEvidenceTxs returns the pending evidence as transactions to be included in the next block of this peer
+func (p *peer) pendingEvidenceTxs() [][]byte {
+	p.pendingMutex.Lock()
+	defer p.pendingMutex.Unlock()
+	var txs [][]byte
+	for _, e := range p.pendingEvidence {
+		tx, err := encodeEvidenceTx(e)
+		if err != nil {
+			p.log().Errorf("could not encode evidence: %v", err)
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+//slash marks the slot penalized and drops its pending evidence, as one evidence is enough for it.
+//it reports false if the slot has been penalized already
+func (p *peer) slash(s slot) bool {
+	p.pendingMutex.Lock()
+	defer p.pendingMutex.Unlock()
+	var kept []*plum.Evidence
+	for _, pending := range p.pendingEvidence {
+		if ps, ok := evidenceSlot(pending); ok && ps == s {
+			continue
+		}
+		kept = append(kept, pending)
+	}
+	p.pendingEvidence = kept
+
+	if p.slashed[s] {
+		return false
+	}
+	p.slashed[s] = true
+	return true
+}
+
+//forwardEvidence sends the evidence to all the other peers, so that whoever proposes the next block includes it
+func (p *peer) forwardEvidence(e *plum.Evidence) {
+	for _, a := range p.AddressBook {
+		if a.PeerId == p.ID || a.peerClient == nil {
+			continue
+		}
+		go func(a *Connection) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			defer cancel()
+			if _, err := a.peerClient.SubmitEvidence(ctx, e); err != nil {
+				p.log().Warnf("could not forward evidence to peer %d: %v", a.PeerId, err)
+			}
+		}(a)
+	}
+}
+
+//applyEvidence penalizes the validators proven to equivocate by the evidence in the appended block.
+//a slot is penalized once, even if its evidence is included again in a later block
+func (p *peer) applyEvidence(b *plum.Block) {
+	for _, tx := range b.GetBody().GetTxs() {
+		e, ok := decodeEvidenceTx(tx)
+		if !ok {
+			continue
+		}
+		s, err := p.verifyEvidence(e)
+		if err != nil {
+			p.log().Warnf("skip evidence in block %d: %v", b.GetHeader().GetId(), err)
+			continue
+		}
+		if !p.slash(s) {
+			continue
+		}
+
+		p.mutex.Lock()
+		reputation := p.ReputationBook[s.sender] * equivocationPenalty
+		p.log().Warnf("slash reputation of %d for equivocation at height %d round %d on %s: %v -> %v", s.sender, s.height, s.round, s.phase, p.ReputationBook[s.sender], reputation)
+		p.setReputation(s.sender, reputation, plum.ReputationEventType_ReputationSlash, reasonEquivocated)
+		p.reputationEvents[len(p.reputationEvents)-1].Slot = s.slashedSlot()
+		p.mutex.Unlock()
+	}
+}
+
+//restoreSlashed finds the slots penalized by the slashes in the reputation records, so that they are not penalized again.
+//the records are kept while the bodies of the blocks with the evidence may be pruned
+func (p *peer) restoreSlashed() {
+	for h := uint64(1); h <= p.L.CurrentHeight(); h++ {
+		r, err := p.L.GetReputationRecord(h)
+		if err != nil {
+			p.log().Errorf("could not read reputation record of height %d: %v", h, err)
+			continue
+		}
+		for _, e := range r.GetEvents() {
+			if e.GetType() == plum.ReputationEventType_ReputationSlash && e.GetSlot() != nil {
+				p.slashed[slotOfSlashed(e.GetSlot())] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package peer
+
+import (
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"testing"
+)
+
+func TestPeer_verifyEvidence(t *testing.T) {
+	p := GetInstance()
+	keys := setSigningKeysForTest()
+
+	signed := func(digest string) *plum.XBFTRequest {
+		m := &plum.XBFTMessage{Phase: plum.XBFTPhase_XBFTPrepare, Round: 3, Height: 2, Digest: []byte(digest), PeerId: 1, PrimaryId: 2}
+		return &plum.XBFTRequest{Message: m, Signature: sign(keys[1], p.chainID, m)}
+	}
+
+	e := newEvidence(signed("a"), signed("b"))
+	s, err := p.verifyEvidence(e)
+	if err != nil {
+		t.Fatalf("evidence of different digests should be verified: %v", err)
+	}
+	if s.sender != 1 || s.round != 3 || s.phase != plum.XBFTPhase_XBFTPrepare.String() {
+		t.Errorf("invalid slot of evidence: %+v", s)
+	}
+
+	if _, err := p.verifyEvidence(newEvidence(signed("a"), signed("a"))); err == nil {
+		t.Errorf("messages of the same digest should not be an evidence")
+	}
+
+	other := signed("b")
+	other.Message.Round = 4
+	other.Signature = sign(keys[1], p.chainID, other.Message)
+	if _, err := p.verifyEvidence(newEvidence(signed("a"), other)); err == nil {
+		t.Errorf("messages of different rounds should not be an evidence")
+	}
+
+	forged := signed("b")
+	forged.Signature = sign(keys[2], p.chainID, forged.Message)
+	if _, err := p.verifyEvidence(newEvidence(signed("a"), forged)); err == nil {
+		t.Errorf("a message signed by another peer should not be an evidence")
+	}
+}
+
+func TestDealer_detectEquivocation(t *testing.T) {
+	p := GetInstance()
+	keys := setSigningKeysForTest()
+	pending, slashed := p.pendingEvidence, p.slashed
+	p.pendingEvidence, p.slashed = nil, make(map[slot]bool)
+	book := make(map[uint32]float64)
+	for id, r := range p.ReputationBook {
+		book[id] = r
+	}
+	defer func() {
+		p.pendingEvidence, p.slashed, p.ReputationBook = pending, slashed, book
+	}()
+
+	signed := func(digest string) *plum.PBFTRequest {
+		m := &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTPrepare, Round: 1000, Height: 1000, Digest: []byte(digest), PeerId: 2}
+		return &plum.PBFTRequest{Message: m, Signature: sign(keys[2], p.chainID, m)}
+	}
+
+	p.D.detectEquivocation(signed("a"))
+	p.D.detectEquivocation(signed("a"))
+	if len(p.pendingEvidence) != 0 {
+		t.Fatalf("the same digest should not be an equivocation")
+	}
+	p.D.detectEquivocation(signed("b"))
+	if len(p.pendingEvidence) != 1 {
+		t.Fatalf("different digests should make an evidence. got: %d", len(p.pendingEvidence))
+	}
+
+	//the evidence is recorded in a block and the equivocating peer is penalized once
+	txs := p.pendingEvidenceTxs()
+	txs = append(txs, txs[0])
+	before := p.ReputationBook[2]
+	p.applyEvidence(block.NewBlock(txs, nil, 1))
+	if got, want := p.ReputationBook[2], before*equivocationPenalty; got != want {
+		t.Errorf("invalid reputation after equivocation. got: %v, want: %v", got, want)
+	}
+	if len(p.pendingEvidence) != 0 {
+		t.Errorf("evidence in a block should not be pending")
+	}
+	if p.addPendingEvidence(newEvidence(signed("a"), signed("c"))) {
+		t.Errorf("evidence of a slot penalized should not be pending again")
+	}
+}
@@ -0,0 +1,91 @@
+package peer
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"github.com/yoseplee/plum/core/ledger/genesis"
+	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/reputation"
+)
+
+//applyGenesis sets the validators with their public keys, initial reputation, the reputation policy and the selection parameters from the genesis configuration.
+//a genesis block without configuration makes every peer in the profile a validator, whose public key is exchanged on start up as before
+func (p *peer) applyGenesis() {
+	p.chainID = genesis.ChainID(p.L.Genesis)
+
+	validators := make(map[uint32]*plum.Validator)
+	c, err := p.L.GenesisConfig()
+	if err != nil {
+		p.log().Warnf("no genesis configuration, public keys are exchanged on start up: %v", err)
+		for id := range p.AddressBook {
+			validators[id] = &plum.Validator{Id: id}
+		}
+		p.validators = validators
+		p.setReputationPolicy(nil)
+		p.setSelectionParams(nil)
+		return
+	}
+	p.genesis = c
+	p.setReputationPolicy(c.GetConsensusParams().GetReputationPolicy())
+	p.setSelectionParams(c.GetConsensusParams().GetSelection())
+
+	for _, v := range c.GetValidators() {
+		validators[v.GetId()] = v
+		a, ok := p.AddressBook[v.GetId()]
+		if !ok {
+			p.log().Warnf("validator %d of the genesis is not in the profile", v.GetId())
+			continue
+		}
+		a.PublicKey = v.GetPublicKey()
+	}
+	p.validators = validators
+
+	for id := range p.AddressBook {
+		if !p.isValidator(id) {
+			p.log().Infof("peer %d of the profile is not a validator of the genesis, it follows the chain until it joins", id)
+		}
+	}
+	p.log().Infof("genesis of chain %s is applied: %d validators", c.GetChainId(), len(c.GetValidators()))
+}
+
+//pinnedPublicKey returns the public key of the validator written in the chain, nil if it is learned on start up
+func (p *peer) pinnedPublicKey(id uint32) []byte {
+	if v, ok := p.validators[id]; ok {
+		return v.GetPublicKey()
+	}
+	return nil
+}
+
+//SetPrivateKey makes this peer use the key instead of generating a new one on start up.
+//if the chain has a public key of this peer, the key should be the pair of it
+func (p *peer) SetPrivateKey(k ed25519.PrivateKey) error {
+	pub := k.Public().(ed25519.PublicKey)
+	if pinned := p.pinnedPublicKey(p.ID); pinned != nil && !bytes.Equal(pinned, pub) {
+		return fmt.Errorf("the key is not of validator %d in the chain", p.ID)
+	}
+	p.PrivateKey = k
+	p.PublicKey = pub
+	return nil
+}
+
+//checkPublicKey rejects a public key of a validator which is different from the one written in the chain
+func (p *peer) checkPublicKey(id uint32, key []byte) error {
+	if _, ok := p.AddressBook[id]; !ok {
+		return fmt.Errorf("peer %d is not in the address book", id)
+	}
+	if pinned := p.pinnedPublicKey(id); pinned != nil && !bytes.Equal(pinned, key) {
+		return fmt.Errorf("public key of peer %d is different from the one in the chain", id)
+	}
+	return nil
+}
+
+//setReputationPolicy makes this peer change reputation by the policy of the chain
+func (p *peer) setReputationPolicy(params *plum.ReputationPolicyParams) {
+	policy, err := reputation.New(params)
+	if err != nil {
+		p.log().Fatalf("invalid reputation policy in the genesis: %v", err)
+	}
+	p.policy = policy
+	p.log().Infof("reputation policy: %s", policy.Name())
+}
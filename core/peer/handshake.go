@@ -0,0 +1,155 @@
+package peer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/util"
+	"google.golang.org/grpc"
+	"sync"
+	"time"
+)
+
+const (
+	//helloTimeout is how long to wait for a peer to answer hello, including the time to connect to it
+	helloTimeout = time.Second * 3
+	//helloRetryInterval is how long to wait before greeting again the peers which are not ready yet
+	helloRetryInterval = time.Second * 2
+)
+
+//SetReadyQuorum makes consensus start when n validators are ready. 0 means 2f+1 of the validators
+func (p *peer) SetReadyQuorum(n int) {
+	p.readyQuorum = n
+}
+
+//hello returns the introduction of this peer, signed with a nonce which has not been used
+func (p *peer) hello() *plum.Hello {
+	h := &plum.Hello{
+		Id:              p.ID,
+		Ipv4:            p.Ipv4,
+		Port:            p.Port,
+		PublicKey:       p.PublicKey,
+		ProtocolVersion: util.ProtocolVersion,
+		ChainId:         p.chainID,
+		Height:          p.L.CurrentHeight(),
+		Ready:           p.isReady(p.ID),
+		Nonce:           p.nextHelloNonce(),
+	}
+	h.Signature = p.CreateSignature(h)
+	return h
+}
+
+//nextHelloNonce returns a nonce larger than the ones used before, even by this peer before restart
+func (p *peer) nextHelloNonce() uint64 {
+	p.readyMutex.Lock()
+	defer p.readyMutex.Unlock()
+	n := uint64(time.Now().UnixNano())
+	if n <= p.helloNonce {
+		n = p.helloNonce + 1
+	}
+	p.helloNonce = n
+	return n
+}
+
+//verifyHello checks that the hello is signed by the key in it over the chain, and is not older than the one taken from the peer
+func (p *peer) verifyHello(h *plum.Hello) error {
+	unsigned := proto.Clone(h).(*plum.Hello)
+	unsigned.Signature = nil
+	if !verifySignature(h.GetPublicKey(), p.chainID, unsigned, h.GetSignature()) {
+		return fmt.Errorf("invalid signature of hello from peer %d", h.GetId())
+	}
+
+	p.readyMutex.Lock()
+	defer p.readyMutex.Unlock()
+	if h.GetNonce() <= p.helloNonces[h.GetId()] {
+		return fmt.Errorf("hello from peer %d is taken again: nonce %d", h.GetId(), h.GetNonce())
+	}
+	p.helloNonces[h.GetId()] = h.GetNonce()
+	return nil
+}
+
+//receiveHello checks that the peer runs the same chain and protocol and signed the hello, then keeps its public key and readiness
+func (p *peer) receiveHello(h *plum.Hello) error {
+	if h.GetChainId() != p.chainID {
+		return fmt.Errorf("peer %d runs chain %s against %s", h.GetId(), h.GetChainId(), p.chainID)
+	}
+	if h.GetProtocolVersion() != util.ProtocolVersion {
+		return fmt.Errorf("peer %d runs protocol version %d against %d", h.GetId(), h.GetProtocolVersion(), util.ProtocolVersion)
+	}
+	if len(h.GetPublicKey()) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key of peer %d", h.GetId())
+	}
+	if err := p.checkPublicKey(h.GetId(), h.GetPublicKey()); err != nil {
+		return err
+	}
+	if err := p.verifyHello(h); err != nil {
+		return err
+	}
+
+	p.AddressBook[h.GetId()].PublicKey = h.GetPublicKey()
+	if h.GetReady() {
+		p.setReady(h.GetId())
+	}
+	return nil
+}
+
+func (p *peer) setReady(id uint32) {
+	p.readyMutex.Lock()
+	defer p.readyMutex.Unlock()
+	if !p.readyPeers[id] {
+		p.log().Infof("peer %d is ready", id)
+	}
+	p.readyPeers[id] = true
+}
+
+func (p *peer) isReady(id uint32) bool {
+	p.readyMutex.Lock()
+	defer p.readyMutex.Unlock()
+	return p.readyPeers[id]
+}
+
+//greet exchanges hello with the peer
+func (p *peer) greet(a *Connection) error {
+	ctx, cancel := context.WithTimeout(context.Background(), helloTimeout)
+	defer cancel()
+
+	h, err := a.peerClient.Hello(ctx, p.hello(), grpc.WaitForReady(true))
+	if err != nil {
+		return err
+	}
+	if h.GetId() != a.PeerId {
+		return fmt.Errorf("peer %d answered hello as peer %d", a.PeerId, h.GetId())
+	}
+	return p.receiveHello(h)
+}
+
+//handshake greets the peers in the address book until every one of them is ready.
+//the ones which have not started or are not ready yet are greeted again, so that a peer starting late is still known
+func (p *peer) handshake() {
+	for {
+		var wg sync.WaitGroup
+		pending := 0
+		for _, a := range p.AddressBook {
+			if a.PeerId == p.ID || p.isReady(a.PeerId) || a.peerClient == nil {
+				continue
+			}
+			pending++
+			wg.Add(1)
+			go func(a *Connection) {
+				defer wg.Done()
+				if err := p.greet(a); err != nil {
+					p.log().Warnf("could not greet peer %d: %v", a.PeerId, err)
+				}
+			}(a)
+		}
+		wg.Wait()
+
+		if pending == 0 {
+			p.log().Infof("all the peers in the address book are ready")
+			return
+		}
+		<-time.After(helloRetryInterval)
+	}
+}
@@ -2,7 +2,6 @@ package peer
 
 import (
 	"github.com/yoseplee/plum/core/plum"
-	"log"
 	"sync"
 	"time"
 )
@@ -46,6 +45,10 @@ func GetKeeperInstance() *Keeper {
 }
 
 func (k *Keeper) Set(setRound uint64, ph interface{}) {
+	//a replay moves on by the messages recorded only
+	if GetInstance().replaying() {
+		return
+	}
 	k.SetRound = setRound
 	switch setPhase := ph.(type) {
 	case plum.PBFTPhase:
@@ -94,26 +97,30 @@ func (k *Keeper) run() {
 		for {
 			select {
 			case <-k.Timer.C:
+				//the round change writes the state of XBFT as the dealer does
+				p.D.stateMutex.Lock()
 				//validation check
 				//if round has been proceeded already, it doesn't have to handle new round
 				if k.SetRound < p.ConsensusRound || k.SetPhase < int32(p.XBFTPhase) {
 					k.setDefault()
+					p.D.stateMutex.Unlock()
 					break
 				}
-				log.Println("triggered at round:", k.SetRound, "| on:", plum.XBFTPhase(k.SetPhase))
-				log.Println("peer's round:", p.ConsensusRound, "| on:", p.XBFTPhase)
+				p.log().Infof("timed out at round %d on %s", k.SetRound, plum.XBFTPhase(k.SetPhase))
 				p.D.triggerXBFTRoundChange()
 				k.setDefault()
+				p.D.stateMutex.Unlock()
 			case <-k.StopSig:
 				break XEXIT
 			}
 		}
 	default:
-		log.Fatalf("invalid consensus type at keeper run()")
+		p.log().Fatalf("invalid consensus type at keeper run()")
 	}
 }
 
 func (k *Keeper) stop() {
+	k.Timer.Stop()
 	k.StopSig <- true
 }
 
@@ -128,7 +135,7 @@ func (k *Keeper) setPBFTTimer(phase plum.PBFTPhase) {
 	case plum.PBFTPhase_PBFTCommit:
 		k.Timer.Reset(TimeoutPBFTCommit)
 	default:
-		log.Println("failed to set time because of the invalid phase")
+		GetInstance().log().Warnf("failed to set timer because of the invalid phase %s", phase)
 	}
 }
 
@@ -145,6 +152,6 @@ func (k *Keeper) setXBFTTimer(phase plum.XBFTPhase) {
 	case plum.XBFTPhase_XBFTCommit:
 		k.Timer.Reset(TimeoutXBFTCommit)
 	default:
-		log.Println("failed to set time because of the invalid phase")
+		GetInstance().log().Warnf("failed to set timer because of the invalid phase %s", phase)
 	}
 }
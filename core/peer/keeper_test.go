@@ -68,3 +68,20 @@ func TestKeeper_Clear(t *testing.T) {
 		t.Errorf("the keeper didn't clear timer variables properly")
 	}
 }
+
+func TestPeer_armRoundTimer(t *testing.T) {
+	p := GetInstance()
+	defer k.Reset()
+
+	p.armRoundTimer()
+	if got, want := k.SetPhase, int32(plum.PBFTPhase_PBFTNewRound); got != want || k.SetRound != p.ConsensusRound {
+		t.Errorf("timer of the round should be armed for a missing primary. got: %v, want: %v", got, want)
+	}
+
+	//the timer set by consensus is kept
+	k.Set(p.ConsensusRound, plum.PBFTPhase_PBFTCommit)
+	p.armRoundTimer()
+	if got, want := k.SetPhase, int32(plum.PBFTPhase_PBFTCommit); got != want {
+		t.Errorf("timer set by consensus should not be replaced. got: %v, want: %v", got, want)
+	}
+}
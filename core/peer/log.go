@@ -0,0 +1,34 @@
+package peer
+
+import (
+	"github.com/yoseplee/plum/core/logger"
+)
+
+//log returns the logger carrying the id of this peer and where it is in consensus
+func (p *peer) log() *logger.Logger {
+	fields := logger.Fields{
+		"peer":  p.ID,
+		"round": p.ConsensusRound,
+	}
+	if p.L != nil {
+		fields["height"] = p.L.CurrentHeight()
+	}
+	if phase := p.currentPhase(); phase != "" {
+		fields["phase"] = phase
+	}
+	return logger.With(fields)
+}
+
+//currentPhase returns the name of the phase this peer is in by its type of consensus, empty if it is not initiated
+func (p *peer) currentPhase() string {
+	if p.D == nil {
+		return ""
+	}
+	switch p.D.ConsensusType {
+	case "PBFT":
+		return p.PBFTPhase.String()
+	case "XBFT":
+		return p.XBFTPhase.String()
+	}
+	return ""
+}
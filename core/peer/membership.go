@@ -0,0 +1,343 @@
+package peer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/plum"
+	"sort"
+	"time"
+)
+
+const (
+	//defaultReputation is given to a validator which joins without reputation
+	defaultReputation = 1.0
+	//minValidators is the least number of validators to tolerate a faulty one
+	minValidators = 4
+)
+
+//validatorUpdateTxPrefix marks a transaction as a validator update. other transactions never start with 0x00
+var validatorUpdateTxPrefix = []byte{0x00, 'v', 'u'}
+
+func initialReputation(v *plum.Validator) float64 {
+	if v.GetReputation() > 0 {
+		return v.GetReputation()
+	}
+	return defaultReputation
+}
+
+//isValidator reports whether the peer participates in consensus at the current height
+func (p *peer) isValidator(id uint32) bool {
+	_, ok := p.validators[id]
+	return ok
+}
+
+func (p *peer) validatorCount() int {
+	return len(p.validators)
+}
+
+//validatorIDs returns ids of the validators in ascending order
+func (p *peer) validatorIDs() []uint32 {
+	var ids []uint32
+	for id := range p.validators {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+//validatorList returns the validators in ascending order of their ids with the public keys known to this peer
+func (p *peer) validatorList() []*plum.Validator {
+	var vs []*plum.Validator
+	for _, id := range p.validatorIDs() {
+		v := proto.Clone(p.validators[id]).(*plum.Validator)
+		if a, ok := p.AddressBook[id]; ok && len(v.PublicKey) == 0 {
+			v.PublicKey = a.PublicKey
+		}
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+func encodeValidatorUpdateTx(su *plum.SignedValidatorUpdate) ([]byte, error) {
+	m, err := proto.Marshal(su)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), validatorUpdateTxPrefix...), m...), nil
+}
+
+//decodeValidatorUpdateTx returns the validator update in the transaction, or false if it is not one
+func decodeValidatorUpdateTx(tx []byte) (*plum.SignedValidatorUpdate, bool) {
+	if !bytes.HasPrefix(tx, validatorUpdateTxPrefix) {
+		return nil, false
+	}
+	su := &plum.SignedValidatorUpdate{}
+	if err := proto.Unmarshal(tx[len(validatorUpdateTxPrefix):], su); err != nil {
+		return nil, false
+	}
+	return su, true
+}
+
+//updateQuorum reports whether the signers are more than 2/3 of the validators, as many as a validator update needs
+func (p *peer) updateQuorum(signers int) bool {
+	return signers*3 > p.validatorCount()*2
+}
+
+//verifyValidatorUpdate checks the update is signed by more than 2/3 of the validators and applicable to the current validator set
+func (p *peer) verifyValidatorUpdate(su *plum.SignedValidatorUpdate) error {
+	if err := p.checkValidatorUpdate(su); err != nil {
+		return err
+	}
+	if n := len(su.GetSignatures()); !p.updateQuorum(n) {
+		return fmt.Errorf("validator update is signed by %d of %d validators", n, p.validatorCount())
+	}
+	return nil
+}
+
+//checkValidatorUpdate checks every signature of the update is of a distinct validator, and the update is applicable at the current nonce
+//and validator set. it does not require the quorum, as the signatures are collected while the update is pending
+func (p *peer) checkValidatorUpdate(su *plum.SignedValidatorUpdate) error {
+	u := su.GetUpdate()
+	if u == nil {
+		return errors.New("empty validator update")
+	}
+	if u.GetNonce() != p.validatorNonce {
+		return fmt.Errorf("validator update of nonce %d is not applicable at nonce %d", u.GetNonce(), p.validatorNonce)
+	}
+
+	signers := make(map[uint32]bool)
+	for _, s := range su.GetSignatures() {
+		if !p.isValidator(s.GetSignerId()) {
+			return fmt.Errorf("signer %d is not a validator", s.GetSignerId())
+		}
+		if signers[s.GetSignerId()] {
+			return fmt.Errorf("signer %d is duplicated", s.GetSignerId())
+		}
+		a, ok := p.AddressBook[s.GetSignerId()]
+		if !ok || !verifySignature(a.PublicKey, p.chainID, u, s.GetSignature()) {
+			return fmt.Errorf("invalid signature of validator %d", s.GetSignerId())
+		}
+		signers[s.GetSignerId()] = true
+	}
+
+	switch u.GetType() {
+	case plum.ValidatorUpdateType_ValidatorJoin:
+		if p.isValidator(u.GetValidator().GetId()) {
+			return fmt.Errorf("peer %d is already a validator", u.GetValidator().GetId())
+		}
+		return checkJoiningValidator(u)
+	case plum.ValidatorUpdateType_ValidatorLeave:
+		if !p.isValidator(u.GetTargetId()) {
+			return fmt.Errorf("peer %d is not a validator", u.GetTargetId())
+		}
+		if p.validatorCount()-1 < minValidators {
+			return fmt.Errorf("there should be at least %d validators", minValidators)
+		}
+		return nil
+	case plum.ValidatorUpdateType_ValidatorReplace:
+		if !p.isValidator(u.GetTargetId()) {
+			return fmt.Errorf("peer %d is not a validator", u.GetTargetId())
+		}
+		//the target can be replaced by itself with a new key
+		if id := u.GetValidator().GetId(); id != u.GetTargetId() && p.isValidator(id) {
+			return fmt.Errorf("peer %d is already a validator", id)
+		}
+		return checkJoiningValidator(u)
+	default:
+		return fmt.Errorf("invalid type of validator update: %v", u.GetType())
+	}
+}
+
+func checkJoiningValidator(u *plum.ValidatorUpdate) error {
+	if len(u.GetValidator().GetPublicKey()) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key of peer %d", u.GetValidator().GetId())
+	}
+	if u.GetValidator().GetReputation() < 0 {
+		return fmt.Errorf("negative reputation of peer %d", u.GetValidator().GetId())
+	}
+	return nil
+}
+
+//addPendingValidatorUpdate keeps the update until it is included in a block, collecting the signatures of the same update.
+//it reports false if the update is already pending with all the signatures
+func (p *peer) addPendingValidatorUpdate(su *plum.SignedValidatorUpdate) bool {
+	p.pendingMutex.Lock()
+	defer p.pendingMutex.Unlock()
+	for _, pending := range p.pendingUpdates {
+		if !proto.Equal(pending.GetUpdate(), su.GetUpdate()) {
+			continue
+		}
+		added := false
+		for _, s := range su.GetSignatures() {
+			if !signedBy(pending, s.GetSignerId()) {
+				pending.Signatures = append(pending.Signatures, s)
+				added = true
+			}
+		}
+		return added
+	}
+	p.pendingUpdates = append(p.pendingUpdates, proto.Clone(su).(*plum.SignedValidatorUpdate))
+	return true
+}
+
+func signedBy(su *plum.SignedValidatorUpdate, id uint32) bool {
+	for _, s := range su.GetSignatures() {
+		if s.GetSignerId() == id {
+			return true
+		}
+	}
+	return false
+}
+
+//pendingValidatorUpdateTxs returns the pending updates signed by the quorum as transactions to be included in the next block of this peer
+func (p *peer) pendingValidatorUpdateTxs() [][]byte {
+	p.pendingMutex.Lock()
+	defer p.pendingMutex.Unlock()
+	var txs [][]byte
+	for _, su := range p.pendingUpdates {
+		if su.GetUpdate().GetNonce() != p.validatorNonce || !p.updateQuorum(len(su.GetSignatures())) {
+			continue
+		}
+		tx, err := encodeValidatorUpdateTx(su)
+		if err != nil {
+			p.log().Errorf("could not encode validator update: %v", err)
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+//removeStaleValidatorUpdates drops the pending updates of the nonces already taken, which can never be applied
+func (p *peer) removeStaleValidatorUpdates() {
+	p.pendingMutex.Lock()
+	defer p.pendingMutex.Unlock()
+	var kept []*plum.SignedValidatorUpdate
+	for _, pending := range p.pendingUpdates {
+		if pending.GetUpdate().GetNonce() >= p.validatorNonce {
+			kept = append(kept, pending)
+		}
+	}
+	p.pendingUpdates = kept
+}
+
+//forwardValidatorUpdate sends the update to all the other peers, so that whoever proposes the next block includes it
+func (p *peer) forwardValidatorUpdate(su *plum.SignedValidatorUpdate) {
+	for _, a := range p.AddressBook {
+		if a.PeerId == p.ID || a.peerClient == nil {
+			continue
+		}
+		go func(a *Connection) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			defer cancel()
+			if _, err := a.peerClient.SubmitValidatorUpdate(ctx, su); err != nil {
+				p.log().Warnf("could not forward validator update to peer %d: %v", a.PeerId, err)
+			}
+		}(a)
+	}
+}
+
+//applyValidatorUpdates applies the validator updates in the appended block in order, each taking the next nonce, then returns the applied ones.
+//as every peer applies the same block on the same validator set, an invalid update is skipped by all of them
+func (p *peer) applyValidatorUpdates(b *plum.Block) []*plum.ValidatorUpdate {
+	var applied []*plum.ValidatorUpdate
+	for _, tx := range b.GetBody().GetTxs() {
+		su, ok := decodeValidatorUpdateTx(tx)
+		if !ok {
+			continue
+		}
+
+		if err := p.verifyValidatorUpdate(su); err != nil {
+			p.log().Warnf("skip validator update in block %d: %v", b.GetHeader().GetId(), err)
+			continue
+		}
+		p.applyValidatorUpdate(su.GetUpdate())
+		p.validatorNonce++
+		applied = append(applied, su.GetUpdate())
+	}
+
+	if len(applied) != 0 {
+		p.removeStaleValidatorUpdates()
+		p.setPBFTThreshold()
+		p.log().Infof("validator set changed at height %d: %v", b.GetHeader().GetId(), p.validatorIDs())
+	}
+	return applied
+}
+
+//applyValidatorUpdate changes the validator set, address book and reputation book.
+//maps are replaced rather than modified, as they are read by other goroutines while sending messages.
+//the reputation book is changed under the lock, as it is read by the peer state
+func (p *peer) applyValidatorUpdate(u *plum.ValidatorUpdate) {
+	validators := make(map[uint32]*plum.Validator)
+	for id, v := range p.validators {
+		validators[id] = v
+	}
+
+	switch u.GetType() {
+	case plum.ValidatorUpdateType_ValidatorJoin:
+		validators[u.GetValidator().GetId()] = u.GetValidator()
+		p.mutex.Lock()
+		p.setReputation(u.GetValidator().GetId(), initialReputation(u.GetValidator()), plum.ReputationEventType_ReputationJoin, reasonJoined)
+		p.mutex.Unlock()
+		p.addAddress(u)
+	case plum.ValidatorUpdateType_ValidatorLeave:
+		//the connection is kept, so that the leaving peer can still follow the chain
+		delete(validators, u.GetTargetId())
+		p.mutex.Lock()
+		p.removeReputation(u.GetTargetId(), reasonLeft)
+		p.mutex.Unlock()
+	case plum.ValidatorUpdateType_ValidatorReplace:
+		delete(validators, u.GetTargetId())
+		validators[u.GetValidator().GetId()] = u.GetValidator()
+		p.mutex.Lock()
+		//the new validator takes over the reputation of the replaced one unless it is given
+		reputation := p.ReputationBook[u.GetTargetId()]
+		if u.GetValidator().GetReputation() > 0 {
+			reputation = u.GetValidator().GetReputation()
+		}
+		p.removeReputation(u.GetTargetId(), reasonReplaced)
+		p.setReputation(u.GetValidator().GetId(), reputation, plum.ReputationEventType_ReputationJoin, reasonReplaced)
+		p.mutex.Unlock()
+		p.addAddress(u)
+	}
+	p.validators = validators
+
+	if !p.isValidator(p.ID) {
+		p.log().Infof("this peer is not a validator, it follows the chain without participating in consensus")
+	}
+}
+
+//addAddress puts the address of the joining validator into the address book and connects to it.
+//a peer already in the address book keeps its connection with the public key updated
+func (p *peer) addAddress(u *plum.ValidatorUpdate) {
+	id := u.GetValidator().GetId()
+	var a *Connection
+	if known, ok := p.AddressBook[id]; ok {
+		//the connection is copied with the new key, as the one in the book is read while sending messages
+		c := *known
+		c.PublicKey = u.GetValidator().GetPublicKey()
+		a = &c
+	} else {
+		a = &Connection{
+			PeerId:        id,
+			ContainerName: u.GetContainerName(),
+			PublicKey:     u.GetValidator().GetPublicKey(),
+			Ipv4:          u.GetIpv4(),
+			Port:          u.GetPort(),
+		}
+		if err := p.connect(a); err != nil {
+			p.log().Errorf("could not connect to the joining validator %d: %v", id, err)
+		}
+	}
+
+	addressBook := make(map[uint32]*Connection)
+	for k, v := range p.AddressBook {
+		addressBook[k] = v
+	}
+	addressBook[id] = a
+	p.AddressBook = addressBook
+}
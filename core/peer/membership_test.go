@@ -0,0 +1,262 @@
+package peer
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"testing"
+)
+
+//saveMembershipForTest keeps the validator set of the test peer and returns a function to restore it
+func saveMembershipForTest() func() {
+	p := GetInstance()
+	validators, addressBook, nonce := p.validators, p.AddressBook, p.validatorNonce
+	reputationBook := make(map[uint32]float64)
+	for k, v := range p.ReputationBook {
+		reputationBook[k] = v
+	}
+	return func() {
+		p.validators, p.AddressBook, p.ReputationBook, p.validatorNonce = validators, addressBook, reputationBook, nonce
+		p.pendingUpdates = nil
+		p.setPBFTThreshold()
+	}
+}
+
+//signedValidatorUpdateForTest signs the update at the nonce with each of the keys
+func signedValidatorUpdateForTest(u *plum.ValidatorUpdate, nonce uint64, keys map[uint32]ed25519.PrivateKey) *plum.SignedValidatorUpdate {
+	p := GetInstance()
+	u.Nonce = nonce
+	su := &plum.SignedValidatorUpdate{Update: u}
+	for id, key := range keys {
+		su.Signatures = append(su.Signatures, &plum.ValidatorSignature{SignerId: id, Signature: sign(key, p.chainID, u)})
+	}
+	return su
+}
+
+func joinForTest(id uint32, pub ed25519.PublicKey) *plum.ValidatorUpdate {
+	if pub == nil {
+		pub, _, _ = ed25519.GenerateKey(nil)
+	}
+	return &plum.ValidatorUpdate{
+		Type:      plum.ValidatorUpdateType_ValidatorJoin,
+		Validator: &plum.Validator{Id: id, PublicKey: pub, Reputation: 2.0},
+		Ipv4:      "localhost",
+		Port:      ":50121",
+	}
+}
+
+func leaveForTest(id uint32) *plum.ValidatorUpdate {
+	return &plum.ValidatorUpdate{Type: plum.ValidatorUpdateType_ValidatorLeave, TargetId: id}
+}
+
+func TestDecodeValidatorUpdateTx(t *testing.T) {
+	su := signedValidatorUpdateForTest(leaveForTest(3), 0, setSigningKeysForTest())
+	tx, err := encodeValidatorUpdateTx(su)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := decodeValidatorUpdateTx(tx)
+	if !ok || got.GetUpdate().GetTargetId() != 3 || !proto.Equal(got, su) {
+		t.Errorf("invalid decoding of validator update. got: %v, want: %v", got, su)
+	}
+
+	if _, ok := decodeValidatorUpdateTx([]byte("tx100")); ok {
+		t.Errorf("a normal transaction should not be decoded as a validator update")
+	}
+}
+
+func TestPeer_verifyValidatorUpdate(t *testing.T) {
+	p := GetInstance()
+	keys := setSigningKeysForTest()
+	nonce := p.validatorNonce
+
+	if err := p.verifyValidatorUpdate(signedValidatorUpdateForTest(joinForTest(100, nil), nonce, keys)); err != nil {
+		t.Errorf("valid join is not verified: %v", err)
+	}
+
+	if err := p.verifyValidatorUpdate(signedValidatorUpdateForTest(joinForTest(1, nil), nonce, keys)); err == nil {
+		t.Errorf("join of a validator should not be verified")
+	}
+
+	if err := p.verifyValidatorUpdate(signedValidatorUpdateForTest(leaveForTest(100), nonce, keys)); err == nil {
+		t.Errorf("leave of a peer which is not a validator should not be verified")
+	}
+
+	//signed by a peer which is not the signer
+	su := signedValidatorUpdateForTest(leaveForTest(1), nonce, keys)
+	su.Signatures[1].Signature = su.Signatures[0].Signature
+	if err := p.verifyValidatorUpdate(su); err == nil {
+		t.Errorf("update with invalid signature should not be verified")
+	}
+
+	//signature on another chain
+	su = signedValidatorUpdateForTest(leaveForTest(1), nonce, keys)
+	su.Signatures[0].Signature = sign(keys[su.Signatures[0].SignerId], "another-chain", su.Update)
+	if err := p.verifyValidatorUpdate(su); err == nil {
+		t.Errorf("update signed on another chain should not be verified")
+	}
+
+	//a single validator, or 2/3 of them, can not change the validator set
+	few := make(map[uint32]ed25519.PrivateKey)
+	for id := uint32(0); (len(few)+1)*3 <= p.validatorCount()*2; id++ {
+		few[id] = keys[id]
+	}
+	su = signedValidatorUpdateForTest(leaveForTest(1), nonce, few)
+	if err := p.verifyValidatorUpdate(su); err == nil {
+		t.Errorf("update signed by %d of %d validators should not be verified", len(few), p.validatorCount())
+	}
+	if err := p.checkValidatorUpdate(su); err != nil {
+		t.Errorf("update lacking signatures should be taken to collect the others: %v", err)
+	}
+
+	//a signer counts once
+	su = signedValidatorUpdateForTest(leaveForTest(1), nonce, few)
+	su.Signatures = append(su.Signatures, su.Signatures[0])
+	if err := p.checkValidatorUpdate(su); err == nil {
+		t.Errorf("update with a duplicated signer should not be verified")
+	}
+
+	//the update is bound to the nonce
+	if err := p.verifyValidatorUpdate(signedValidatorUpdateForTest(leaveForTest(1), nonce+1, keys)); err == nil {
+		t.Errorf("update of another nonce should not be verified")
+	}
+
+	replace := joinForTest(1, nil)
+	replace.Type = plum.ValidatorUpdateType_ValidatorReplace
+	replace.TargetId = 1
+	if err := p.verifyValidatorUpdate(signedValidatorUpdateForTest(replace, nonce, keys)); err != nil {
+		t.Errorf("replacing a validator with a new key is not verified: %v", err)
+	}
+}
+
+func TestPeer_applyValidatorUpdates(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+
+	before := p.validatorCount()
+	nonce := p.validatorNonce
+	keys := setSigningKeysForTest()
+	delete(keys, 5)
+	delete(keys, 6)
+
+	//the joining validator signs the updates after its join, each of which takes the next nonce
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	join := signedValidatorUpdateForTest(joinForTest(100, pub), nonce, keys)
+	keys[100] = priv
+	updates := []*plum.SignedValidatorUpdate{
+		join,
+		signedValidatorUpdateForTest(leaveForTest(5), nonce+1, keys),
+		signedValidatorUpdateForTest(leaveForTest(6), nonce+2, keys),
+		signedValidatorUpdateForTest(joinForTest(1, nil), nonce+3, keys),
+		//replaying the join applied
+		join,
+	}
+	var txs [][]byte
+	for _, su := range updates {
+		tx, _ := encodeValidatorUpdateTx(su)
+		txs = append(txs, tx)
+	}
+	txs = append(txs, p.RetrieveTxs()...)
+
+	applied := p.applyValidatorUpdates(block.NewBlock(txs, nil, 1))
+	if got, want := len(applied), 3; got != want {
+		t.Errorf("invalid number of updates applied. got: %d, want: %d", got, want)
+	}
+
+	if got, want := p.validatorCount(), before-1; got != want {
+		t.Fatalf("invalid number of validators. got: %d, want: %d", got, want)
+	}
+	if p.isValidator(5) || p.isValidator(6) || !p.isValidator(100) {
+		t.Errorf("invalid validator set: %v", p.validatorIDs())
+	}
+	if _, ok := p.ReputationBook[5]; ok || p.ReputationBook[100] != 2.0 {
+		t.Errorf("invalid reputation book: %v", p.ReputationBook)
+	}
+	if a, ok := p.AddressBook[100]; !ok || !bytes.Equal(a.PublicKey, join.GetUpdate().GetValidator().GetPublicKey()) || a.consensusClient == nil {
+		t.Errorf("joining validator should be connected with its public key")
+	}
+	if got, want := p.validatorNonce, nonce+3; got != want {
+		t.Errorf("invalid nonce of validator updates. got: %d, want: %d", got, want)
+	}
+
+	//thresholds and primary follow the new validator set
+	if got, want := p.PBFTThreshold[plum.PBFTPhase_PBFTCommit], 2*((p.validatorCount()-1)/3); got != want {
+		t.Errorf("invalid threshold. got: %d, want: %d", got, want)
+	}
+	ids := p.validatorIDs()
+	if got := p.NewPrimary(uint64(len(ids) - 1)); got != 100 {
+		t.Errorf("invalid primary. got: %d, want: %d", got, 100)
+	}
+
+	//messages from a validator which has left are not accepted
+	m := &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTCommit, PeerId: 5}
+	if p.VerifyConsensusMessageSignature(&plum.PBFTRequest{Message: m}) {
+		t.Errorf("message from a peer which has left should not be verified")
+	}
+}
+
+func TestPeer_applyValidatorUpdate_Replace(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+
+	//the connection read by senders keeps its key, the address book takes a new one
+	old := p.AddressBook[3]
+	key := old.PublicKey
+	reputation := p.ReputationBook[3]
+	replace := joinForTest(3, nil)
+	replace.Type = plum.ValidatorUpdateType_ValidatorReplace
+	replace.TargetId = 3
+	replace.Validator.Reputation = 0
+	p.applyValidatorUpdate(replace)
+
+	if !bytes.Equal(old.PublicKey, key) {
+		t.Errorf("connection in the address book should not be changed in place")
+	}
+	if a := p.AddressBook[3]; a == old || !bytes.Equal(a.PublicKey, replace.GetValidator().GetPublicKey()) || a.consensusClient != old.consensusClient {
+		t.Errorf("address book should have the connection with the new key")
+	}
+	if got := p.ReputationBook[3]; got != reputation {
+		t.Errorf("replacing validator should take over the reputation. got: %v, want: %v", got, reputation)
+	}
+}
+
+func TestPeer_pendingValidatorUpdate(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+
+	keys := setSigningKeysForTest()
+	u := joinForTest(100, nil)
+
+	//signatures of the same update are collected until the quorum
+	var signatures []*plum.ValidatorSignature
+	for id, key := range keys {
+		su := signedValidatorUpdateForTest(proto.Clone(u).(*plum.ValidatorUpdate), p.validatorNonce, map[uint32]ed25519.PrivateKey{id: key})
+		if !p.addPendingValidatorUpdate(su) {
+			t.Errorf("new signature of the update should be pending")
+		}
+		if p.addPendingValidatorUpdate(su) {
+			t.Errorf("same signature should be pending only once")
+		}
+		signatures = append(signatures, su.Signatures...)
+		if want := p.updateQuorum(len(signatures)); len(p.pendingValidatorUpdateTxs()) != 0 != want {
+			t.Errorf("update signed by %d validators should be included in a block: %v", len(signatures), want)
+		}
+	}
+
+	b := p.NewCandidateBlock()
+	got, ok := decodeValidatorUpdateTx(b.GetBody().GetTxs()[0])
+	if !ok || got.GetUpdate().GetValidator().GetId() != 100 {
+		t.Fatalf("pending update should be included in the candidate block")
+	}
+
+	p.applyValidatorUpdates(b)
+	if !p.isValidator(100) {
+		t.Errorf("update signed by the quorum should be applied")
+	}
+	if len(p.pendingUpdates) != 0 {
+		t.Errorf("update included in a block should not be pending")
+	}
+}
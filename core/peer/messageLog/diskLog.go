@@ -0,0 +1,256 @@
+package messageLog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/logger"
+	"github.com/yoseplee/plum/core/plum"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//lengthSize is the size of the length written before each message in a file
+const lengthSize = 4
+
+//DiskLog keeps the messages in files, one for each height, so that they are found even after the peer stops.
+//only the index of the messages is in memory, messages are read from the files when they are found
+type DiskLog struct {
+	dir       string
+	idx       *index
+	retention uint64
+	//file is open to append the messages of the height
+	file       *os.File
+	fileHeight uint64
+	mutex      *sync.Mutex
+}
+
+//NewDiskLog opens the message log in the directory, indexing the messages left by the previous run
+func NewDiskLog(dir string) (*DiskLog, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	d := &DiskLog{
+		dir:   dir,
+		idx:   newIndex(),
+		mutex: &sync.Mutex{},
+	}
+	heights, err := d.heights()
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range heights {
+		if err := d.load(h); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (d *DiskLog) path(height uint64) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%d.msg", height))
+}
+
+//heights returns the heights which have a file in the directory, in ascending order
+func (d *DiskLog) heights() ([]uint64, error) {
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	var heights []uint64
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".msg") {
+			continue
+		}
+		h, err := strconv.ParseUint(strings.TrimSuffix(f.Name(), ".msg"), 10, 64)
+		if err != nil {
+			continue
+		}
+		heights = append(heights, h)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights, nil
+}
+
+//load indexes the messages in the file of the height.
+//a message partly written as the peer has stopped is cut off, so that the next one is appended right after the last whole one
+func (d *DiskLog) load(height uint64) error {
+	b, err := ioutil.ReadFile(d.path(height))
+	if err != nil {
+		return err
+	}
+	var offset int64
+	for int(offset)+lengthSize <= len(b) {
+		size := int(binary.BigEndian.Uint32(b[offset:]))
+		start := int(offset) + lengthSize
+		if start+size > len(b) {
+			break
+		}
+		lm := &plum.LoggedMessage{}
+		if err := proto.Unmarshal(b[start:start+size], lm); err != nil {
+			break
+		}
+		k, _, signature := describe(lm)
+		d.idx.add(k, &record{height: height, signature: signature, offset: offset, size: size})
+		offset = int64(start + size)
+	}
+	if int(offset) != len(b) {
+		logger.Warnf("message log of height %d is cut off at %d of %d bytes", height, offset, len(b))
+		return os.Truncate(d.path(height), offset)
+	}
+	return nil
+}
+
+//open returns the file to append the messages of the height
+func (d *DiskLog) open(height uint64) (*os.File, error) {
+	if d.file != nil && d.fileHeight == height {
+		return d.file, nil
+	}
+	if d.file != nil {
+		d.file.Close()
+		d.file = nil
+	}
+	f, err := os.OpenFile(d.path(height), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	d.file = f
+	d.fileHeight = height
+	return f, nil
+}
+
+func (d *DiskLog) Store(i interface{}) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	lm := wrap(i)
+	k, height, signature := describe(lm)
+	if d.idx.has(k, signature) {
+		return
+	}
+	m, err := proto.Marshal(lm)
+	if err != nil {
+		logger.Errorf("could not marshal the message to log: %v", err)
+		return
+	}
+	f, err := d.open(height)
+	if err != nil {
+		logger.Errorf("could not open message log of height %d: %v", height, err)
+		return
+	}
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		logger.Errorf("could not seek message log of height %d: %v", height, err)
+		return
+	}
+
+	b := make([]byte, lengthSize+len(m))
+	binary.BigEndian.PutUint32(b, uint32(len(m)))
+	copy(b[lengthSize:], m)
+	if _, err := f.Write(b); err != nil {
+		logger.Errorf("could not write message log of height %d: %v", height, err)
+		return
+	}
+	d.idx.add(k, &record{height: height, signature: signature, offset: offset, size: len(m)})
+}
+
+func (d *DiskLog) Clear() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.file != nil {
+		d.file.Close()
+		d.file = nil
+	}
+	for h := range d.idx.heights {
+		if err := os.Remove(d.path(h)); err != nil && !os.IsNotExist(err) {
+			logger.Errorf("could not remove message log of height %d: %v", h, err)
+		}
+	}
+	d.idx = newIndex()
+}
+
+func (d *DiskLog) Get(r uint64, ph interface{}) interface{} {
+	return requests(d.Find(r, []string{phaseName(ph)}, nil), ph)
+}
+
+//Find reads the messages of the round from the files. the ones which could not be read are left out
+func (d *DiskLog) Find(r uint64, phases []string, senders []uint32) []*plum.LoggedMessage {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.read(d.idx.find(r, phases, senders))
+}
+
+func (d *DiskLog) All() []*plum.LoggedMessage {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.read(d.idx.all())
+}
+
+//read reads the messages of the records from the files
+func (d *DiskLog) read(rs []*record) []*plum.LoggedMessage {
+	files := make(map[uint64][]byte)
+	var lms []*plum.LoggedMessage
+	for _, rec := range rs {
+		b, ok := files[rec.height]
+		if !ok {
+			var err error
+			if b, err = ioutil.ReadFile(d.path(rec.height)); err != nil {
+				logger.Errorf("could not read message log of height %d: %v", rec.height, err)
+			}
+			files[rec.height] = b
+		}
+		start := rec.offset + lengthSize
+		if int(start)+rec.size > len(b) {
+			continue
+		}
+		lm := &plum.LoggedMessage{}
+		if err := proto.Unmarshal(b[start:int(start)+rec.size], lm); err != nil {
+			logger.Errorf("could not unmarshal message log of height %d at %d: %v", rec.height, rec.offset, err)
+			continue
+		}
+		lms = append(lms, lm)
+	}
+	return lms
+}
+
+func (d *DiskLog) SetRetention(n uint64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.retention = n
+}
+
+//Prune removes the files of the heights older than the retention
+func (d *DiskLog) Prune(committed uint64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.retention == 0 || committed <= d.retention {
+		return
+	}
+	for _, h := range d.idx.prune(committed - d.retention) {
+		if d.file != nil && d.fileHeight == h {
+			d.file.Close()
+			d.file = nil
+		}
+		if err := os.Remove(d.path(h)); err != nil && !os.IsNotExist(err) {
+			logger.Errorf("could not remove message log of height %d: %v", h, err)
+		}
+	}
+}
+
+func (d *DiskLog) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
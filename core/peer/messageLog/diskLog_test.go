@@ -0,0 +1,122 @@
+package messageLog
+
+import (
+	"github.com/yoseplee/plum/core/plum"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func xbftRequest(round, height uint64, ph plum.XBFTPhase, sender uint32) *plum.XBFTRequest {
+	return &plum.XBFTRequest{
+		Message:   &plum.XBFTMessage{Phase: ph, Round: round, Height: height, PeerId: sender},
+		Signature: []byte{byte(round), byte(ph), byte(sender)},
+	}
+}
+
+func TestDiskLog_Find(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := NewDiskLog(dir)
+	if err != nil {
+		t.Fatalf("could not open the message log: %v", err)
+	}
+	for sender := uint32(0); sender < 4; sender++ {
+		d.Store(xbftRequest(1, 1, plum.XBFTPhase_XBFTPrepare, sender))
+		d.Store(xbftRequest(1, 1, plum.XBFTPhase_XBFTCommit, sender))
+	}
+	d.Store(&plum.PBFTRequest{
+		Message:   &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTPrepare, Round: 1, Height: 1, PeerId: 2},
+		Signature: []byte{1},
+	})
+	//a message handled again is kept once
+	d.Store(xbftRequest(1, 1, plum.XBFTPhase_XBFTPrepare, 0))
+
+	if got := len(d.Find(1, nil, nil)); got != 9 {
+		t.Errorf("invalid number of messages of the round. got: %d, want: %d", got, 9)
+	}
+	if got := len(d.Find(1, []string{"XBFTCommit"}, []uint32{1, 2})); got != 2 {
+		t.Errorf("invalid number of messages of the phase and senders. got: %d, want: %d", got, 2)
+	}
+	if got := len(d.Get(1, plum.PBFTPhase_PBFTPrepare).([]*plum.PBFTRequest)); got != 1 {
+		t.Errorf("invalid number of PBFT messages. got: %d, want: %d", got, 1)
+	}
+	lms := d.Find(1, []string{"XBFTPrepare"}, nil)
+	for i, lm := range lms {
+		if lm.GetXbft().GetMessage().GetPeerId() != uint32(i) {
+			t.Errorf("messages should be in the order they are stored. got: %d, want: %d", lm.GetXbft().GetMessage().GetPeerId(), i)
+		}
+	}
+
+	//messages are found after the log is opened again
+	d.Close()
+	d, err = NewDiskLog(dir)
+	if err != nil {
+		t.Fatalf("could not open the message log again: %v", err)
+	}
+	if got := len(d.Find(1, nil, nil)); got != 9 {
+		t.Errorf("invalid number of messages after reopening. got: %d, want: %d", got, 9)
+	}
+	d.Close()
+}
+
+func TestDiskLog_Prune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := NewDiskLog(dir)
+	if err != nil {
+		t.Fatalf("could not open the message log: %v", err)
+	}
+	defer d.Close()
+	d.SetRetention(2)
+	for h := uint64(1); h <= 5; h++ {
+		d.Store(xbftRequest(h, h, plum.XBFTPhase_XBFTPrepare, 0))
+	}
+
+	d.Prune(5)
+	for h := uint64(1); h <= 5; h++ {
+		_, err := os.Stat(d.path(h))
+		kept := h > 3
+		if kept != (err == nil) {
+			t.Errorf("file of height %d should be kept: %v", h, kept)
+		}
+		if got := len(d.Find(h, nil, nil)); kept != (got == 1) {
+			t.Errorf("messages of round %d should be kept: %v, got: %d", h, kept, got)
+		}
+	}
+}
+
+func TestDiskLog_cutOff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, _ := NewDiskLog(dir)
+	d.Store(xbftRequest(1, 1, plum.XBFTPhase_XBFTPrepare, 0))
+	d.Close()
+
+	//a message partly written as the peer has stopped
+	f, _ := os.OpenFile(d.path(1), os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write([]byte{0, 0, 0, 100, 1, 2})
+	f.Close()
+
+	d, err = NewDiskLog(dir)
+	if err != nil {
+		t.Fatalf("could not open the message log: %v", err)
+	}
+	defer d.Close()
+	d.Store(xbftRequest(1, 1, plum.XBFTPhase_XBFTPrepare, 1))
+	if got := len(d.Find(1, nil, nil)); got != 2 {
+		t.Errorf("invalid number of messages after cutting off. got: %d, want: %d", got, 2)
+	}
+}
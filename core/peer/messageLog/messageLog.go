@@ -1,46 +1,307 @@
 package messageLog
 
 import (
+	"bytes"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/yoseplee/plum/core/plum"
-	"log"
+	"sort"
+	"sync"
 )
 
+//LogManager keeps the consensus messages handled by a peer, to find out afterwards what has happened in a round
 type LogManager interface {
+	//Store keeps a *plum.PBFTRequest or *plum.XBFTRequest. a message already kept is ignored
 	Store(interface{})
+	//Clear drops every message
 	Clear()
+	//Get returns the messages of the round in the phase, []*plum.PBFTRequest for a plum.PBFTPhase or []*plum.XBFTRequest for a plum.XBFTPhase
 	Get(r uint64, ph interface{}) interface{}
+	//Find returns the messages of the round in the order they are stored. empty phases or senders mean all of them
+	Find(r uint64, phases []string, senders []uint32) []*plum.LoggedMessage
+	//All returns every message in the log in the order they are stored
+	All() []*plum.LoggedMessage
+	//SetRetention makes the log keep the messages of n heights below the committed height, 0 means keeping all
+	SetRetention(n uint64)
+	//Prune drops the messages older than the retention as the block of the height is committed
+	Prune(committed uint64)
+	//Close releases what the log holds, the log should not be used after
+	Close() error
 }
 
-type MessageLog struct {
-	log []*plum.XBFTRequest
+//key is what a message is indexed by
+type key struct {
+	round  uint64
+	phase  string
+	sender uint32
 }
 
-func (m *MessageLog) Store(i interface{}) {
-	switch i.(type) {
+//record is a message in the log. msg is kept in memory, or the message is at the offset of the file of the height
+type record struct {
+	seq       uint64
+	height    uint64
+	signature []byte
+	msg       *plum.LoggedMessage
+	offset    int64
+	size      int
+}
+
+//wrap makes the request a message to be logged, stamped with the current time
+func wrap(i interface{}) *plum.LoggedMessage {
+	lm := &plum.LoggedMessage{Timestamp: ptypes.TimestampNow()}
+	switch m := i.(type) {
+	case *plum.PBFTRequest:
+		lm.Request = &plum.LoggedMessage_Pbft{Pbft: m}
 	case *plum.XBFTRequest:
-		m.log = append(m.log, i.(*plum.XBFTRequest))
+		lm.Request = &plum.LoggedMessage_Xbft{Xbft: m}
 	default:
-		log.Panic("invalid type for MessageLog Store()")
+		panic("invalid type for MessageLog Store()")
 	}
+	return lm
 }
 
-func (m *MessageLog) Clear() {
-	m.log = nil
+//describe returns what the logged message is indexed by, with its height and signature
+func describe(lm *plum.LoggedMessage) (key, uint64, []byte) {
+	if m := lm.GetPbft(); m != nil {
+		return key{m.GetMessage().GetRound(), m.GetMessage().GetPhase().String(), m.GetMessage().GetPeerId()}, m.GetMessage().GetHeight(), m.GetSignature()
+	}
+	m := lm.GetXbft()
+	return key{m.GetMessage().GetRound(), m.GetMessage().GetPhase().String(), m.GetMessage().GetPeerId()}, m.GetMessage().GetHeight(), m.GetSignature()
 }
 
-func (m *MessageLog) Get(r uint64, ph interface{}) interface{} {
-	var prePrepares []*plum.XBFTRequest
-	for i := len(m.log); i > 0; i-- {
-		idx := i - 1
-		l := m.log[idx]
+//requests picks the requests of the phase out of the logged messages
+func requests(lms []*plum.LoggedMessage, ph interface{}) interface{} {
+	switch ph.(type) {
+	case plum.PBFTPhase:
+		var rs []*plum.PBFTRequest
+		for _, lm := range lms {
+			if m := lm.GetPbft(); m != nil {
+				rs = append(rs, m)
+			}
+		}
+		return rs
+	case plum.XBFTPhase:
+		var rs []*plum.XBFTRequest
+		for _, lm := range lms {
+			if m := lm.GetXbft(); m != nil {
+				rs = append(rs, m)
+			}
+		}
+		return rs
+	default:
+		panic("invalid phase for MessageLog Get()")
+	}
+}
+
+func phaseName(ph interface{}) string {
+	switch ph := ph.(type) {
+	case plum.PBFTPhase:
+		return ph.String()
+	case plum.XBFTPhase:
+		return ph.String()
+	default:
+		panic("invalid phase for MessageLog Get()")
+	}
+}
+
+//index finds the records by round, phase and sender
+type index struct {
+	seq     uint64
+	records map[uint64]map[string]map[uint32][]*record
+	heights map[uint64][]key
+}
+
+func newIndex() *index {
+	return &index{
+		records: make(map[uint64]map[string]map[uint32][]*record),
+		heights: make(map[uint64][]key),
+	}
+}
+
+//has returns whether the message of the signature is in the index. unsigned messages are never found to be the same
+func (x *index) has(k key, signature []byte) bool {
+	if len(signature) == 0 {
+		return false
+	}
+	for _, r := range x.records[k.round][k.phase][k.sender] {
+		if bytes.Equal(r.signature, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+func (x *index) add(k key, r *record) {
+	x.seq++
+	r.seq = x.seq
+	if x.records[k.round] == nil {
+		x.records[k.round] = make(map[string]map[uint32][]*record)
+	}
+	if x.records[k.round][k.phase] == nil {
+		x.records[k.round][k.phase] = make(map[uint32][]*record)
+	}
+	x.records[k.round][k.phase][k.sender] = append(x.records[k.round][k.phase][k.sender], r)
+	x.heights[r.height] = append(x.heights[r.height], k)
+}
+
+//find returns the records of the round in the order they are added
+func (x *index) find(round uint64, phases []string, senders []uint32) []*record {
+	found := x.collect(round, phases, senders)
+	sort.Slice(found, func(i, j int) bool { return found[i].seq < found[j].seq })
+	return found
+}
+
+//all returns every record in the order they are added
+func (x *index) all() []*record {
+	var found []*record
+	for round := range x.records {
+		found = append(found, x.collect(round, nil, nil)...)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].seq < found[j].seq })
+	return found
+}
+
+func (x *index) collect(round uint64, phases []string, senders []uint32) []*record {
+	var found []*record
+	for phase, bySender := range x.records[round] {
+		if len(phases) != 0 && !containsString(phases, phase) {
+			continue
+		}
+		for sender, rs := range bySender {
+			if len(senders) != 0 && !containsUint32(senders, sender) {
+				continue
+			}
+			found = append(found, rs...)
+		}
+	}
+	return found
+}
+
+//prune drops the records of the heights up to the given one, then returns the heights dropped
+func (x *index) prune(height uint64) []uint64 {
+	var dropped []uint64
+	for h, keys := range x.heights {
+		if h > height {
+			continue
+		}
+		for _, k := range keys {
+			rs := x.records[k.round][k.phase][k.sender]
+			kept := rs[:0]
+			for _, r := range rs {
+				if r.height != h {
+					kept = append(kept, r)
+				}
+			}
+			if len(kept) != 0 {
+				x.records[k.round][k.phase][k.sender] = kept
+				continue
+			}
+			delete(x.records[k.round][k.phase], k.sender)
+			if len(x.records[k.round][k.phase]) == 0 {
+				delete(x.records[k.round], k.phase)
+			}
+			if len(x.records[k.round]) == 0 {
+				delete(x.records, k.round)
+			}
+		}
+		delete(x.heights, h)
+		dropped = append(dropped, h)
+	}
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i] < dropped[j] })
+	return dropped
+}
 
-		if l.GetMessage().GetRound() < r {
-			break
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
 		}
+	}
+	return false
+}
 
-		if l.GetMessage().GetRound() == r && l.GetMessage().GetPhase() == ph.(plum.XBFTPhase) {
-			prePrepares = append(prePrepares, l)
+func containsUint32(ns []uint32, n uint32) bool {
+	for _, e := range ns {
+		if e == n {
+			return true
 		}
 	}
-	return prePrepares
+	return false
+}
+
+//MessageLog keeps the messages in memory, which are lost when the peer stops. its zero value is ready to use
+type MessageLog struct {
+	idx       *index
+	retention uint64
+	mutex     sync.Mutex
+}
+
+func (m *MessageLog) init() {
+	if m.idx == nil {
+		m.idx = newIndex()
+	}
+}
+
+func (m *MessageLog) Store(i interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.init()
+
+	lm := wrap(i)
+	k, height, signature := describe(lm)
+	if m.idx.has(k, signature) {
+		return
+	}
+	m.idx.add(k, &record{height: height, signature: signature, msg: lm})
+}
+
+func (m *MessageLog) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.idx = newIndex()
+}
+
+func (m *MessageLog) Get(r uint64, ph interface{}) interface{} {
+	return requests(m.Find(r, []string{phaseName(ph)}, nil), ph)
+}
+
+func (m *MessageLog) Find(r uint64, phases []string, senders []uint32) []*plum.LoggedMessage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.init()
+	return messages(m.idx.find(r, phases, senders))
+}
+
+func (m *MessageLog) All() []*plum.LoggedMessage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.init()
+	return messages(m.idx.all())
+}
+
+func messages(rs []*record) []*plum.LoggedMessage {
+	var lms []*plum.LoggedMessage
+	for _, r := range rs {
+		lms = append(lms, r.msg)
+	}
+	return lms
+}
+
+func (m *MessageLog) SetRetention(n uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.retention = n
+}
+
+func (m *MessageLog) Prune(committed uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.init()
+	if m.retention == 0 || committed <= m.retention {
+		return
+	}
+	m.idx.prune(committed - m.retention)
+}
+
+func (m *MessageLog) Close() error {
+	return nil
 }
@@ -0,0 +1,80 @@
+package peer
+
+import (
+	"fmt"
+	"github.com/yoseplee/plum/core/metrics"
+	"github.com/yoseplee/plum/core/plum"
+	"sync"
+	"time"
+)
+
+var registerMetricsOnce sync.Once
+
+//registerMetrics exposes the depth of the queues and heaps of the dealer, which are read on every scrape
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		metrics.RegisterDepth("pbft_queue", func() float64 { return float64(GetInstance().D.MQ.GetN()) })
+		metrics.RegisterDepth("xbft_queue", func() float64 { return float64(GetInstance().D.XBFTMQ.GetN()) })
+		metrics.RegisterDepth("pbft_heap", func() float64 { return float64(GetInstance().D.ReservedPBFTMessage.GetLast() + 1) })
+		metrics.RegisterDepth("xbft_heap", func() float64 { return float64(GetInstance().D.ReservedXBFTMessage.GetLast() + 1) })
+	})
+}
+
+//messageLabels returns the type of consensus and the phase of the message
+func messageLabels(message interface{}) (string, string) {
+	switch m := message.(type) {
+	case *plum.PBFTRequest:
+		return "PBFT", m.GetMessage().GetPhase().String()
+	case *plum.XBFTRequest:
+		return "XBFT", m.GetMessage().GetPhase().String()
+	default:
+		panic(fmt.Sprintf("invalid type of consensus message: %T", message))
+	}
+}
+
+func countReceived(m interface{}) {
+	metrics.MessagesReceived.WithLabelValues(messageLabels(m)).Inc()
+}
+
+//countSent counts the message sent, and the error if it has failed
+func countSent(m interface{}, err error) {
+	consensus, phase := messageLabels(m)
+	metrics.MessagesSent.WithLabelValues(consensus, phase).Inc()
+	if err != nil {
+		metrics.SendErrors.WithLabelValues(consensus, phase).Inc()
+	}
+}
+
+//startCommit marks when a candidate block of the next height is first set on this peer.
+//the later candidates of the height, e.g. after round change, keep the mark
+func (p *peer) startCommit() {
+	if h := p.L.CurrentHeight() + 1; p.commitHeight != h {
+		p.commitHeight = h
+		p.commitStartedAt = time.Now()
+	}
+}
+
+//commitLatency returns how long it has taken to commit the current height since its first candidate block.
+//it is unknown for a height whose candidate block this peer has not seen, e.g. one taken by sync
+func (p *peer) commitLatency() (time.Duration, bool) {
+	if p.commitStartedAt.IsZero() || p.commitHeight != p.L.CurrentHeight() {
+		return 0, false
+	}
+	return time.Since(p.commitStartedAt), true
+}
+
+//observeCommit records the latency of the block just appended by consensus from the proposal of its height
+func (p *peer) observeCommit() {
+	if d, ok := p.commitLatency(); ok {
+		metrics.CommitLatency.WithLabelValues(p.D.ConsensusType).Observe(d.Seconds())
+	}
+}
+
+//exportReputation sets the height and the reputation of the validators, which should be called whenever a block is applied
+func (p *peer) exportReputation() {
+	metrics.Height.Set(float64(p.L.CurrentHeight()))
+	metrics.Reputation.Reset()
+	for id, r := range p.ReputationBook {
+		metrics.Reputation.WithLabelValues(fmt.Sprint(id)).Set(r)
+	}
+}
@@ -0,0 +1,69 @@
+package peer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yoseplee/plum/core/metrics"
+	"github.com/yoseplee/plum/core/plum"
+	"testing"
+	"time"
+)
+
+func TestCountSent(t *testing.T) {
+	m := &plum.XBFTRequest{Message: &plum.XBFTMessage{Phase: plum.XBFTPhase_XBFTPrepare}}
+	sent := metrics.MessagesSent.WithLabelValues("XBFT", "XBFTPrepare")
+	failed := metrics.SendErrors.WithLabelValues("XBFT", "XBFTPrepare")
+	sentBefore, failedBefore := testutil.ToFloat64(sent), testutil.ToFloat64(failed)
+
+	countSent(m, nil)
+	countSent(m, errors.New("unavailable"))
+
+	if got := testutil.ToFloat64(sent) - sentBefore; got != 2 {
+		t.Errorf("invalid count of sent messages. got: %v, want: %v", got, 2)
+	}
+	if got := testutil.ToFloat64(failed) - failedBefore; got != 1 {
+		t.Errorf("invalid count of send errors. got: %v, want: %v", got, 1)
+	}
+}
+
+func TestPeer_exportReputation(t *testing.T) {
+	p := GetInstance()
+	p.exportReputation()
+
+	if got := testutil.ToFloat64(metrics.Height); got != float64(p.L.CurrentHeight()) {
+		t.Errorf("invalid height. got: %v, want: %v", got, p.L.CurrentHeight())
+	}
+	if got := testutil.CollectAndCount(metrics.Reputation); got != len(p.ReputationBook) {
+		t.Errorf("invalid number of reputation. got: %v, want: %v", got, len(p.ReputationBook))
+	}
+	for id, r := range p.ReputationBook {
+		if got := testutil.ToFloat64(metrics.Reputation.WithLabelValues(fmt.Sprint(id))); got != r {
+			t.Errorf("invalid reputation of %d. got: %v, want: %v", id, got, r)
+		}
+	}
+}
+
+func TestPeer_commitLatency(t *testing.T) {
+	p := GetInstance()
+	height, started := p.commitHeight, p.commitStartedAt
+	defer func() { p.commitHeight, p.commitStartedAt = height, started }()
+
+	//the latency of a height runs from its first candidate block, round changes included
+	p.commitHeight = 0
+	p.startCommit()
+	first := p.commitStartedAt
+	time.Sleep(time.Millisecond)
+	p.startCommit()
+	if p.commitHeight != p.L.CurrentHeight()+1 || !p.commitStartedAt.Equal(first) {
+		t.Errorf("later candidate of the height should keep the start. got: %d at %v, want: %d at %v", p.commitHeight, p.commitStartedAt, p.L.CurrentHeight()+1, first)
+	}
+	if _, ok := p.commitLatency(); ok {
+		t.Errorf("latency should be unknown before the height is committed")
+	}
+
+	p.commitHeight = p.L.CurrentHeight()
+	if d, ok := p.commitLatency(); !ok || d < time.Millisecond {
+		t.Errorf("invalid latency of the committed height. got: %v, %v", d, ok)
+	}
+}
@@ -2,16 +2,17 @@ package peer
 
 import (
 	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/metrics"
 	"github.com/yoseplee/plum/core/plum"
-	"log"
+	"github.com/yoseplee/plum/core/util"
 )
 
 func (d *Dealer) startPBFT() {
-	log.Printf("Dealer has started up on peer %d | type: %s\n", GetInstance().ID, d.ConsensusType)
+	GetInstance().log().Infof("dealer has started up")
 	for {
 		select {
 		case <-d.stopSig:
-			log.Printf("Dealer stopped on peer %d\n", GetInstance().ID)
+			GetInstance().log().Infof("dealer stopped")
 			return
 		default:
 			d.schedulePBFT(scheduleMq)
@@ -39,11 +40,31 @@ func (d *Dealer) schedulePBFT(criteria scheduleCriteria) {
 
 func (d *Dealer) handlePBFT(m *plum.PBFTRequest) {
 	p := GetInstance()
-	//log.Printf("[p %d] handle %s | current_round(%d), current_phase(%s), n_mq(%d), n_reserved(%d)\n", p.ID, util.MakeString(m), p.ConsensusRound, p.PBFTPhase.String(), d.MQ.GetN(), d.Reserved.Last)
+	p.log().Debugf("handle %s | n_mq(%d), n_reserved(%d)", util.MakeString(m), d.MQ.GetN(), d.ReservedPBFTMessage.GetLast()+1)
 
-	if !d.roundCheck(m) || !p.VerifyConsensusMessageSignature(m) {
+	if !p.replayable(m) {
+		p.log().Debugf("drop %s made again in replay", util.MakeString(m))
 		return
 	}
+	if !p.VerifyConsensusMessageSignature(m) {
+		return
+	}
+
+	if d.behind(m) {
+		d.catchUp()
+	}
+
+	//a peer out of the validator set only follows the chain
+	if !p.isValidator(p.ID) {
+		return
+	}
+
+	if !d.roundCheck(m) {
+		return
+	}
+	d.detectEquivocation(m)
+	p.tracePhase(m)
+	p.MessageLog.Store(m)
 
 	switch m.Message.GetPhase() {
 	case plum.PBFTPhase_PBFTNewRound:
@@ -57,7 +78,7 @@ func (d *Dealer) handlePBFT(m *plum.PBFTRequest) {
 	case plum.PBFTPhase_PBFTRoundChange:
 		handlePBFTRoundChange(m)
 	default:
-		log.Println("failed to handle consensus message due to the invalid phase")
+		p.log().Warnf("failed to handle consensus message due to the invalid phase %s", m.GetMessage().GetPhase())
 	}
 }
 
@@ -73,6 +94,7 @@ func handlePBFTNewRound(m *plum.PBFTRequest) {
 		consensusMessage := &plum.PBFTMessage{
 			Phase:  plum.PBFTPhase_PBFTPrePrepare,
 			Round:  p.ConsensusRound,
+			Height: p.L.Height,
 			Digest: p.D.CandidateBlockDigest,
 			PeerId: p.ID,
 		}
@@ -95,10 +117,19 @@ func handlePBFTPrePrepare(m *plum.PBFTRequest) {
 		return
 	}
 
-	p.PBFTPhase = plum.PBFTPhase_PBFTPrePrepare
+	p.setPBFTPhase(plum.PBFTPhase_PBFTPrePrepare)
 	p.SetTimer(m.Message.Phase)
 
 	if p.Role == plum.ConsensusRole_Primary {
+		//a replay takes the candidate block from the recorded pre-prepare of this peer, as the one made again at new round differs from it
+		if p.replaying() && m.GetMessage().GetPeerId() == p.ID {
+			p.D.setCandidateBlock(m.GetBlock())
+		}
+		return
+	}
+
+	//verify the received block: the merkle tree is not shipped, so the root is recomputed from the transactions
+	if !block.Verify(m.GetBlock()) {
 		return
 	}
 
@@ -114,6 +145,7 @@ func handlePBFTPrePrepare(m *plum.PBFTRequest) {
 	consensusMessage := &plum.PBFTMessage{
 		Phase:  plum.PBFTPhase_PBFTPrepare,
 		Round:  p.ConsensusRound,
+		Height: p.L.Height,
 		Digest: p.D.CandidateBlockDigest,
 		PeerId: p.ID,
 	}
@@ -141,14 +173,17 @@ func handlePBFTPrepare(m *plum.PBFTRequest) {
 		p.SetTimer(m.Message.Phase)
 	}
 
-	vote(plum.PBFTPhase_PBFTPrepare)
+	if !vote(m) {
+		return
+	}
 
 	if p.PBFTVote[plum.PBFTPhase_PBFTPrepare] > p.PBFTThreshold[plum.PBFTPhase_PBFTPrepare] {
-		p.PBFTPhase = plum.PBFTPhase_PBFTPrepare
+		p.setPBFTPhase(plum.PBFTPhase_PBFTPrepare)
 
 		consensusMessage := &plum.PBFTMessage{
 			Phase:  plum.PBFTPhase_PBFTCommit,
 			Round:  p.ConsensusRound,
+			Height: p.L.Height,
 			Digest: p.D.CandidateBlockDigest,
 			PeerId: p.ID,
 		}
@@ -177,23 +212,39 @@ func handlePBFTCommit(m *plum.PBFTRequest) {
 		return
 	}
 
-	vote(plum.PBFTPhase_PBFTCommit)
+	if !vote(m) {
+		return
+	}
+	p.D.PBFTCommitMessages = append(p.D.PBFTCommitMessages, m)
 
 	if p.PBFTVote[plum.PBFTPhase_PBFTCommit] > p.PBFTThreshold[plum.PBFTPhase_PBFTCommit] {
 
-		//append block to the ledger
-		appendErr := p.L.Append(p.D.CandidateBlock)
+		//append block to the ledger along with the commit messages as its certificate
+		certificate := &plum.CommitCertificate{
+			Height:      p.D.CandidateBlock.GetHeader().GetId(),
+			BlockDigest: p.D.CandidateBlockDigest,
+			PbftCommits: p.D.PBFTCommitMessages,
+		}
+		p.traceAppend()
+		appendErr := p.L.AppendWithCertificate(p.D.CandidateBlock, certificate)
 		if appendErr != nil {
-			log.Printf("could not append: %v\n", appendErr)
+			p.log().Errorf("could not append: %v", appendErr)
 			return
 		}
 
+		//apply validator updates in the block, the new validator set runs the next round
+		p.applyBlock(p.D.CandidateBlock, p.ConsensusRound)
+		p.observeCommit()
+		p.finishRoundTrace("appended")
+
 		//update and reset attributes in peer
 		p.ConsensusRound++
-		p.PBFTVote = make(map[plum.PBFTPhase]int)
-		p.PBFTPhase = plum.PBFTPhase_PBFTNewRound
+		p.takeSnapshot()
+		p.resetPBFTVote()
+		p.setPBFTPhase(plum.PBFTPhase_PBFTNewRound)
 		p.D.CandidateBlock = nil
 		p.D.CandidateBlockDigest = nil
+		p.D.PBFTCommitMessages = nil
 
 		//set timer
 		p.SetTimer(plum.PBFTPhase_PBFTNewRound)
@@ -202,6 +253,7 @@ func handlePBFTCommit(m *plum.PBFTRequest) {
 			consensusMessage := &plum.PBFTMessage{
 				Phase:  plum.PBFTPhase_PBFTNewRound,
 				Round:  p.ConsensusRound,
+				Height: p.L.Height,
 				PeerId: p.ID,
 			}
 			signature := p.CreateSignature(consensusMessage)
@@ -216,11 +268,14 @@ func handlePBFTCommit(m *plum.PBFTRequest) {
 func handlePBFTRoundChange(m *plum.PBFTRequest) {
 	p := GetInstance()
 	//vote & count
-	vote(plum.PBFTPhase_PBFTRoundChange)
+	if !vote(m) {
+		return
+	}
 	//if 2f+1 && this peer is the new peer of the next round -> send pre-prepare message to all
 	if p.PBFTVote[plum.PBFTPhase_PBFTRoundChange] > p.PBFTThreshold[plum.PBFTPhase_PBFTRoundChange] {
-		p.PBFTVote = make(map[plum.PBFTPhase]int)
-		p.PBFTPhase = plum.PBFTPhase_PBFTNewRound
+		p.resetPBFTVote()
+		p.D.PBFTCommitMessages = nil
+		p.setPBFTPhase(plum.PBFTPhase_PBFTNewRound)
 		p.SetTimer(plum.PBFTPhase_PBFTNewRound)
 
 		//if this peer is the new primary for the next round, start the next round
@@ -228,6 +283,7 @@ func handlePBFTRoundChange(m *plum.PBFTRequest) {
 			consensusMessage := &plum.PBFTMessage{
 				Phase:  plum.PBFTPhase_PBFTNewRound,
 				Round:  p.ConsensusRound,
+				Height: p.L.Height,
 				PeerId: p.ID,
 			}
 			signature := p.CreateSignature(consensusMessage)
@@ -243,23 +299,13 @@ func handlePBFTRoundChange(m *plum.PBFTRequest) {
 //it resets state of the peer, increase round then multicasts round change message
 func (d *Dealer) triggerPBFTRoundChange() {
 	p := GetInstance()
-	//discard request messages at current round in the heap
-	d.discardAllTheRemainedMessagesAtTheRound()
-
-	//peer update to set for the next round
-	p.PBFTPhase = plum.PBFTPhase_PBFTNewRound
-	p.ConsensusRound++
-	p.Primary = p.NewPrimary(p.ConsensusRound)
-	if p.ID == p.Primary {
-		p.Role = plum.ConsensusRole_Primary
-	} else {
-		p.Role = plum.ConsensusRole_Backup
-	}
+	d.enterPBFTRoundChange()
 
 	//send all: ROUND CHANGE
 	consensusMessage := &plum.PBFTMessage{
 		Phase:  plum.PBFTPhase_PBFTRoundChange,
 		Round:  p.ConsensusRound,
+		Height: p.L.Height,
 		PeerId: p.ID,
 	}
 	signature := p.CreateSignature(consensusMessage)
@@ -268,3 +314,24 @@ func (d *Dealer) triggerPBFTRoundChange() {
 		Signature: signature,
 	})
 }
+
+//enterPBFTRoundChange moves this peer to the next round with its primary
+func (d *Dealer) enterPBFTRoundChange() {
+	p := GetInstance()
+	//discard request messages at current round in the heap
+	d.discardAllTheRemainedMessagesAtTheRound()
+
+	//peer update to set for the next round
+	p.finishRoundTrace("round change")
+	p.setPBFTPhase(plum.PBFTPhase_PBFTNewRound)
+	d.PBFTCommitMessages = nil
+	p.ConsensusRound++
+	metrics.RoundChanges.WithLabelValues("PBFT").Inc()
+	p.emit(plum.EventType_RoundChangeTriggered, nil)
+	p.setPrimary(p.NewPrimary(p.ConsensusRound))
+	if p.ID == p.Primary {
+		p.Role = plum.ConsensusRole_Primary
+	} else {
+		p.Role = plum.ConsensusRole_Backup
+	}
+}
@@ -8,14 +8,16 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/yoseplee/plum/core/ledger"
 	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/metrics"
 	"github.com/yoseplee/plum/core/peer/heap"
 	"github.com/yoseplee/plum/core/peer/messageLog"
 	"github.com/yoseplee/plum/core/peer/mq"
 	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/reputation"
+	"github.com/yoseplee/plum/core/trace"
 	"github.com/yoseplee/plum/core/util"
 	"github.com/yoseplee/plum/core/util/path"
 	"google.golang.org/grpc"
-	"log"
 	"math"
 	"math/rand"
 	"sync"
@@ -49,8 +51,31 @@ type peer struct {
 	XBFTMQ                 *mq.XQueue
 	ReservedPBFTMessage    *heap.MinPBFTHeap
 	ReservedXBFTMessage    *heap.MinXBFTHeap
-	XBFTMessageLog         messageLog.LogManager
+	MessageLog             messageLog.LogManager
 	L                      *ledger.Ledger
+	genesis                *plum.GenesisConfig
+	chainID                string
+	validators             map[uint32]*plum.Validator
+	pendingUpdates         []*plum.SignedValidatorUpdate
+	validatorNonce         uint64
+	pendingEvidence        []*plum.Evidence
+	slashed                map[slot]bool
+	pendingMutex           *sync.Mutex
+	reputationEvents       []*plum.ReputationEvent
+	readyPeers             map[uint32]bool
+	helloNonce             uint64
+	helloNonces            map[uint32]uint64
+	readyQuorum            int
+	readyMutex             *sync.Mutex
+	policy                 reputation.Policy
+	selection              *plum.SelectionParams
+	commitHeight           uint64
+	commitStartedAt        time.Time
+	roundTrace             *roundTrace
+	remoteSpans            map[traceKey]trace.SpanContext
+	traceMutex             *sync.Mutex
+	events                 *eventBus
+	replay                 *replay
 	rwMutex                *sync.RWMutex
 	mutex                  *sync.Mutex
 }
@@ -71,6 +96,23 @@ var (
 	instance *peer
 )
 
+//DefaultMessageLogRetention is how many heights of consensus messages are kept in the message log below the committed height
+const DefaultMessageLogRetention = 100
+
+//openMessageLog opens the message log on the disk next to the ledger, or in memory if it could not be opened
+func (p *peer) openMessageLog(id uint32) messageLog.LogManager {
+	dir := fmt.Sprintf("%speer-%d/messages/", path.GetInstance().LedgerPath, id)
+	l, err := messageLog.NewDiskLog(dir)
+	if err != nil {
+		p.log().Errorf("could not open the message log in %s, messages are kept in memory: %v", dir, err)
+		m := &messageLog.MessageLog{}
+		m.SetRetention(DefaultMessageLogRetention)
+		return m
+	}
+	l.SetRetention(DefaultMessageLogRetention)
+	return l
+}
+
 func (p *peer) Init(id uint32, ipv4 string, port string, profile map[uint32]*Connection, consensusType string) {
 	p.ID = id
 
@@ -79,136 +121,132 @@ func (p *peer) Init(id uint32, ipv4 string, port string, profile map[uint32]*Con
 		p.Malicious = true
 	}
 
-	p.Primary = 0
-
 	p.Role = plum.ConsensusRole_Backup
 	p.PBFTPhase = plum.PBFTPhase_PBFTNewRound
 	p.XBFTPhase = plum.XBFTPhase_XBFTPrePrepare
 	p.AddressBook = profile
 	p.ReputationBook = make(map[uint32]float64)
-	p.initReputation()
 	p.MQ = mq.NewPBFTQueue()
 	p.XBFTMQ = mq.NewXBFTQueue()
-	p.XBFTMessageLog = &messageLog.MessageLog{}
+	p.events = newEventBus()
 	p.D = NewDealer(consensusType)
 	p.K = GetKeeperInstance()
-	p.L = ledger.NewLedger(path.GetInstance().LedgerPath, path.GetInstance().GenesisBlockPath, false)
+	p.L = ledger.NewLedger(fmt.Sprintf("%speer-%d/", path.GetInstance().LedgerPath, id), path.GetInstance().GenesisBlockPath, true)
+	p.MessageLog = p.openMessageLog(id)
+	p.applyGenesis()
+	p.initReputation()
+	p.slashed = make(map[slot]bool)
+	p.restore()
+	p.pendingMutex = &sync.Mutex{}
+	p.readyPeers = make(map[uint32]bool)
+	p.helloNonces = make(map[uint32]uint64)
+	p.readyMutex = &sync.Mutex{}
+	p.remoteSpans = make(map[traceKey]trace.SpanContext)
+	p.traceMutex = &sync.Mutex{}
 
 	p.XBFTThreshold = make(map[uint32]map[plum.XBFTPhase]int)
 	p.Ipv4 = ipv4
 	p.Port = port
-	p.PBFTVote = make(map[plum.PBFTPhase]int)
+	p.resetPBFTVote()
 	p.setPBFTThreshold()
 	p.ConsensusState = plum.ConsensusState_Idle
 	p.rwMutex = &sync.RWMutex{}
 	p.mutex = &sync.Mutex{}
+
+	p.Primary = p.NewPrimary(p.ConsensusRound)
+	//a peer restored at a height does not know the committee of the round, which is formed again by round change
+	if consensusType == "XBFT" && p.L.CurrentHeight() == 0 {
+		p.setBootstrapCommittee()
+	}
+
+	registerMetrics()
+	p.exportReputation()
 }
 
-func (p *peer) run() {
-	log.Println("connect to all peers in the address book after 3 sec")
-	<-time.After(time.Second * 3)
+//Run connects to the peers and runs consensus, then says hello to the peers until they are ready.
+//the first round starts once a quorum of validators is ready
+func (p *peer) Run() {
 	p.connectAll()
-	p.generateAndSetKeyPair()
+	p.setKeyPair()
 
-	setPubKeyDone := make(chan struct{})
-	go func() {
-		p.sendPublicKeyToAll()
-		close(setPubKeyDone)
-	}()
+	go p.D.Run()
+	p.setReady(p.ID)
 
-	go func() {
-		<-setPubKeyDone
-		go p.D.Run()
-		p.triggerConsensus()
-	}()
+	go p.handshake()
+	go p.triggerConsensus()
 }
 
 func (p *peer) InitAndRun(id uint32, ipv4 string, port string, profile map[uint32]*Connection, consensusType string) {
 	p.Init(id, ipv4, port, profile, consensusType)
-	p.run()
+	p.Run()
 }
 
-//triggerConsensus runs only if the very first time of consensus, when peer id is 0 and all the public key is set in this peer
+//triggerConsensus starts the round by the primary of it, once a quorum of validators is ready.
+//the round is the first one, or the one this peer has been restored at.
+//the primary of PBFT is the one of the round, and the one of XBFT is the primary of the bootstrap committee.
+//the other validators arm the timer of the round instead, so that the round changes if the primary does not start it.
+//as the committee of XBFT is not known on resume, every validator waits for the round change then
 func (p *peer) triggerConsensus() {
 
-	log.Println("ready to consensus!")
-	if p.ID != 0 {
-		return
+	p.log().Infof("ready to consensus")
+	primary := p.Primary
+	if p.D.ConsensusType == "XBFT" {
+		primary = p.XBFTPrimary
 	}
 
-	log.Println("trigger consensus after 5 seconds")
-	<-time.After(time.Second * 5)
+	p.waitForQuorum()
 
-	if p.EmptyAddressBook() {
+	if p.ID != primary || (p.D.ConsensusType == "XBFT" && p.L.CurrentHeight() > 0) {
+		p.armRoundTimer()
 		return
 	}
 
-	log.Println("try to trigger consensus for 15 seconds")
+	p.log().Infof("try to trigger consensus for 15 seconds")
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
 
-	log.Println("start to trigger...")
+	p.log().Infof("start to trigger")
 
 	//trigger: send PBFTRequest to the peer itself
 	nextBlock := p.NewCandidateBlock()
 	nextBlockDigest, pErr := proto.Marshal(nextBlock)
 	if pErr != nil {
-		log.Fatalf("could not make candidate block: %v", pErr)
+		p.log().Fatalf("could not make candidate block: %v", pErr)
 	}
 
 	switch p.D.ConsensusType {
 	case "PBFT":
 		consensusMessage := &plum.PBFTMessage{
 			Phase:  plum.PBFTPhase_PBFTNewRound,
-			Round:  0,
+			Round:  p.ConsensusRound,
+			Height: p.L.Height,
 			Digest: nextBlockDigest,
-			PeerId: 0,
+			PeerId: p.ID,
 		}
 
 		sig := p.CreateSignature(consensusMessage)
 
-		_, err := p.AddressBook[0].consensusClient.ServePBFTPhase(ctx, &plum.PBFTRequest{
+		_, err := p.AddressBook[p.ID].consensusClient.ServePBFTPhase(ctx, &plum.PBFTRequest{
 			Message:   consensusMessage,
 			Signature: sig,
 			Block:     nextBlock,
 		}, grpc.WaitForReady(true))
 
 		if err != nil {
-			log.Fatalf("could not trigger the consensus: %v", err)
+			p.log().Fatalf("could not trigger the consensus: %v", err)
 		}
 
-		log.Println("PBFT consensus triggered!")
+		p.log().Infof("PBFT consensus triggered")
 	case "XBFT":
-		cms := []*plum.CommitteeMembers{
-			{
-				PeerId:         0,
-				Round:          0,
-				SelectionValue: 1.3,
-			},
-			{
-				PeerId:         1,
-				Round:          0,
-				SelectionValue: 0.9,
-			},
-			{
-				PeerId:         2,
-				Round:          0,
-				SelectionValue: 0.95,
-			},
-			{
-				PeerId:         3,
-				Round:          0,
-				SelectionValue: 0.94,
-			},
-		}
-		nextBlock.CommitteeMembers = cms
+		nextBlock.CommitteeMembers = p.D.committeeMembers
+		block.SealCommittee(nextBlock)
 
 		consensusMessage := &plum.XBFTMessage{
 			Phase:  plum.XBFTPhase_XBFTPrePrepare,
-			Round:  0,
+			Round:  p.ConsensusRound,
 			Height: p.L.Height,
 			Digest: nextBlockDigest,
-			PeerId: 0,
+			PeerId: p.ID,
 		}
 
 		sig := p.CreateSignature(consensusMessage)
@@ -220,7 +258,7 @@ func (p *peer) triggerConsensus() {
 		}
 		go SendAll(req)
 
-		log.Println("XBFT consensus triggered!")
+		p.log().Infof("XBFT consensus triggered")
 	}
 }
 
@@ -233,40 +271,22 @@ func (p *peer) EmptyAddressBook() bool {
 	return false
 }
 
-func (p *peer) generateAndSetKeyPair() {
-	publicKey, privateKey, err := ed25519.GenerateKey(nil)
-	if err != nil {
-		log.Fatalf("could not generate key pair: %v", err)
+//setKeyPair uses the key set by SetPrivateKey, or generates a new one if there is none
+func (p *peer) setKeyPair() {
+	if p.PrivateKey == nil {
+		if p.pinnedPublicKey(p.ID) != nil {
+			p.log().Fatalf("the chain has a public key of peer %d, its private key should be given", p.ID)
+		}
+		publicKey, privateKey, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			p.log().Fatalf("could not generate key pair: %v", err)
+		}
+		p.PrivateKey = privateKey
+		p.PublicKey = publicKey
 	}
-	p.PrivateKey = privateKey
-	p.PublicKey = publicKey
 	p.AddressBook[p.ID].PublicKey = p.PublicKey
 }
 
-func (p *peer) sendPublicKeyToAll() {
-	log.Println("broadcast public key to all for 10 seconds")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-	var wg sync.WaitGroup
-	for _, conn := range p.AddressBook {
-		wg.Add(1)
-		go func(conn *Connection) {
-			defer wg.Done()
-			_, err := conn.peerClient.SetPublicKey(ctx, &plum.PublicKey{
-				Id:   p.ID,
-				Ipv4: p.Ipv4,
-				Port: p.Port,
-				Key:  p.PublicKey,
-			}, grpc.WaitForReady(true))
-
-			if err != nil {
-				log.Printf("could not set public key: %v", err)
-			}
-		}(conn)
-	}
-	wg.Wait()
-}
-
 //setPBFTThreshold sets consensus threshold according to size of the address book
 func (p *peer) setPBFTThreshold() {
 	p.PBFTThreshold = make(map[plum.PBFTPhase]int)
@@ -288,7 +308,7 @@ func (p *peer) setXBFTThreshold(peerID uint32, committeeMembers []*plum.Committe
 }
 
 func (p *peer) toleranceBase() int {
-	return int(math.Floor(float64(len(p.AddressBook)-1) / 3))
+	return int(math.Floor(float64(p.validatorCount()-1) / 3))
 }
 
 func (p *peer) xbftToleranceBase(committeeMembers []*plum.CommitteeMembers) float64 {
@@ -302,7 +322,7 @@ func (p peer) String() string {
 		p.mutex.Lock()
 		t, err := util.MakeTarget(p.Ipv4, p.Port)
 		if err != nil {
-			log.Println("could not make target, ", err)
+			p.log().Errorf("could not make target: %v", err)
 		}
 		s += fmt.Sprintf("\n| %s |\n", "================ PEER ================")
 		s += fmt.Sprintf("|%-17s| %-20d |\n", "ID", p.ID)
@@ -321,7 +341,7 @@ func (p peer) String() string {
 		p.mutex.Lock()
 		t, err := util.MakeTarget(p.Ipv4, p.Port)
 		if err != nil {
-			log.Println("could not make target, ", err)
+			p.log().Errorf("could not make target: %v", err)
 		}
 		s += fmt.Sprintf("\n| %s |\n", "================ PEER ================")
 		s += fmt.Sprintf("|%-17s| %-20d |\n", "ID", p.ID)
@@ -349,10 +369,9 @@ func (p peer) PrintPeer() {
 	d := p.D
 	switch d.ConsensusType {
 	case "PBFT":
-		log.Println(fmt.Sprintf("%s\n%s\n%s\n", p.String(), p.MQ.String(), d.ReservedPBFTMessage.String()))
+		p.log().Infof("%s\n%s\n%s", p.String(), p.MQ.String(), d.ReservedPBFTMessage.String())
 	case "XBFT":
-		log.Println(fmt.Sprintf("%s\n%s\n%s\n%s\n", p.String(), p.XBFTMQ.String(), d.ReservedXBFTMessage.String(), p.ReputationBookString()))
-		util.PrintCommitteeMembers(p.D.committeeMembers)
+		p.log().Infof("%s\n%s\n%s\n%s\n%s", p.String(), p.XBFTMQ.String(), d.ReservedXBFTMessage.String(), p.ReputationBookString(), util.CommitteeMembersString(p.D.committeeMembers))
 	}
 }
 
@@ -383,6 +402,9 @@ func GetInstance() *peer {
 func SendAll(request interface{}) {
 	var wg sync.WaitGroup
 	peerInstance := GetInstance()
+	if peerInstance.replaying() {
+		return
+	}
 
 	switch cr := request.(type) {
 	case *plum.PBFTRequest:
@@ -407,6 +429,9 @@ func SendAll(request interface{}) {
 func SendAllExceptThisPeer(request interface{}) {
 	var wg sync.WaitGroup
 	peerInstance := GetInstance()
+	if peerInstance.replaying() {
+		return
+	}
 
 	switch cr := request.(type) {
 	case *plum.PBFTRequest:
@@ -437,6 +462,9 @@ func SendAllExceptThisPeer(request interface{}) {
 func SendCommitteeMembers(request interface{}) {
 	var wg sync.WaitGroup
 	peerInstance := GetInstance()
+	if peerInstance.replaying() {
+		return
+	}
 
 	switch cr := request.(type) {
 	case *plum.XBFTRequest:
@@ -452,26 +480,29 @@ func SendCommitteeMembers(request interface{}) {
 
 //Send sends a consensus message to one specific peer
 func Send(m *Connection, request interface{}) {
+	if GetInstance().replaying() {
+		return
+	}
 	switch cr := request.(type) {
 	case *plum.PBFTRequest:
 		c := m.consensusClient
 		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*15000)
 		defer cancel()
 
-		_, err := c.ServePBFTPhase(ctx, cr)
-
+		_, err := c.ServePBFTPhase(tracedContext(ctx, cr), cr)
+		countSent(cr, err)
 		if err != nil {
-			log.Printf("could not serve: %v", err)
+			GetInstance().log().Warnf("could not send to peer %d: %v", m.PeerId, err)
 		}
 	case *plum.XBFTRequest:
 		c := m.consensusClient
 		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*15000)
 		defer cancel()
 
-		_, err := c.ServeXBFTPhase(ctx, cr)
-
+		_, err := c.ServeXBFTPhase(tracedContext(ctx, cr), cr)
+		countSent(cr, err)
 		if err != nil {
-			log.Printf("could not serve: %v", err)
+			GetInstance().log().Warnf("could not send to peer %d: %v", m.PeerId, err)
 		}
 	}
 }
@@ -485,13 +516,14 @@ func sendPBFTMessage(m *Connection, cr *plum.PBFTRequest, wg *sync.WaitGroup, ch
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*15000)
 	defer cancel()
 
-	r, err := c.ServePBFTPhase(ctx, cr)
+	r, err := c.ServePBFTPhase(tracedContext(ctx, cr), cr)
+	countSent(cr, err)
 	if err != nil {
 		return
 	}
 	select {
 	case <-timer.C:
-		log.Println("drop this message due to sending this again for 3s")
+		GetInstance().log().Debugf("drop the response of peer %d as it has taken more than 3s", m.PeerId)
 		return
 	default:
 		ch <- r
@@ -507,13 +539,14 @@ func sendXBFTMessage(m *Connection, cr *plum.XBFTRequest, wg *sync.WaitGroup, ch
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*15000)
 	defer cancel()
 
-	r, err := c.ServeXBFTPhase(ctx, cr)
+	r, err := c.ServeXBFTPhase(tracedContext(ctx, cr), cr)
+	countSent(cr, err)
 	if err != nil {
 		return
 	}
 	select {
 	case <-timer.C:
-		log.Println("drop this message due to sending this again for 20s", util.MakeString(cr))
+		GetInstance().log().Debugf("drop the response of peer %d as it has taken more than 20s: %s", m.PeerId, util.MakeString(cr))
 		return
 	default:
 		ch <- r
@@ -522,41 +555,50 @@ func sendXBFTMessage(m *Connection, cr *plum.XBFTRequest, wg *sync.WaitGroup, ch
 
 func (p *peer) connectAll() {
 	for _, addr := range p.AddressBook {
-		var target string
-		var err error
-
-		if addr.Ipv4 == "localhost" {
-			//local test mode
-			target, err = util.MakeTarget(addr.Ipv4, addr.Port)
-		} else if addr.Ipv4 == "" {
-			//local docker test mode
-			target, err = util.MakeTarget(addr.ContainerName, addr.Port)
-		} else {
-			//distributed docker mode
-			target, err = util.MakeTarget(addr.Ipv4, addr.Port)
-			if addr.Ipv4 == p.AddressBook[p.ID].Ipv4 {
-				//if the target is placed in the same host with the peer, use container name because they are in the same docker network
-				target, err = util.MakeTarget(addr.ContainerName, addr.Port)
-			}
+		if err := p.connect(addr); err != nil {
+			p.log().Fatalf("%v", err)
 		}
+	}
+}
 
-		if err != nil {
-			log.Fatalf("could not make target: %v", err)
+//connect dials the peer of the address. the connection is made lazily by grpc
+func (p *peer) connect(addr *Connection) error {
+	var target string
+	var err error
+
+	if addr.Ipv4 == "localhost" {
+		//local test mode
+		target, err = util.MakeTarget(addr.Ipv4, addr.Port)
+	} else if addr.Ipv4 == "" {
+		//local docker test mode
+		target, err = util.MakeTarget(addr.ContainerName, addr.Port)
+	} else {
+		//distributed docker mode
+		target, err = util.MakeTarget(addr.Ipv4, addr.Port)
+		if addr.Ipv4 == p.AddressBook[p.ID].Ipv4 {
+			//if the target is placed in the same host with the peer, use container name because they are in the same docker network
+			target, err = util.MakeTarget(addr.ContainerName, addr.Port)
 		}
+	}
 
-		conn, err := grpc.Dial(target, grpc.WithInsecure())
-		if err != nil {
-			log.Fatalf("failed to connect: %v", err)
-		}
-		addr.clientConn = conn
-		addr.consensusClient = plum.NewConsensusClient(conn)
-		addr.peerClient = plum.NewPeerClient(conn)
+	if err != nil {
+		return fmt.Errorf("could not make target: %v", err)
 	}
+
+	conn, err := grpc.Dial(target, grpc.WithInsecure())
+	if err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+	addr.clientConn = conn
+	addr.consensusClient = plum.NewConsensusClient(conn)
+	addr.peerClient = plum.NewPeerClient(conn)
+	return nil
 }
 
+//NewPrimary picks the primary of the round among the validators in order of their ids
 func (p *peer) NewPrimary(nr uint64) uint32 {
-	np := len(p.AddressBook)
-	return uint32(nr % uint64(np))
+	ids := p.validatorIDs()
+	return ids[nr%uint64(len(ids))]
 }
 
 // RetrieveTxs method should be updated to get transactions from the mempool
@@ -570,58 +612,80 @@ func (p *peer) RetrieveTxs() [][]byte {
 	return txs
 }
 
+//NewCandidateBlock makes a block on top of the ledger with the pending validator updates and evidence ahead of the other transactions
 func (p *peer) NewCandidateBlock() *plum.Block {
-	txs := p.RetrieveTxs()
+	txs := append(append(p.pendingValidatorUpdateTxs(), p.pendingEvidenceTxs()...), p.RetrieveTxs()...)
 	phd := block.Digest(p.L.CurrentBlockHeader())
-	b := block.NewBlock(txs, phd, p.L.Height+1)
+	b := block.NewVersionedBlock(txs, phd, p.L.Height+1, p.L.MerkleTreeVersion())
 	return b
 }
 
 func (p *peer) CreateSignature(m proto.Message) []byte {
-	md, err := proto.Marshal(m)
+	return sign(p.PrivateKey, p.chainID, m)
+}
+
+//sign signs a consensus message in the signing envelope of the chain with the private key
+func sign(privateKey ed25519.PrivateKey, chainID string, m proto.Message) []byte {
+	md, err := util.SigningBytes(chainID, m)
 	if err != nil {
-		log.Printf("could not marshal the message: %v\n", err)
+		GetInstance().log().Errorf("could not make signing bytes: %v", err)
+		return nil
 	}
-	sig := ed25519.Sign(p.PrivateKey, md)
+	sig := ed25519.Sign(privateKey, md)
 
 	return sig
 }
 
+//verifySignature verifies the signature of a consensus message in the signing envelope of the chain
+func verifySignature(publicKey ed25519.PublicKey, chainID string, m proto.Message, sig []byte) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	md, err := util.SigningBytes(chainID, m)
+	if err != nil {
+		GetInstance().log().Errorf("could not make signing bytes: %v", err)
+		return false
+	}
+	return ed25519.Verify(publicKey, md, sig)
+}
+
+//VerifyConsensusMessageSignature verifies the signature of the message, which should be sent by a validator
 func (p *peer) VerifyConsensusMessageSignature(message interface{}) bool {
+	_, sender, _ := messageHeight(message)
+	if !p.isValidator(sender) || p.AddressBook[sender] == nil {
+		p.log().Warnf("message from peer %d which is not a validator", sender)
+		return false
+	}
+	//the messages recorded have been verified as they were handled
+	if p.replaying() && p.replay.recorded[message] {
+		return true
+	}
+
 	switch m := message.(type) {
 	case *plum.PBFTRequest:
-		md, err := proto.Marshal(m.Message)
-		if err != nil {
-			log.Printf("could not marshal the message: %v\n", err)
-		}
-
-		if ed25519.Verify(p.AddressBook[m.Message.GetPeerId()].PublicKey, md, m.GetSignature()) {
+		if verifySignature(p.AddressBook[m.Message.GetPeerId()].PublicKey, p.chainID, m.Message, m.GetSignature()) {
 			return true
 		}
 
-		log.Println("invalid signature for message", util.MakeString(m))
+		p.log().Warnf("invalid signature for message %s", util.MakeString(m))
+		metrics.SignatureFailures.WithLabelValues("PBFT").Inc()
 		return false
 	case *plum.XBFTRequest:
-		md, err := proto.Marshal(m.Message)
-		if err != nil {
-			log.Printf("could not marshal the message: %v\n", err)
-		}
-
-		if ed25519.Verify(p.AddressBook[m.Message.GetPeerId()].PublicKey, md, m.GetSignature()) {
+		if verifySignature(p.AddressBook[m.Message.GetPeerId()].PublicKey, p.chainID, m.Message, m.GetSignature()) {
 			return true
 		}
 
-		//log.Println("invalid signature for message", util.MakeString(m))
-		msg := m.GetMessage()
-		log.Println("tried to verify with the pub key of peer", m.Message.GetPeerId(), " but failed: ", hex.EncodeToString(p.AddressBook[m.Message.GetPeerId()].PublicKey))
-		log.Println("invalid signature for message", msg.GetPeerId(), " | ", msg.Phase)
+		p.log().Warnf("invalid signature for message of peer %d at %s, verified with public key %s", m.Message.GetPeerId(), m.Message.GetPhase(), hex.EncodeToString(p.AddressBook[m.Message.GetPeerId()].PublicKey))
+		metrics.SignatureFailures.WithLabelValues("XBFT").Inc()
 		return false
 	default:
-		log.Panic("invalid type of message on verifying signature")
+		p.log().Panicf("invalid type of message on verifying signature")
 		return false
 	}
 }
 
+//verifyCertificate checks that the certificate has the messages of more signers than the threshold of the primary in the phase,
+//each signed on the candidate block of the primary. a signer is counted once, as makeCert does
 func (p *peer) verifyCertificate(c *plum.Certificate) bool {
 	if c.GetCert() == nil {
 		return false
@@ -629,6 +693,7 @@ func (p *peer) verifyCertificate(c *plum.Certificate) bool {
 
 	// Check phase and primary consistency
 	ph, pr := c.GetCert()[0].GetMessage().GetPhase(), c.GetCert()[0].GetMessage().GetPrimaryId()
+	signers := make(map[uint32]bool)
 	for _, v := range c.GetCert() {
 		if ph != v.GetMessage().GetPhase() || pr != v.GetMessage().GetPrimaryId() {
 			return false
@@ -636,26 +701,27 @@ func (p *peer) verifyCertificate(c *plum.Certificate) bool {
 
 		// Check signature
 		if !p.VerifyConsensusMessageSignature(v) {
-			log.Println("invalid signature detected during the verification of certificate. occurred on peer", v.GetMessage().GetPeerId())
+			p.log().Warnf("invalid signature detected during the verification of certificate. occurred on peer %d", v.GetMessage().GetPeerId())
 			return false
 		}
 
 		// Check block digest from message and candidate block's
 		if !block.CompareBlockDigest(v.GetMessage().GetDigest(), p.D.CandidateBlockDigests[pr]) {
-			log.Println("different block digest detected during the verification of certificate. occurred on peer", v.GetMessage().GetPeerId())
+			p.log().Warnf("different block digest detected during the verification of certificate. occurred on peer %d", v.GetMessage().GetPeerId())
 			return false
 		}
+		signers[v.GetMessage().GetPeerId()] = true
 	}
 
 	// Check on threshold and candidate block
 	switch ph {
 	case plum.XBFTPhase_XBFTRoundChange:
-		log.Println("verifying round change certificate is not implemented yet")
+		p.log().Debugf("verifying round change certificate is not implemented yet")
 		return true
 	case plum.XBFTPhase_XBFTPrepare:
 		fallthrough
 	case plum.XBFTPhase_XBFTCommit:
-		if len(c.GetCert()) > p.XBFTThreshold[pr][ph] {
+		if len(signers) > p.XBFTThreshold[pr][ph] {
 			return true
 		}
 		return false
@@ -669,17 +735,23 @@ func (p *peer) SetTimer(ph interface{}) {
 }
 
 func (p *peer) setXBFTPrimary(id uint32) {
+	if p.XBFTPrimary == id {
+		return
+	}
 	p.XBFTPrimary = id
+	p.emit(plum.EventType_PrimaryChanged, &plum.Event{PrimaryId: id})
 }
 
-func (p *peer) nextRoundCandidateBlock(rcc *plum.Certificate) *plum.Block {
+//nextRoundCandidateBlock returns the block of the round change message with the highest priority, and whether it is certified.
+//a new candidate block is made with the committee members of the message if none of the messages has a certificate
+func (p *peer) nextRoundCandidateBlock(rcc *plum.Certificate) (*plum.Block, bool) {
 	// Priority 1: has PC, CC - round change occurred at selection
 	// Priority 2: has PC, but no CC - round change occurred at commit
 	// Priority 3: no PC, CC - round change occurred at prepare or earlier
 	// in the same priority, selection value is the casting voter
 
 	if rcc.GetCert()[0] == nil {
-		log.Panic("empty round change certificate")
+		GetInstance().log().Panicf("empty round change certificate")
 	}
 
 	// Check if is has 51%
@@ -722,12 +794,13 @@ func (p *peer) nextRoundCandidateBlock(rcc *plum.Certificate) *plum.Block {
 		}
 	}
 
-	// Set a new candidate block if all the round change has no certificate for both prepared and committed
+	// Set a new candidate block if all the round change has no certificate for both prepared and committed,
+	// leaving the message in the certificate as it has been signed
 	if highestPriority.GetMessage().PreparedCertificate.GetCert() == nil && highestPriority.GetMessage().CommittedCertificate.GetCert() == nil {
-		roundChangedCommitteeMember := highestPriority.GetBlock().GetCommitteeMembers()
-		highestPriority.Block = p.NewCandidateBlock()
-		highestPriority.Block.CommitteeMembers = roundChangedCommitteeMember
+		cb := p.NewCandidateBlock()
+		cb.CommitteeMembers = highestPriority.GetBlock().GetCommitteeMembers()
+		return cb, false
 	}
 
-	return highestPriority.GetBlock()
+	return highestPriority.GetBlock(), true
 }
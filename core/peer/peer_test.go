@@ -1,15 +1,17 @@
 package peer
 
 import (
+	"bytes"
 	"context"
 	"crypto/ed25519"
 	"fmt"
 	"github.com/golang/protobuf/proto"
-	"github.com/yoseplee/plum/core/ledger"
 	"github.com/yoseplee/plum/core/ledger/block"
 	"github.com/yoseplee/plum/core/peer/heap"
 	"github.com/yoseplee/plum/core/peer/mq"
 	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/util"
+	"github.com/yoseplee/plum/core/util/path"
 	"google.golang.org/grpc"
 	"log"
 	"math/rand"
@@ -35,6 +37,8 @@ func Setup() {
 	//setup for peer
 	p = GetInstance()
 	profile := loadProfile()
+	//start from the genesis, not from the chain left by a previous run
+	os.RemoveAll(fmt.Sprintf("%speer-%d/", path.GetInstance().LedgerPath, 0))
 	p.Init(0, "localhost", ":50051", profile, "PBFT")
 	k = GetKeeperInstance()
 	peerSetup()
@@ -50,12 +54,12 @@ func peerSetup() {
 	log.Println("connect to all peers in the address book after 1 sec")
 	<-time.After(time.Second)
 	p.connectAll()
-	p.generateAndSetKeyPair()
+	p.setKeyPair()
 	setAddressBookForTest()
 }
 
 //for test setup, insert public keys into the address boo
-//mimicking sendPublicKeyToAll()
+//mimicking the handshake
 func setAddressBookForTest() {
 	for i, a := range p.AddressBook {
 		if i == 0 {
@@ -260,33 +264,14 @@ func TestGetInstance(t *testing.T) {
 
 func TestPeer_CreateSignature(t *testing.T) {
 	p := GetInstance()
-	b := block.NewBlock(nil, nil, 0)
-	bd, err := proto.Marshal(b)
-	if err != nil {
-		log.Printf("could not marshal the message: %v\n", err)
-	}
-	sig1 := p.CreateSignature(b)
-	if got := ed25519.Verify(p.PublicKey, bd, sig1); got != true {
-		t.Errorf("invalid verify of signature")
-	}
-
-	b = ledger.LoadGenesisBlock("../")
-	bd, err = proto.Marshal(b)
-	if err != nil {
-		log.Printf("could not marshal the message: %v\n", err)
-	}
-	sig2 := p.CreateSignature(b)
-	if got := ed25519.Verify(p.PublicKey, bd, sig2); got != true {
-		t.Errorf("invalid verify of signature")
-	}
 
-	if got := ed25519.Verify(p.PublicKey, bd, sig1); got != false {
-		t.Errorf("invalid verify of signature")
+	//only consensus messages are signed
+	if sig := p.CreateSignature(block.NewBlock(nil, nil, 0)); sig != nil {
+		t.Errorf("a block should not be signed as a consensus message")
 	}
 
 	var cm *plum.PBFTMessage
 	var sig []byte
-	p = GetInstance()
 
 	cm = &plum.PBFTMessage{
 		Phase:  0,
@@ -295,15 +280,49 @@ func TestPeer_CreateSignature(t *testing.T) {
 		PeerId: 0,
 	}
 	sig = p.CreateSignature(cm)
-	cmd, err := proto.Marshal(cm)
-
+	cmd, err := util.SigningBytes(p.chainID, cm)
 	if err != nil {
-		t.Errorf("could not marshal the message: %v\n", err)
+		t.Errorf("could not make signing bytes: %v\n", err)
 	}
 
 	if got := ed25519.Verify(p.PublicKey, cmd, sig); got != true {
 		t.Errorf("invalid verify of signature on req")
 	}
+
+	//raw message without the envelope is not what is signed
+	raw, _ := proto.Marshal(cm)
+	if got := ed25519.Verify(p.PublicKey, raw, sig); got != false {
+		t.Errorf("signature should be over the signing envelope")
+	}
+}
+
+func TestPeer_SignatureDomainSeparation(t *testing.T) {
+	p := GetInstance()
+	cm := &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTCommit, Round: 3, Height: 2, Digest: []byte("digest"), PeerId: p.ID}
+	sig := p.CreateSignature(cm)
+
+	if !verifySignature(p.PublicKey, p.chainID, cm, sig) {
+		t.Fatalf("signature should be verified on the same chain")
+	}
+
+	//signature from another network
+	if verifySignature(p.PublicKey, "another-chain", cm, sig) {
+		t.Errorf("signature should not be verified on another chain")
+	}
+
+	//same fields in the other type of message
+	xm := &plum.XBFTMessage{Round: 3, Height: 2, Digest: []byte("digest"), PeerId: p.ID}
+	xsig := p.CreateSignature(xm)
+	if verifySignature(p.PublicKey, p.chainID, cm, xsig) {
+		t.Errorf("signature of xbft message should not be verified as pbft message")
+	}
+
+	//signature of another height
+	cm2 := proto.Clone(cm).(*plum.PBFTMessage)
+	cm2.Height = 3
+	if verifySignature(p.PublicKey, p.chainID, cm2, sig) {
+		t.Errorf("signature should not be verified on another height")
+	}
 }
 
 func TestPeer_VerifyRequestSignature(t *testing.T) {
@@ -397,56 +416,57 @@ func TestPeer_toleranceBase(t *testing.T) {
 		t.Errorf("invalid calculation of tolerance base(f). got: %v, want: %v", got, want)
 	}
 
-	var dummyAddressBook map[uint32]*Connection
-	dummyAddressBook = make(map[uint32]*Connection)
+	//tolerance is of the validators
+	var dummyValidators map[uint32]*plum.Validator
+	dummyValidators = make(map[uint32]*plum.Validator)
 	for i := 0; i < 7; i++ {
-		dummyAddressBook[uint32(i)] = &Connection{}
+		dummyValidators[uint32(i)] = &plum.Validator{Id: uint32(i)}
 	}
-	tp = &peer{AddressBook: dummyAddressBook}
+	tp = &peer{validators: dummyValidators}
 
 	want = 2
 	if got := tp.toleranceBase(); got != want {
 		t.Errorf("invalid calculation of tolerance base(f)")
 	}
 
-	dummyAddressBook = make(map[uint32]*Connection)
+	dummyValidators = make(map[uint32]*plum.Validator)
 	for i := 0; i < 10; i++ {
-		dummyAddressBook[uint32(i)] = &Connection{}
+		dummyValidators[uint32(i)] = &plum.Validator{Id: uint32(i)}
 	}
-	tp = &peer{AddressBook: dummyAddressBook}
+	tp = &peer{validators: dummyValidators}
 
 	want = 3
 	if got := tp.toleranceBase(); got != want {
 		t.Errorf("invalid calculation of tolerance base(f)")
 	}
 
-	dummyAddressBook = make(map[uint32]*Connection)
+	dummyValidators = make(map[uint32]*plum.Validator)
 	for i := 0; i < 13; i++ {
-		dummyAddressBook[uint32(i)] = &Connection{}
+		dummyValidators[uint32(i)] = &plum.Validator{Id: uint32(i)}
 	}
-	tp = &peer{AddressBook: dummyAddressBook}
+	tp = &peer{validators: dummyValidators}
 
 	want = 4
 	if got := tp.toleranceBase(); got != want {
 		t.Errorf("invalid calculation of tolerance base(f)")
 	}
 
-	dummyAddressBook = make(map[uint32]*Connection)
+	dummyValidators = make(map[uint32]*plum.Validator)
 	for i := 0; i < 16; i++ {
-		dummyAddressBook[uint32(i)] = &Connection{}
+		dummyValidators[uint32(i)] = &plum.Validator{Id: uint32(i)}
 	}
-	tp = &peer{AddressBook: dummyAddressBook}
+	tp = &peer{validators: dummyValidators}
 
 	want = 5
 	if got := tp.toleranceBase(); got != want {
 		t.Errorf("invalid calculation of tolerance base(f)")
 	}
 
-	dummyAddressBook = make(map[uint32]*Connection)
+	dummyValidators = make(map[uint32]*plum.Validator)
 	for i := 0; i < 19; i++ {
-		dummyAddressBook[uint32(i)] = &Connection{}
+		dummyValidators[uint32(i)] = &plum.Validator{Id: uint32(i)}
 	}
-	tp = &peer{AddressBook: dummyAddressBook}
+	tp = &peer{validators: dummyValidators}
 
 	want = 6
 	if got := tp.toleranceBase(); got != want {
@@ -582,3 +602,114 @@ func TestPeer_makeCert(t *testing.T) {
 		},
 	})
 }
+
+func TestPeer_makeCertOncePerSigner(t *testing.T) {
+	p.D.CandidateBlockCertificates = make(map[uint32]map[plum.XBFTPhase][]*plum.XBFTRequest)
+	commit := func(id uint32) *plum.XBFTRequest {
+		return &plum.XBFTRequest{Message: &plum.XBFTMessage{Phase: plum.XBFTPhase_XBFTCommit, PeerId: id, PrimaryId: 3}}
+	}
+
+	//a peer resending its commit does not make the certificate by itself
+	for i := 0; i < 5; i++ {
+		makeCert(3, plum.XBFTPhase_XBFTCommit, commit(1))
+	}
+	if makeCert(3, plum.XBFTPhase_XBFTCommit, commit(1)) {
+		t.Errorf("a duplicate commit should not be added to the certificate")
+	}
+	if !makeCert(3, plum.XBFTPhase_XBFTCommit, commit(2)) {
+		t.Errorf("a commit of another peer should be added to the certificate")
+	}
+	if got := len(p.D.CandidateBlockCertificates[3][plum.XBFTPhase_XBFTCommit]); got != 2 {
+		t.Errorf("invalid size of certificate. got: %d, want: %d", got, 2)
+	}
+}
+
+func Test_handleXBFTRoundChangeOncePerSigner(t *testing.T) {
+	d := p.D
+	certificate, sum, members, total := d.roundChangeCertificate, d.roundChangeReputationSum, d.roundChangeCommitteeMembers, d.totalReputationAtRound
+	defer func() {
+		d.roundChangeCertificate, d.roundChangeReputationSum, d.roundChangeCommitteeMembers, d.totalReputationAtRound = certificate, sum, members, total
+		p.K.Timer.Stop()
+	}()
+	d.roundChangeCertificate, d.roundChangeReputationSum, d.roundChangeCommitteeMembers = nil, 0, nil
+	//the round does not change, as the reputation collected never gets over the half of the total
+	d.totalReputationAtRound = 1e9
+
+	roundChange := func(round uint64) *plum.XBFTRequest {
+		return &plum.XBFTRequest{Message: &plum.XBFTMessage{Phase: plum.XBFTPhase_XBFTRoundChange, Round: round, PeerId: 1}}
+	}
+	for i := 0; i < 3; i++ {
+		handleXBFTRoundChange(roundChange(p.ConsensusRound))
+	}
+	handleXBFTRoundChange(roundChange(p.ConsensusRound + 1))
+
+	if d.roundChangeReputationSum != p.ReputationBook[1] {
+		t.Errorf("reputation of a signer should be summed once. got: %v, want: %v", d.roundChangeReputationSum, p.ReputationBook[1])
+	}
+	if len(d.roundChangeCertificate) != 1 || d.roundChangeCertificate[0].GetMessage().GetRound() != p.ConsensusRound+1 {
+		t.Errorf("the round change of the later round should take the place of the earlier one: %v", d.roundChangeCertificate)
+	}
+}
+
+func Test_vote(t *testing.T) {
+	vote0, voters := p.PBFTVote, p.D.pbftVoters
+	p.resetPBFTVote()
+	defer func() {
+		p.PBFTVote, p.D.pbftVoters = vote0, voters
+	}()
+
+	prepare := func(id uint32, round uint64, digest string) *plum.PBFTRequest {
+		return &plum.PBFTRequest{Message: &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTPrepare, Round: round, Digest: []byte(digest), PeerId: id}}
+	}
+
+	if !vote(prepare(1, 7, "a")) {
+		t.Errorf("the first vote of a peer should be counted")
+	}
+	//a peer resending its prepare is not counted again, however many times it does
+	for i := 0; i < 3; i++ {
+		if vote(prepare(1, 7, "a")) {
+			t.Errorf("a duplicate vote should not be counted")
+		}
+	}
+	vote(prepare(2, 7, "a"))
+	if got := p.PBFTVote[plum.PBFTPhase_PBFTPrepare]; got != 2 {
+		t.Errorf("invalid number of votes. got: %d, want: %d", got, 2)
+	}
+
+	//votes of another round or digest are counted apart
+	if !vote(prepare(1, 8, "a")) || !vote(prepare(1, 7, "b")) {
+		t.Errorf("a vote of another round or digest should be counted")
+	}
+	if got := len(p.D.pbftVoters[pbftVoteKey{7, plum.PBFTPhase_PBFTPrepare, "a"}]); got != 2 {
+		t.Errorf("invalid number of signers. got: %d, want: %d", got, 2)
+	}
+}
+
+func TestPeer_nextRoundCandidateBlock(t *testing.T) {
+	p := GetInstance()
+	committee := []*plum.CommitteeMembers{{PeerId: 1, SelectionValue: 0.9}, {PeerId: 2, SelectionValue: 0.5}}
+
+	//a block certified in the previous round is proposed again without a change of its digest
+	certified := p.NewCandidateBlock()
+	certified.CommitteeMembers = committee
+	block.SealCommittee(certified)
+	digest := block.Digest(certified.GetHeader())
+	rcc := &plum.Certificate{Cert: []*plum.XBFTRequest{
+		{Message: &plum.XBFTMessage{Phase: plum.XBFTPhase_XBFTRoundChange, PeerId: 1}, Block: &plum.Block{CommitteeMembers: committee}},
+		{Message: &plum.XBFTMessage{Phase: plum.XBFTPhase_XBFTRoundChange, PeerId: 2, PreparedCertificate: &plum.Certificate{Cert: []*plum.XBFTRequest{{}}}}, Block: certified},
+	}}
+	b, ok := p.nextRoundCandidateBlock(rcc)
+	if !ok || b != certified || !bytes.Equal(block.Digest(b.GetHeader()), digest) {
+		t.Errorf("the certified block should be proposed as it is")
+	}
+
+	//a new block is made without changing the messages of the certificate
+	rcc.Cert = rcc.Cert[:1]
+	b, ok = p.nextRoundCandidateBlock(rcc)
+	if ok || b == rcc.Cert[0].GetBlock() || len(b.GetCommitteeMembers()) != len(committee) {
+		t.Errorf("a new block should be made with the committee members of the message")
+	}
+	if rcc.Cert[0].GetBlock().GetHeader() != nil {
+		t.Errorf("the block of the round change message should not be changed")
+	}
+}
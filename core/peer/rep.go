@@ -1,20 +1,27 @@
 package peer
 
 import (
+	"fmt"
 	"github.com/yoseplee/plum/core/plum"
-	"log"
+	"math"
 	"sort"
 )
 
+//reasons of changes of reputation, written in the reputation records
 const (
-	repIncreaseUnit = 0.01
-	repDecreaseUnit = 0.09
+	reasonCommittedBlock = "committed block"
+	reasonRoundChanged   = "round-changed committee member"
+	reasonDecay          = "decay"
+	reasonExpired        = "expired out of the window"
+	reasonJoined         = "joined validators"
+	reasonLeft           = "left validators"
+	reasonReplaced       = "replaced validator"
 )
 
-//initReputation() loads reputation of peers. This can be changed to retrieve reputation record from data sources
+//initReputation() loads reputation of validators, which is the one given by the genesis or the default
 func (p *peer) initReputation() {
-	for _, a := range p.AddressBook {
-		p.ReputationBook[a.PeerId] = 1.0
+	for id, v := range p.validators {
+		p.ReputationBook[id] = initialReputation(v)
 	}
 }
 
@@ -46,21 +53,207 @@ func (p *peer) RepMedianRatio() float64 {
 	return p.RepMedian() / p.RepSum()
 }
 
-//RepIncrease() linearly increases reputation of committee member
+//RepIncrease() rewards committee members by the reputation policy
 //when they successfully appended their candidate block
 func (p *peer) RepIncrease(cms []*plum.CommitteeMembers) {
 	p.mutex.Lock()
 	for _, cm := range cms {
-		p.ReputationBook[cm.PeerId] = repIncreaseUnit + p.ReputationBook[cm.PeerId]
+		p.setReputation(cm.PeerId, p.policy.Reward(p.ReputationBook[cm.PeerId]), plum.ReputationEventType_ReputationIncrease, reasonCommittedBlock)
 	}
 	p.mutex.Unlock()
 }
 
+//RepDecrease() penalizes committee members by the reputation policy
+//when they failed to append their candidate block in a round
 func (p *peer) RepDecrease(cms []*plum.CommitteeMembers) {
 	p.mutex.Lock()
 	for _, cm := range cms {
-		log.Printf("decrease reputation of %d: %v -> %v\n", cm.PeerId, p.ReputationBook[cm.PeerId], p.ReputationBook[cm.PeerId]*repDecreaseUnit)
-		p.ReputationBook[cm.PeerId] = repDecreaseUnit * p.ReputationBook[cm.PeerId]
+		reputation := p.policy.Penalize(p.ReputationBook[cm.PeerId])
+		p.log().Infof("decrease reputation of %d: %v -> %v", cm.PeerId, p.ReputationBook[cm.PeerId], reputation)
+		p.setReputation(cm.PeerId, reputation, plum.ReputationEventType_ReputationDecrease, reasonRoundChanged)
 	}
 	p.mutex.Unlock()
 }
+
+//decayReputation lets reputation of every validator decay by the reputation policy as a block is appended
+func (p *peer) decayReputation() {
+	p.mutex.Lock()
+	for _, id := range p.validatorIDs() {
+		r, ok := p.ReputationBook[id]
+		if !ok {
+			continue
+		}
+		if decayed := p.policy.Decay(r); decayed != r {
+			p.setReputation(id, decayed, plum.ReputationEventType_ReputationDecay, reasonDecay)
+		}
+	}
+	p.mutex.Unlock()
+}
+
+//expireReputation reverts the rewards and penalties made by the block which has just got out of the window of the reputation policy.
+//they are read from the reputation record of the block, so that every peer reverts the same ones even after restart.
+//reputation is kept non-negative, as reverting a reward after a penalty may go below zero
+func (p *peer) expireReputation(height uint64) {
+	w := p.policy.Window()
+	if w == 0 || height <= w {
+		return
+	}
+
+	r, err := p.L.GetReputationRecord(height - w)
+	if err != nil {
+		p.log().Errorf("could not expire reputation of height %d: %v", height-w, err)
+		return
+	}
+
+	p.mutex.Lock()
+	for _, e := range r.GetEvents() {
+		if e.GetType() != plum.ReputationEventType_ReputationIncrease && e.GetType() != plum.ReputationEventType_ReputationDecrease {
+			continue
+		}
+		current, ok := p.ReputationBook[e.GetPeerId()]
+		if !ok {
+			continue
+		}
+		p.setReputation(e.GetPeerId(), math.Max(0, current-(e.GetAfter()-e.GetBefore())), plum.ReputationEventType_ReputationExpire, reasonExpired)
+	}
+	p.mutex.Unlock()
+}
+
+//updateReputationByBlock updates reputation as the block is appended.
+//committee members which has failed to append their block in earlier rounds are decreased, then the committee members of the block are increased.
+//then reputation decays and the changes out of the window expire, if the policy does so
+func (p *peer) updateReputationByBlock(b *plum.Block) {
+	p.RepDecrease(b.GetRoundChangedCommitteeMembers())
+	p.RepIncrease(b.GetCommitteeMembers())
+	p.decayReputation()
+	p.expireReputation(b.GetHeader().GetId())
+}
+
+//setReputation changes reputation of the peer and keeps the change to be recorded along with the block being applied
+func (p *peer) setReputation(id uint32, reputation float64, t plum.ReputationEventType, reason string) {
+	p.reputationEvents = append(p.reputationEvents, &plum.ReputationEvent{
+		PeerId: id,
+		Type:   t,
+		Before: p.ReputationBook[id],
+		After:  reputation,
+		Delta:  reputation - p.ReputationBook[id],
+		Reason: reason,
+	})
+	p.ReputationBook[id] = reputation
+}
+
+//removeReputation drops the peer from the reputation book and keeps the change to be recorded along with the block being applied
+func (p *peer) removeReputation(id uint32, reason string) {
+	p.reputationEvents = append(p.reputationEvents, &plum.ReputationEvent{
+		PeerId: id,
+		Type:   plum.ReputationEventType_ReputationLeave,
+		Before: p.ReputationBook[id],
+		Delta:  -p.ReputationBook[id],
+		Reason: reason,
+	})
+	delete(p.ReputationBook, id)
+}
+
+//applyBlock applies what the appended block decides on the state of this peer, then records the change of reputation made by it.
+//it should be called once for every block right after it is appended, with the round in which the block is committed
+func (p *peer) applyBlock(b *plum.Block, round uint64) {
+	p.reputationEvents = nil
+	if p.D.ConsensusType == "XBFT" {
+		p.updateReputationByBlock(b)
+	}
+	p.applyEvidence(b)
+	updates := p.applyValidatorUpdates(b)
+	p.D.pruneSignedMessages(b.GetHeader().GetId())
+
+	r := &plum.ReputationRecord{
+		Height:           b.GetHeader().GetId(),
+		Events:           p.reputationEvents,
+		ValidatorNonce:   p.validatorNonce,
+		ValidatorUpdates: updates,
+	}
+	for _, e := range r.Events {
+		e.Height = r.Height
+		e.Round = round
+	}
+	p.reputationEvents = nil
+	if err := p.L.SaveReputationRecord(r); err != nil {
+		p.log().Errorf("could not record reputation at height %d: %v", r.GetHeight(), err)
+	}
+	p.exportReputation()
+	p.MessageLog.Prune(r.GetHeight())
+	p.emitBlockAppended(b, round, r.GetEvents())
+}
+
+//genesisReputation returns the reputation book the chain has started with
+func (p *peer) genesisReputation() map[uint32]float64 {
+	book := make(map[uint32]float64)
+	if p.genesis == nil {
+		for id := range p.AddressBook {
+			book[id] = defaultReputation
+		}
+		return book
+	}
+
+	for _, v := range p.genesis.GetValidators() {
+		book[v.GetId()] = initialReputation(v)
+	}
+	return book
+}
+
+//rebuildReputation derives the reputation book at the current height of the ledger
+//by applying the recorded changes on top of the latest snapshot, or the genesis if there is none
+func (p *peer) rebuildReputation() (map[uint32]float64, error) {
+	height := p.L.CurrentHeight()
+
+	book := p.genesisReputation()
+	var base uint64
+	if s, err := p.L.LatestSnapshot(); err == nil && s.GetHeight() <= height {
+		book = make(map[uint32]float64)
+		for id, r := range s.GetReputationBook() {
+			book[id] = r
+		}
+		base = s.GetHeight()
+	}
+
+	for h := base + 1; h <= height; h++ {
+		r, err := p.L.GetReputationRecord(h)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range r.GetEvents() {
+			if e.GetType() == plum.ReputationEventType_ReputationLeave {
+				delete(book, e.GetPeerId())
+				continue
+			}
+			book[e.GetPeerId()] = e.GetAfter()
+		}
+	}
+	return book, nil
+}
+
+//reputationHistory returns the changes of reputation of the peer made by the blocks from and to the heights, read from the reputation records
+func (p *peer) reputationHistory(id uint32, from, to uint64) ([]*plum.ReputationEvent, error) {
+	if to == 0 || to > p.L.CurrentHeight() {
+		to = p.L.CurrentHeight()
+	}
+	if from == 0 {
+		from = 1
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid range of heights: %d to %d", from, to)
+	}
+
+	var events []*plum.ReputationEvent
+	for h := from; h <= to; h++ {
+		r, err := p.L.GetReputationRecord(h)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range r.GetEvents() {
+			if e.GetPeerId() == id {
+				events = append(events, e)
+			}
+		}
+	}
+	return events, nil
+}
@@ -1,7 +1,12 @@
 package peer
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"github.com/yoseplee/plum/core/ledger/block"
 	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/reputation"
+	"math"
 	"testing"
 )
 
@@ -80,7 +85,7 @@ func TestPeer_RepIncrease(t *testing.T) {
 	p.RepIncrease(cms)
 
 	for k, v := range before {
-		before[k] = repIncreaseUnit + v
+		before[k] = p.policy.Reward(v)
 	}
 
 	for k, v := range before {
@@ -108,7 +113,7 @@ func TestPeer_RepDecrease(t *testing.T) {
 	p.RepDecrease(cms)
 
 	for k, v := range before {
-		before[k] = repDecreaseUnit * v
+		before[k] = p.policy.Penalize(v)
 	}
 
 	for k, v := range before {
@@ -118,3 +123,163 @@ func TestPeer_RepDecrease(t *testing.T) {
 		}
 	}
 }
+
+func TestPeer_rebuildReputation(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+	consensusType := p.D.ConsensusType
+	p.D.ConsensusType = "XBFT"
+	defer func() { p.D.ConsensusType = consensusType }()
+
+	book, err := p.rebuildReputation()
+	if err != nil {
+		t.Fatalf("could not rebuild reputation: %v", err)
+	}
+	p.ReputationBook = book
+
+	blocks := chainOnLedger(3)
+	blocks[0].Block.CommitteeMembers = []*plum.CommitteeMembers{{PeerId: 1}, {PeerId: 2}}
+	blocks[1].Block.RoundChangedCommitteeMembers = []*plum.CommitteeMembers{{PeerId: 1}}
+	blocks[1].Block.CommitteeMembers = []*plum.CommitteeMembers{{PeerId: 3}}
+	sealChainForTest(blocks)
+	if err := p.replayBlocks(blocks); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+
+	r, err := p.L.GetReputationRecord(blocks[1].Block.GetHeader().GetId())
+	if err != nil {
+		t.Fatalf("could not get the reputation record: %v", err)
+	}
+	if len(r.GetEvents()) != 2 || r.GetEvents()[0].GetType() != plum.ReputationEventType_ReputationDecrease {
+		t.Errorf("invalid reputation record: %v", r)
+	}
+
+	got, err := p.rebuildReputation()
+	if err != nil {
+		t.Fatalf("could not rebuild reputation: %v", err)
+	}
+	if len(got) != len(p.ReputationBook) {
+		t.Fatalf("invalid rebuilt reputation book. got: %v, want: %v", got, p.ReputationBook)
+	}
+	for id, want := range p.ReputationBook {
+		if got[id] != want {
+			t.Errorf("invalid rebuilt reputation of %d. got: %v, want: %v", id, got[id], want)
+		}
+	}
+}
+
+func TestPeer_restoreSlashed(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+	slashed := p.slashed
+	defer func() { p.slashed = slashed }()
+
+	blocks := chainOnLedger(1)
+	keys := setSigningKeysForTest()
+	signed := func(digest string) *plum.PBFTRequest {
+		m := &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTCommit, Round: 2000, Height: 2000, Digest: []byte(digest), PeerId: 3}
+		return &plum.PBFTRequest{Message: m, Signature: sign(keys[3], p.chainID, m)}
+	}
+	e := newEvidence(signed("a"), signed("b"))
+	tx, err := encodeEvidenceTx(e)
+	if err != nil {
+		t.Fatalf("could not encode evidence: %v", err)
+	}
+	h := blocks[0].Block.GetHeader()
+	b := block.NewVersionedBlock([][]byte{tx}, h.GetPrevBlockHash(), h.GetId(), p.L.MerkleTreeVersion())
+	blocks[0] = &plum.SyncBlock{Block: b, Certificate: pbftCommitCertificateForTest(b, keys, len(keys))}
+	if err := p.replayBlocks(blocks); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+
+	//the slot is recorded along with the slash, so that it is restored without the body of the block
+	s, _ := evidenceSlot(e)
+	r, err := p.L.GetReputationRecord(h.GetId())
+	if err != nil {
+		t.Fatalf("could not get the reputation record: %v", err)
+	}
+	if len(r.GetEvents()) == 0 || r.GetEvents()[0].GetType() != plum.ReputationEventType_ReputationSlash || slotOfSlashed(r.GetEvents()[0].GetSlot()) != s {
+		t.Fatalf("slot of the slash should be recorded: %v", r)
+	}
+
+	p.slashed = make(map[slot]bool)
+	p.restoreSlashed()
+	if !p.slashed[s] {
+		t.Errorf("slot penalized should be restored from the reputation records")
+	}
+}
+
+func TestPeer_restoreValidators(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+
+	blocks := chainOnLedger(1)
+	keys := setSigningKeysForTest()
+	real := p.AddressBook[2].PublicKey
+
+	//a join of an existing validator without the quorum is skipped, and is not taken on restart either
+	fake, _, _ := ed25519.GenerateKey(nil)
+	bogus := signedValidatorUpdateForTest(joinForTest(2, fake), p.validatorNonce, map[uint32]ed25519.PrivateKey{2: keys[2]})
+	pub, _, _ := ed25519.GenerateKey(nil)
+	join := signedValidatorUpdateForTest(joinForTest(100, pub), p.validatorNonce, keys)
+	var txs [][]byte
+	for _, su := range []*plum.SignedValidatorUpdate{bogus, join} {
+		tx, _ := encodeValidatorUpdateTx(su)
+		txs = append(txs, tx)
+	}
+	h := blocks[0].Block.GetHeader()
+	b := block.NewVersionedBlock(txs, h.GetPrevBlockHash(), h.GetId(), p.L.MerkleTreeVersion())
+	blocks[0] = &plum.SyncBlock{Block: b, Certificate: pbftCommitCertificateForTest(b, keys, len(keys))}
+	if err := p.replayBlocks(blocks); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+
+	r, err := p.L.GetReputationRecord(h.GetId())
+	if err != nil {
+		t.Fatalf("could not get the reputation record: %v", err)
+	}
+	if len(r.GetValidatorUpdates()) != 1 || r.GetValidatorUpdates()[0].GetValidator().GetId() != 100 {
+		t.Fatalf("only the update applied should be recorded: %v", r.GetValidatorUpdates())
+	}
+
+	p.restoreValidators()
+	if !bytes.Equal(p.AddressBook[2].PublicKey, real) {
+		t.Errorf("public key of a validator should not be restored from an update skipped")
+	}
+	if v, ok := p.validators[100]; !ok || !bytes.Equal(v.GetPublicKey(), pub) {
+		t.Errorf("validator joined should be restored with its public key")
+	}
+}
+
+func TestPeer_expireReputation(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+	consensusType, policy := p.D.ConsensusType, p.policy
+	p.D.ConsensusType = "XBFT"
+	defer func() { p.D.ConsensusType, p.policy = consensusType, policy }()
+
+	//blocks without change of reputation keep the window clear of the ones appended by other tests
+	if err := p.replayBlocks(chainOnLedger(2)); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+	p.policy, _ = reputation.New(&plum.ReputationPolicyParams{Name: reputation.Windowed, Window: 2})
+
+	before := p.ReputationBook[1]
+	blocks := chainOnLedger(3)
+	blocks[0].Block.CommitteeMembers = []*plum.CommitteeMembers{{PeerId: 1}}
+	sealChainForTest(blocks)
+	if err := p.replayBlocks(blocks[:2]); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+	if got, want := p.ReputationBook[1], p.policy.Reward(before); got != want {
+		t.Errorf("reward should last within the window. got: %v, want: %v", got, want)
+	}
+
+	//the reward gets out of the window by the third block
+	if err := p.replayBlocks(blocks[2:]); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+	if got := p.ReputationBook[1]; math.Abs(got-before) > 1e-9 {
+		t.Errorf("reward should expire out of the window. got: %v, want: %v", got, before)
+	}
+}
@@ -0,0 +1,203 @@
+package peer
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/yoseplee/plum/core/peer/messageLog"
+	"github.com/yoseplee/plum/core/plum"
+	"io"
+	"sort"
+)
+
+//replay is the state of this peer replaying the messages of a message log
+type replay struct {
+	//recorded holds the messages of the log, the ones of this peer are handled only if they are recorded
+	recorded map[interface{}]bool
+}
+
+//ReplayStop is where a replay stops. Phase is the name of a phase such as XBFTCommit, empty for any phase of the round
+type ReplayStop struct {
+	Round uint64
+	Phase string
+}
+
+//InitReplay initiates this peer to replay the messages recorded by the peer of the id, from the genesis.
+//nothing is sent to the other peers and no timer is set, so that the messages are the only input of consensus.
+//the key is of the recorded peer, which reproduces the selection of it. a key is made if it is nil
+func (p *peer) InitReplay(id uint32, profile map[uint32]*Connection, consensusType string, key ed25519.PrivateKey) error {
+	p.Init(id, "", "", profile, consensusType)
+	p.replay = &replay{recorded: make(map[interface{}]bool)}
+	p.MessageLog.Close()
+	p.MessageLog = &messageLog.MessageLog{}
+
+	if key != nil {
+		return p.SetPrivateKey(key)
+	}
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+	p.PrivateKey = key
+	p.PublicKey = pub
+	if a, ok := p.AddressBook[p.ID]; ok && a.PublicKey == nil {
+		a.PublicKey = pub
+	}
+	return nil
+}
+
+//replaying tells whether this peer replays a message log
+func (p *peer) replaying() bool {
+	return p.replay != nil
+}
+
+//replayable tells whether the message is handled. while replaying, the messages of this peer are taken from the log only,
+//as the ones made again during the replay differ from the recorded ones, e.g. in the timestamp of a block
+func (p *peer) replayable(message interface{}) bool {
+	if !p.replaying() {
+		return true
+	}
+	_, sender, _ := messageHeight(message)
+	return sender != p.ID || p.replay.recorded[message]
+}
+
+//Replay handles the recorded messages in the order they have been handled, then returns the number of the messages handled.
+//it stops once this peer reaches the round and phase of stop, or runs out of the messages if stop is nil.
+//the events of the state transitions are written to w as they happen
+func (p *peer) Replay(lms []*plum.LoggedMessage, stop *ReplayStop, w io.Writer) int {
+	sort.SliceStable(lms, func(i, j int) bool {
+		ti, _ := ptypes.Timestamp(lms[i].GetTimestamp())
+		tj, _ := ptypes.Timestamp(lms[j].GetTimestamp())
+		return ti.Before(tj)
+	})
+	if len(lms) != 0 {
+		if h, _, _ := messageHeight(request(lms[0])); h > 1 {
+			p.log().Warnf("the log starts at height %d, the heights below it are not in the log and the replay may not follow it", h)
+		}
+	}
+
+	id, events := p.events.subscribe(nil)
+	defer p.events.unsubscribe(id)
+
+	handled := 0
+	for _, lm := range lms {
+		if stop != nil && p.reached(stop) {
+			break
+		}
+		m := request(lm)
+		p.replay.recorded[m] = true
+		p.D.replayMessage(m)
+		p.D.handleReserved()
+		handled++
+		writeEvents(events, w)
+	}
+	return handled
+}
+
+//State returns the state of this peer as the Farmer service reports it
+func (p *peer) State() (*plum.PeerState, error) {
+	return getPeerState()
+}
+
+//reached tells whether this peer has reached the round and phase
+func (p *peer) reached(stop *ReplayStop) bool {
+	if p.ConsensusRound != stop.Round {
+		return p.ConsensusRound > stop.Round
+	}
+	return stop.Phase == "" || stop.Phase == p.currentPhase()
+}
+
+//request returns the request of the logged message
+func request(lm *plum.LoggedMessage) interface{} {
+	if m := lm.GetPbft(); m != nil {
+		return m
+	}
+	return lm.GetXbft()
+}
+
+//replayMessage handles the recorded message. a round change of this peer, which has been triggered by its timer,
+//moves this peer to the next round first, then its recorded message is handled in place of the one made by the timer.
+//likewise the recorded pre-prepare of this peer as the primary sets the candidate block in place of the one made at new round
+func (d *Dealer) replayMessage(message interface{}) {
+	p := GetInstance()
+	switch m := message.(type) {
+	case *plum.PBFTRequest:
+		if m.GetMessage().GetPeerId() == p.ID && m.GetMessage().GetPhase() == plum.PBFTPhase_PBFTRoundChange && m.GetMessage().GetRound() > p.ConsensusRound {
+			d.enterPBFTRoundChange()
+		}
+		d.handlePBFT(m)
+	case *plum.XBFTRequest:
+		if m.GetMessage().GetPeerId() == p.ID && m.GetMessage().GetPhase() == plum.XBFTPhase_XBFTRoundChange && m.GetMessage().GetRound() > p.ConsensusRound {
+			d.enterXBFTRoundChange()
+			if p.Selection(m.GetMessage().GetSelectionValue()) {
+				p.TentativeSelectedCount++
+			}
+		}
+		d.handleXBFT(m)
+	}
+}
+
+//handleReserved handles the messages reserved in the heap for later rounds, as the dealer does between the messages in the queue.
+//it goes on while the round or phase moves, as a message reserved may become ready by another
+func (d *Dealer) handleReserved() {
+	p := GetInstance()
+	for {
+		round, phase := p.ConsensusRound, p.currentPhase()
+		switch d.ConsensusType {
+		case "PBFT":
+			for n := d.ReservedPBFTMessage.GetLast() + 1; n > 0; n-- {
+				m, err := d.ReservedPBFTMessage.Pop()
+				if err != nil {
+					break
+				}
+				d.handlePBFT(m)
+			}
+		case "XBFT":
+			for n := d.ReservedXBFTMessage.GetLast() + 1; n > 0; n-- {
+				m, err := d.ReservedXBFTMessage.Pop()
+				if err != nil {
+					break
+				}
+				d.handleXBFT(m)
+			}
+		}
+		if round == p.ConsensusRound && phase == p.currentPhase() {
+			return
+		}
+	}
+}
+
+func writeEvents(events <-chan *plum.Event, w io.Writer) {
+	for {
+		select {
+		case e := <-events:
+			fmt.Fprintln(w, formatEvent(e))
+		default:
+			return
+		}
+	}
+}
+
+//formatEvent returns the event in a line
+func formatEvent(e *plum.Event) string {
+	s := fmt.Sprintf("round=%d height=%d %s", e.GetRound(), e.GetHeight(), e.GetType())
+	switch e.GetType() {
+	case plum.EventType_PhaseChanged:
+		s += fmt.Sprintf(" phase=%s", e.GetPhase())
+	case plum.EventType_PrimaryChanged:
+		s += fmt.Sprintf(" primary=%d", e.GetPrimaryId())
+	case plum.EventType_BlockAppended:
+		s += fmt.Sprintf(" digest=%x", e.GetBlockDigest())
+	case plum.EventType_CommitteeSelected:
+		var ids []uint32
+		for _, cm := range e.GetCommitteeMembers() {
+			ids = append(ids, cm.GetPeerId())
+		}
+		s += fmt.Sprintf(" committee=%v", ids)
+	case plum.EventType_ReputationUpdated:
+		for _, re := range e.GetReputationEvents() {
+			s += fmt.Sprintf(" %d:%+f", re.GetPeerId(), re.GetDelta())
+		}
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package peer
+
+import (
+	"bytes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"strings"
+	"testing"
+)
+
+func loggedPBFTMessageForTest(sec int64, m *plum.PBFTMessage) *plum.LoggedMessage {
+	return &plum.LoggedMessage{
+		Timestamp: &timestamp.Timestamp{Seconds: sec},
+		Request:   &plum.LoggedMessage_Pbft{Pbft: &plum.PBFTRequest{Message: m}},
+	}
+}
+
+func TestPeer_Replay(t *testing.T) {
+	p := GetInstance()
+	round, phase, vote, voters, primary, role := p.ConsensusRound, p.PBFTPhase, p.PBFTVote, p.D.pbftVoters, p.Primary, p.Role
+	p.replay = &replay{recorded: make(map[interface{}]bool)}
+	p.resetPBFTVote()
+	defer func() {
+		p.replay = nil
+		p.ConsensusRound, p.PBFTPhase, p.PBFTVote, p.D.pbftVoters, p.Primary, p.Role = round, phase, vote, voters, primary, role
+	}()
+
+	//the messages are handled in the order of their timestamps: the round change of peer 1 is reserved for the next round,
+	//then this peer moves to the round by its own round change recorded
+	h := p.L.Height
+	lms := []*plum.LoggedMessage{
+		loggedPBFTMessageForTest(3, &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTNewRound, Round: round + 2, Height: h, PeerId: 2}),
+		loggedPBFTMessageForTest(2, &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTRoundChange, Round: round + 1, Height: h, PeerId: p.ID}),
+		loggedPBFTMessageForTest(1, &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTRoundChange, Round: round + 1, Height: h, PeerId: 1}),
+	}
+
+	var out bytes.Buffer
+	n := p.Replay(lms, &ReplayStop{Round: round + 1}, &out)
+	if n != 2 {
+		t.Errorf("replay should stop as the round is reached. got: %d, want: %d", n, 2)
+	}
+	if p.ConsensusRound != round+1 {
+		t.Errorf("invalid round after replay. got: %d, want: %d", p.ConsensusRound, round+1)
+	}
+	if got := p.PBFTVote[plum.PBFTPhase_PBFTRoundChange]; got != 2 {
+		t.Errorf("the reserved round change should be handled in the round. got: %d, want: %d", got, 2)
+	}
+	if !strings.Contains(out.String(), plum.EventType_RoundChangeTriggered.String()) {
+		t.Errorf("round change should be written to the output. got: %s", out.String())
+	}
+
+	//a message of this peer made again in the replay is not handled
+	if p.replayable(&plum.PBFTRequest{Message: &plum.PBFTMessage{PeerId: p.ID}}) {
+		t.Errorf("a message of this peer not recorded should not be replayed")
+	}
+	if !p.replayable(&plum.PBFTRequest{Message: &plum.PBFTMessage{PeerId: 1}}) {
+		t.Errorf("a message of another peer should be replayed")
+	}
+}
+
+func TestPeer_Replay_Primary(t *testing.T) {
+	p := GetInstance()
+	round, phase, vote, voters, primary, role := p.ConsensusRound, p.PBFTPhase, p.PBFTVote, p.D.pbftVoters, p.Primary, p.Role
+	cb, cbd := p.D.CandidateBlock, p.D.CandidateBlockDigest
+	p.replay = &replay{recorded: make(map[interface{}]bool)}
+	p.resetPBFTVote()
+	p.PBFTPhase = plum.PBFTPhase_PBFTNewRound
+	defer func() {
+		p.replay = nil
+		p.ConsensusRound, p.PBFTPhase, p.PBFTVote, p.D.pbftVoters, p.Primary, p.Role = round, phase, vote, voters, primary, role
+		p.D.CandidateBlock, p.D.CandidateBlockDigest = cb, cbd
+	}()
+
+	//the block proposed by this peer as the primary is recorded in its pre-prepare, the other peers prepare on it
+	b := p.NewCandidateBlock()
+	b.Header.Time = &timestamp.Timestamp{Seconds: 1}
+	digest := block.Digest(b.GetHeader())
+	h := p.L.Height
+	lms := []*plum.LoggedMessage{
+		loggedPBFTMessageForTest(1, &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTNewRound, Round: round, Height: h, PeerId: p.ID}),
+		{
+			Timestamp: &timestamp.Timestamp{Seconds: 2},
+			Request: &plum.LoggedMessage_Pbft{Pbft: &plum.PBFTRequest{
+				Message: &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTPrePrepare, Round: round, Height: h, Digest: digest, PeerId: p.ID},
+				Block:   b,
+			}},
+		},
+	}
+	for id := uint32(1); int(id) <= p.PBFTThreshold[plum.PBFTPhase_PBFTPrepare]+1; id++ {
+		lms = append(lms, loggedPBFTMessageForTest(int64(2+id), &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTPrepare, Round: round, Height: h, Digest: digest, PeerId: id}))
+	}
+
+	p.Replay(lms, nil, &bytes.Buffer{})
+	if !bytes.Equal(p.D.CandidateBlockDigest, digest) {
+		t.Errorf("the candidate block of the primary should be the recorded one. got: %x, want: %x", p.D.CandidateBlockDigest, digest)
+	}
+	if p.PBFTPhase != plum.PBFTPhase_PBFTPrepare {
+		t.Errorf("the recorded prepares should be counted on the recorded block. got phase: %s, want: %s", p.PBFTPhase, plum.PBFTPhase_PBFTPrepare)
+	}
+}
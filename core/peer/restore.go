@@ -0,0 +1,97 @@
+package peer
+
+import (
+	"github.com/yoseplee/plum/core/plum"
+)
+
+//restore resumes this peer from the chain stored by its previous run.
+//the reputation book is rebuilt from the ledger, then the validator set follows it
+func (p *peer) restore() {
+	height, err := p.L.Recover()
+	if err != nil {
+		p.log().Fatalf("could not recover the ledger: %v", err)
+	}
+	if height == 0 {
+		return
+	}
+
+	book, err := p.rebuildReputation()
+	if err != nil {
+		p.log().Fatalf("could not rebuild reputation at height %d: %v", height, err)
+	}
+	p.ReputationBook = book
+	p.restoreValidators()
+	p.restoreValidatorNonce(height)
+	p.restoreSlashed()
+	p.resumeAppState(height)
+	p.log().Infof("restored at height %d: validators %v", height, p.validatorIDs())
+}
+
+//restoreValidators makes the peers in the reputation book the validators, as only validators have reputation.
+//public keys and addresses of the ones joined after the genesis are found in the validator updates applied by the stored blocks,
+//as the reputation records keep them, or the latest snapshot. an update skipped as invalid is never taken
+func (p *peer) restoreValidators() {
+	joined := make(map[uint32]*plum.ValidatorUpdate)
+	for h := uint64(1); h <= p.L.CurrentHeight(); h++ {
+		r, err := p.L.GetReputationRecord(h)
+		if err != nil {
+			p.log().Errorf("could not read reputation record of height %d: %v", h, err)
+			continue
+		}
+		for _, u := range r.GetValidatorUpdates() {
+			if u.GetType() != plum.ValidatorUpdateType_ValidatorLeave {
+				joined[u.GetValidator().GetId()] = u
+			}
+		}
+	}
+
+	snapshot := make(map[uint32]*plum.Validator)
+	if s, err := p.L.LatestSnapshot(); err == nil {
+		for _, v := range s.GetValidators() {
+			snapshot[v.GetId()] = v
+		}
+	}
+
+	validators := make(map[uint32]*plum.Validator)
+	for id := range p.ReputationBook {
+		if u, ok := joined[id]; ok {
+			validators[id] = u.GetValidator()
+			p.restoreAddress(u)
+		} else if v, ok := snapshot[id]; ok {
+			validators[id] = v
+		} else if v, ok := p.validators[id]; ok {
+			validators[id] = v
+		} else {
+			p.log().Warnf("public key of validator %d is not found, it is exchanged on start up", id)
+			validators[id] = &plum.Validator{Id: id}
+		}
+	}
+	p.validators = validators
+}
+
+//restoreValidatorNonce takes the number of the validator updates applied on the chain from the reputation record of the height,
+//so that an update applied before the restart is never applied again
+func (p *peer) restoreValidatorNonce(height uint64) {
+	r, err := p.L.GetReputationRecord(height)
+	if err != nil {
+		p.log().Fatalf("could not read reputation record of height %d: %v", height, err)
+	}
+	p.validatorNonce = r.GetValidatorNonce()
+}
+
+//restoreAddress puts the address of the joined validator into the address book, which is connected on start up
+func (p *peer) restoreAddress(u *plum.ValidatorUpdate) {
+	id := u.GetValidator().GetId()
+	if a, ok := p.AddressBook[id]; ok {
+		a.PublicKey = u.GetValidator().GetPublicKey()
+		return
+	}
+
+	p.AddressBook[id] = &Connection{
+		PeerId:        id,
+		ContainerName: u.GetContainerName(),
+		PublicKey:     u.GetValidator().GetPublicKey(),
+		Ipv4:          u.GetIpv4(),
+		Port:          u.GetPort(),
+	}
+}
@@ -1,18 +1,26 @@
 package peer
 
 import (
-	"log"
+	"github.com/yoseplee/plum/core/ledger/genesis"
+	"github.com/yoseplee/plum/core/plum"
+	"math"
 	"math/big"
 )
 
-const selectionThreshold float64 = 0.2
-const F float64 = 10.0
+//setSelectionParams makes this peer select the committee by the parameters of the chain
+func (p *peer) setSelectionParams(sp *plum.SelectionParams) {
+	if sp == nil {
+		sp = genesis.DefaultSelectionParams()
+	}
+	p.selection = sp
+	p.log().Infof("selection parameters: %v", sp)
+}
 
 //Selection() decides that this node is selected or not based on selection value
-//if the selection value is larger than selection threshold which denoted as selectionThreshold
+//if the selection value is larger than the selection threshold of the chain
 //then it knows that it is selected
-func Selection(selectionValue float64) bool {
-	if selectionValue > selectionThreshold {
+func (p *peer) Selection(selectionValue float64) bool {
+	if selectionValue > p.selection.GetThreshold() {
 		return true
 	}
 	return false
@@ -21,7 +29,7 @@ func Selection(selectionValue float64) bool {
 func (p *peer) selectionValue(vrfHash []byte, peerID uint32) float64 {
 	gamma := p.getHashRatio(vrfHash)
 	rho := p.RepRatio(peerID)
-	return gamma + F*rho
+	return gamma + p.selection.GetReputationWeight()*rho
 }
 
 func (p *peer) getHashRatio(verifiableHash []byte) float64 {
@@ -31,7 +39,7 @@ func (p *peer) getHashRatio(verifiableHash []byte) float64 {
 	precision := uint(8 * (len(verifiableHash) + 1))
 	max, b, err := big.ParseFloat("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 0, precision, big.ToNearestEven)
 	if b != 16 || err != nil {
-		log.Fatal("failed to parse big float constant for selection")
+		p.log().Fatalf("failed to parse big float constant for selection")
 	}
 
 	h := big.Float{}
@@ -45,18 +53,27 @@ func (p *peer) getHashRatio(verifiableHash []byte) float64 {
 }
 
 func (p *peer) expectedCommitteeSize() float64 {
-	n := len(p.AddressBook)
-	return float64(n) * (1 - (selectionThreshold - F*p.RepMedianRatio()))
+	n := p.validatorCount()
+	return float64(n) * (1 - (p.selection.GetThreshold() - p.selection.GetReputationWeight()*p.RepMedianRatio()))
 }
 
+//minimumCommitteeSize returns the least number of committee members to commit a block by the committee size rule of the chain.
+//the expected rule takes the size to tolerate faulty members of the expected committee,
+//which is not less than the fixed minimum and not more than the number of validators
 func (p *peer) minimumCommitteeSize() int {
-	//var minimumCommitteeSize int
-	//minimumCommitteeSize = 3*int(math.Floor(calcFaultyNodeSize(p.expectedCommitteeSize()))) + 1
-	//if minimumCommitteeSize < 4 {
-	//	minimumCommitteeSize = 4
-	//}
-	//return minimumCommitteeSize
-	return 4
+	fixed := int(p.selection.GetMinCommitteeSize())
+	if p.selection.GetCommitteeSizeRule() != plum.CommitteeSizeRule_ExpectedCommitteeSize {
+		return fixed
+	}
+
+	minimumCommitteeSize := 3*int(math.Floor(calcFaultyNodeSize(p.expectedCommitteeSize()))) + 1
+	if minimumCommitteeSize < fixed {
+		minimumCommitteeSize = fixed
+	}
+	if n := p.validatorCount(); minimumCommitteeSize > n {
+		minimumCommitteeSize = n
+	}
+	return minimumCommitteeSize
 }
 
 func calcFaultyNodeSize(n interface{}) float64 {
@@ -75,7 +92,7 @@ func calcFaultyNodeSize(n interface{}) float64 {
 	case float64:
 		r = (k - 1) / 3.0
 	default:
-		log.Panic("invalid type for calculating faulty node size")
+		GetInstance().log().Panicf("invalid type for calculating faulty node size")
 	}
 	return r
 }
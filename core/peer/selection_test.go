@@ -1,6 +1,8 @@
 package peer
 
 import (
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/plum"
 	"log"
 	"testing"
 )
@@ -29,6 +31,40 @@ func TestPeer_minimumCommitteeSize(t *testing.T) {
 	}
 }
 
+func TestPeer_minimumCommitteeSize_Expected(t *testing.T) {
+	p := &peer{
+		validators:     make(map[uint32]*plum.Validator),
+		ReputationBook: make(map[uint32]float64),
+		selection: &plum.SelectionParams{
+			Threshold:         0.2,
+			CommitteeSizeRule: plum.CommitteeSizeRule_ExpectedCommitteeSize,
+			MinCommitteeSize:  4,
+		},
+	}
+	for id := uint32(0); id < 20; id++ {
+		p.validators[id] = &plum.Validator{Id: id}
+		p.ReputationBook[id] = 1.0
+	}
+
+	//expected size is 20 * 0.8 = 16, which tolerates 5 faulty members
+	if got, want := p.minimumCommitteeSize(), 16; got != want {
+		t.Errorf("invalid minimum size of committee. got: %v, want: %v", got, want)
+	}
+
+	//not less than the fixed minimum
+	p.selection.Threshold = 0.9
+	if got, want := p.minimumCommitteeSize(), 4; got != want {
+		t.Errorf("minimum size of committee should not be less than the fixed one. got: %v, want: %v", got, want)
+	}
+
+	//not more than the number of validators
+	p.selection.Threshold = 0
+	p.selection.ReputationWeight = 10.0
+	if got, want := p.minimumCommitteeSize(), 20; got != want {
+		t.Errorf("minimum size of committee should not be more than the validators. got: %v, want: %v", got, want)
+	}
+}
+
 func TestCalcFaultyNodeSize(t *testing.T) {
 	var want float64
 
@@ -52,3 +88,36 @@ func TestCalcFaultyNodeSize(t *testing.T) {
 		t.Errorf("invalid calculation of faulty node size. got: %v, want: %v", got, want)
 	}
 }
+
+func TestPeer_bootstrapCommittee(t *testing.T) {
+	p := GetInstance()
+	cms := p.bootstrapCommittee()
+
+	if len(cms) < p.minimumCommitteeSize() && len(cms) != p.validatorCount() {
+		t.Errorf("bootstrap committee should be at least the minimum size. got: %d, want: %d", len(cms), p.minimumCommitteeSize())
+	}
+	for i := 1; i < len(cms); i++ {
+		if cms[i-1].GetSelectionValue() < cms[i].GetSelectionValue() {
+			t.Errorf("bootstrap committee should be in descending order of selection value: %v", cms)
+		}
+	}
+	for i := p.minimumCommitteeSize(); i < len(cms); i++ {
+		if !p.Selection(cms[i].GetSelectionValue()) {
+			t.Errorf("members over the minimum size should be selected: %v", cms[i])
+		}
+	}
+
+	//every peer derives the same committee from the same genesis
+	again := p.bootstrapCommittee()
+	if len(again) != len(cms) {
+		t.Fatalf("bootstrap committee should be deterministic. got: %v, want: %v", again, cms)
+	}
+	for i := range cms {
+		if !proto.Equal(again[i], cms[i]) {
+			t.Errorf("bootstrap committee should be deterministic. got: %v, want: %v", again[i], cms[i])
+		}
+	}
+	if primary, _ := findXBFTPrimary(cms); primary != cms[0].GetPeerId() {
+		t.Errorf("primary should be the first member of the bootstrap committee. got: %d, want: %d", primary, cms[0].GetPeerId())
+	}
+}
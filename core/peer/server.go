@@ -5,10 +5,10 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/yoseplee/plum/core/logger"
 	"github.com/yoseplee/plum/core/plum"
 	"github.com/yoseplee/plum/core/util"
 	"google.golang.org/grpc"
-	"log"
 	"net"
 	"time"
 )
@@ -45,14 +45,14 @@ func (s *server) Run() {
 	s.NewListener(port)
 
 	if err := s.gs.Serve(s.lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		p.log().Fatalf("failed to serve: %v", err)
 	}
 }
 
 func (s *server) NewListener(port string) {
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		GetInstance().log().Fatalf("failed to listen: %v", err)
 	}
 	s.lis = lis
 }
@@ -61,13 +61,31 @@ func (s *server) Stop() {
 	s.gs.Stop()
 	err := s.lis.Close()
 	if err != nil {
-		log.Printf("could not close listner: %v", err)
+		GetInstance().log().Warnf("could not close listener: %v", err)
 	}
 }
 
-func (s *server) ServePBFTPhase(_ context.Context, in *plum.PBFTRequest) (*plum.PBFTResponse, error) {
-	//log.Println("GOT: ", util.MakeString(in))
+//GracefulStop stops accepting new rpcs and waits for the ones in flight to finish.
+//streams such as GetPeerStateStream do not finish by themselves, so the server is stopped forcibly after the timeout
+func (s *server) GracefulStop(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.gs.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		GetInstance().log().Warnf("rpcs in flight are not finished in time, stop the server")
+		s.gs.Stop()
+	}
+}
+
+func (s *server) ServePBFTPhase(ctx context.Context, in *plum.PBFTRequest) (*plum.PBFTResponse, error) {
 	p := GetInstance()
+	countReceived(in)
+	p.receiveTrace(ctx, in)
 
 	switch in.Message.GetPhase() {
 	case plum.PBFTPhase_PBFTNewRound:
@@ -94,9 +112,10 @@ func (s *server) ServePBFTPhase(_ context.Context, in *plum.PBFTRequest) (*plum.
 	}, nil
 }
 
-func (s *server) ServeXBFTPhase(_ context.Context, in *plum.XBFTRequest) (*plum.XBFTResponse, error) {
-	//log.Println("GOT: ", util.MakeString(in))
+func (s *server) ServeXBFTPhase(ctx context.Context, in *plum.XBFTRequest) (*plum.XBFTResponse, error) {
 	p := GetInstance()
+	countReceived(in)
+	p.receiveTrace(ctx, in)
 
 	switch in.Message.GetPhase() {
 	case plum.XBFTPhase_XBFTSelect:
@@ -159,25 +178,45 @@ func getPeerState() (*plum.PeerState, error) {
 			BlockHeight:    p.L.Height,
 			QueueLength:    p.MQ.GetN(),
 			HeapLength:     int64(p.D.ReservedPBFTMessage.GetLast()),
+			ValidatorNonce: p.validatorNonce,
 		}
 		return ps, nil
 	case "XBFT":
-		//convert
+		//certificates are written by the dealer while they are read
+		certificates, thresholds := p.D.xbftCertificateState()
+
+		//reputation is changed by the dealer while it is read
+		p.mutex.Lock()
+		book := make(map[uint32]float64)
+		var total float64
+		for id, r := range p.ReputationBook {
+			book[id] = r
+			total += r
+		}
+		p.mutex.Unlock()
+
 		ps := &plum.PeerState{
 			Id:                     p.ID,
 			Ipv4:                   p.Ipv4,
 			Port:                   p.Port,
 			Role:                   p.Role,
 			ConsensusRound:         p.ConsensusRound,
-			CurrentPrimary:         p.Primary,
-			ConsensusPhase:         p.PBFTPhase,
+			CurrentPrimary:         p.XBFTPrimary,
 			ConsensusState:         p.ConsensusState,
 			BlockHeight:            p.L.Height,
-			QueueLength:            p.MQ.GetN(),
-			HeapLength:             int64(p.D.ReservedPBFTMessage.GetLast()),
-			Reputation:             p.ReputationBook[p.ID],
+			QueueLength:            p.XBFTMQ.GetN(),
+			HeapLength:             int64(p.D.ReservedXBFTMessage.GetLast()),
+			Reputation:             book[p.ID],
 			SelectedCount:          p.SelectedCount,
 			TentativeSelectedCount: p.TentativeSelectedCount,
+			XbftPhase:              p.XBFTPhase,
+			XbftPrimary:            p.XBFTPrimary,
+			CommitteeMembers:       p.D.committeeMembers,
+			Certificates:           certificates,
+			Thresholds:             thresholds,
+			ReputationBook:         book,
+			TotalReputation:        total,
+			ValidatorNonce:         p.validatorNonce,
 		}
 		return ps, nil
 	default:
@@ -185,6 +224,62 @@ func getPeerState() (*plum.PeerState, error) {
 	}
 }
 
+//GetReputationHistory returns the changes of reputation of the peer with their reasons, in the range of heights
+func (s *server) GetReputationHistory(_ context.Context, r *plum.ReputationHistoryRequest) (*plum.ReputationHistory, error) {
+	p := GetInstance()
+	events, err := p.reputationHistory(r.GetPeerId(), r.GetFromHeight(), r.GetToHeight())
+	if err != nil {
+		return nil, err
+	}
+	return &plum.ReputationHistory{PeerId: r.GetPeerId(), Events: events}, nil
+}
+
+//SetLogLevel changes the level of logs written by this peer while it runs, then returns the level in effect
+func (s *server) SetLogLevel(_ context.Context, l *plum.LogLevel) (*plum.LogLevel, error) {
+	if l.GetLevel() != "" {
+		lv, err := logger.ParseLevel(l.GetLevel())
+		if err != nil {
+			return nil, err
+		}
+		logger.SetLevel(lv)
+		GetInstance().log().Infof("log level is set to %s", lv)
+	}
+	return &plum.LogLevel{Level: logger.GetLevel().String()}, nil
+}
+
+//SubscribeEvents streams the events of consensus on this peer as they happen until the client goes away.
+//the types of the filter are streamed, or every type if it is empty
+func (s *server) SubscribeEvents(f *plum.EventFilter, stream plum.Farmer_SubscribeEventsServer) error {
+	p := GetInstance()
+	id, events := p.events.subscribe(f.GetTypes())
+	defer p.events.unsubscribe(id)
+	p.log().Infof("subscriber %d has subscribed events %v", id, f.GetTypes())
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			p.log().Infof("subscriber %d has gone", id)
+			return nil
+		case e := <-events:
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//GetMessageLog returns the consensus messages of the round kept in the message log, to find out why the round has stalled
+func (s *server) GetMessageLog(_ context.Context, r *plum.MessageLogRequest) (*plum.MessageLogDump, error) {
+	p := GetInstance()
+	if p.MessageLog == nil {
+		return nil, errors.New("the peer is not initiated yet")
+	}
+	return &plum.MessageLogDump{
+		Round:    r.GetRound(),
+		Messages: p.MessageLog.Find(r.GetRound(), r.GetPhases(), r.GetSenders()),
+	}, nil
+}
+
 func (s *server) GetPeerStateStream(_ *plum.Empty, stream plum.Farmer_GetPeerStateStreamServer) error {
 
 	t := time.NewTimer(time.Second * 500)
@@ -193,7 +288,7 @@ func (s *server) GetPeerStateStream(_ *plum.Empty, stream plum.Farmer_GetPeerSta
 		for {
 			ps, err := getPeerState()
 			if err != nil {
-				log.Println("could not get state of peer:", err)
+				GetInstance().log().Errorf("could not get state of peer: %v", err)
 			}
 			if err := stream.Send(ps); err != nil {
 				errSig <- err
@@ -204,7 +299,7 @@ func (s *server) GetPeerStateStream(_ *plum.Empty, stream plum.Farmer_GetPeerSta
 
 	select {
 	case <-t.C:
-		log.Println("timed out, let the stream be closed")
+		GetInstance().log().Infof("timed out, let the stream be closed")
 	case err := <-errSig:
 		return err
 	}
@@ -214,11 +309,25 @@ func (s *server) GetPeerStateStream(_ *plum.Empty, stream plum.Farmer_GetPeerSta
 func (s *server) SetPublicKey(_ context.Context, pub *plum.PublicKey) (*plum.Empty, error) {
 	//verify public key and related information
 	//then update the public key in the address book
-	log.Printf("pub key get from [ %d ]: %s", pub.Id, hex.EncodeToString(pub.Key))
+	GetInstance().log().Debugf("public key of peer %d: %s", pub.Id, hex.EncodeToString(pub.Key))
+	if err := instance.checkPublicKey(pub.GetId(), pub.GetKey()); err != nil {
+		GetInstance().log().Warnf("%v", err)
+		return nil, err
+	}
 	instance.AddressBook[pub.GetId()].PublicKey = pub.Key
 	return &plum.Empty{}, nil
 }
 
+//Hello takes the introduction of the peer and answers with the one of this peer
+func (s *server) Hello(_ context.Context, h *plum.Hello) (*plum.Hello, error) {
+	p := GetInstance()
+	if err := p.receiveHello(h); err != nil {
+		GetInstance().log().Warnf("could not take hello from peer %d: %v", h.GetId(), err)
+		return nil, err
+	}
+	return p.hello(), nil
+}
+
 func (s *server) GetPublicKey(_ context.Context, _ *plum.Empty) (*plum.PublicKey, error) {
 
 	//if the peer hasn't initiated yet,
@@ -245,17 +354,94 @@ func (s *server) GetPublicKeyAllStream(_ *plum.Empty, stream plum.Peer_GetPublic
 		}
 
 		if err := stream.Send(pk); err != nil {
-			log.Fatalf("could not send addresses: %v", err)
+			GetInstance().log().Fatalf("could not send addresses: %v", err)
 		}
 	}
 	return nil
 }
 
+//GetBlocks streams blocks in the range with their commit certificates for a peer which is catching up.
+//the range is cut at the current height
+func (s *server) GetBlocks(r *plum.BlockRange, stream plum.Peer_GetBlocksServer) error {
+	p := GetInstance()
+	if p.L == nil {
+		return errors.New("the peer hasn't initiated yet")
+	}
+
+	if p.L.IsPruned(r.GetFrom()) {
+		return fmt.Errorf("block of height %d is pruned, blocks are kept from height %d", r.GetFrom(), p.L.PrunedHeight()+1)
+	}
+
+	to := r.GetTo()
+	if h := p.L.CurrentHeight(); to > h {
+		to = h
+	}
+	//nothing is sent for a range beyond the current height or in reverse
+	if r.GetFrom() > to {
+		return nil
+	}
+	if to-r.GetFrom()+1 > syncBatchSize {
+		to = r.GetFrom() + syncBatchSize - 1
+	}
+
+	for h := r.GetFrom(); h <= to; h++ {
+		b, err := p.L.GetBlockById(h)
+		if err != nil {
+			return err
+		}
+		c, err := p.L.GetCertificate(h)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&plum.SyncBlock{Block: b, Certificate: c}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//SubmitValidatorUpdate takes a validator update to be included in a block. a new one is forwarded to the other peers
+func (s *server) SubmitValidatorUpdate(_ context.Context, su *plum.SignedValidatorUpdate) (*plum.Empty, error) {
+	p := GetInstance()
+	if p.L == nil {
+		return nil, errors.New("the peer hasn't initiated yet")
+	}
+
+	if err := p.checkValidatorUpdate(su); err != nil {
+		return nil, err
+	}
+
+	if p.addPendingValidatorUpdate(su) {
+		GetInstance().log().Infof("validator update is pending: %s", su.GetUpdate())
+		p.forwardValidatorUpdate(su)
+	}
+	return &plum.Empty{}, nil
+}
+
+//SubmitEvidence takes an evidence of equivocation to be included in a block. a new one is forwarded to the other peers
+func (s *server) SubmitEvidence(_ context.Context, e *plum.Evidence) (*plum.Empty, error) {
+	p := GetInstance()
+	if p.L == nil {
+		return nil, errors.New("the peer hasn't initiated yet")
+	}
+
+	slot, err := p.verifyEvidence(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.addPendingEvidence(e) {
+		p.log().Infof("evidence of equivocation of peer %d at height %d round %d on %s is pending", slot.sender, slot.height, slot.round, slot.phase)
+		p.forwardEvidence(e)
+	}
+	return &plum.Empty{}, nil
+}
+
 func (s *server) PingPong(ctx context.Context, in *plum.Ping) (*plum.Pong, error) {
-	log.Printf("Ping from - %v", in.GetName())
+	GetInstance().log().Debugf("ping from %v", in.GetName())
 	address, err := util.GetExternalIP()
 	if err != nil {
-		log.Fatalf("failed to get external ip address: %v", err)
+		GetInstance().log().Fatalf("failed to get external ip address: %v", err)
 	}
 
 	message := fmt.Sprintf("Pong - from %s", address)
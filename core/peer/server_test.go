@@ -5,10 +5,14 @@ import (
 	"context"
 	"crypto/ed25519"
 	"encoding/hex"
+	"github.com/golang/protobuf/proto"
+	"github.com/yoseplee/plum/core/logger"
 	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/util"
 	"google.golang.org/grpc"
 	"log"
 	"testing"
+	"time"
 )
 
 func connectClientForServerTest() {
@@ -64,3 +68,254 @@ func TestServer_GetPublicKey(t *testing.T) {
 		t.Errorf("got invalid key. got: %s, want: %s", hex.EncodeToString(r.Key), hex.EncodeToString(instance.PublicKey))
 	}
 }
+
+func TestServer_GetReputationHistory(t *testing.T) {
+	p := GetInstance()
+	defer saveMembershipForTest()()
+	consensusType := p.D.ConsensusType
+	p.D.ConsensusType = "XBFT"
+	defer func() { p.D.ConsensusType = consensusType }()
+
+	blocks := chainOnLedger(3)
+	blocks[0].Block.CommitteeMembers = []*plum.CommitteeMembers{{PeerId: 2}, {PeerId: 3}}
+	blocks[2].Block.RoundChangedCommitteeMembers = []*plum.CommitteeMembers{{PeerId: 2}}
+	sealChainForTest(blocks)
+	if err := p.replayBlocks(blocks); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+
+	fc := plum.NewFarmerClient(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	from := blocks[0].Block.GetHeader().GetId()
+	r, err := fc.GetReputationHistory(ctx, &plum.ReputationHistoryRequest{PeerId: 2, FromHeight: from}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("could not get the reputation history: %v", err)
+	}
+
+	want := []struct {
+		height uint64
+		reason string
+	}{
+		{from, reasonCommittedBlock},
+		{from + 2, reasonRoundChanged},
+	}
+	if len(r.GetEvents()) != len(want) {
+		t.Fatalf("invalid number of events. got: %v, want: %d", r.GetEvents(), len(want))
+	}
+	for i, e := range r.GetEvents() {
+		if e.GetPeerId() != 2 || e.GetHeight() != want[i].height || e.GetReason() != want[i].reason {
+			t.Errorf("invalid event. got: %v, want: %v", e, want[i])
+		}
+		if e.GetDelta() != e.GetAfter()-e.GetBefore() {
+			t.Errorf("invalid delta of event: %v", e)
+		}
+	}
+
+	if _, err := fc.GetReputationHistory(ctx, &plum.ReputationHistoryRequest{PeerId: 2, FromHeight: from + 10, ToHeight: from}); err == nil {
+		t.Errorf("invalid range of heights should be rejected")
+	}
+}
+
+func TestServer_Hello(t *testing.T) {
+	p := GetInstance()
+	keys := setSigningKeysForTest()
+	defer delete(p.readyPeers, 1)
+
+	pc := plum.NewPeerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	nonce := uint64(time.Now().UnixNano())
+	signed := func(h *plum.Hello) *plum.Hello {
+		nonce++
+		h.Nonce = nonce
+		h.Signature = nil
+		h.Signature = sign(keys[1], p.chainID, h)
+		return h
+	}
+	h := signed(&plum.Hello{
+		Id:              1,
+		PublicKey:       p.AddressBook[1].PublicKey,
+		ProtocolVersion: util.ProtocolVersion,
+		ChainId:         p.chainID,
+		Ready:           true,
+	})
+	r, err := pc.Hello(ctx, h, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("could not say hello: %v", err)
+	}
+	if r.GetId() != p.ID || !bytes.Equal(r.GetPublicKey(), p.PublicKey) || r.GetChainId() != p.chainID {
+		t.Errorf("invalid answer of hello: %v", r)
+	}
+	if !p.isReady(1) {
+		t.Errorf("peer which said hello as ready should be ready")
+	}
+	answer := proto.Clone(r).(*plum.Hello)
+	answer.Signature = nil
+	if !verifySignature(p.PublicKey, p.chainID, answer, r.GetSignature()) {
+		t.Errorf("answer of hello should be signed by the peer")
+	}
+
+	//a hello taken once, unsigned or signed by another key does not change the address book
+	if _, err := pc.Hello(ctx, h); err == nil {
+		t.Errorf("hello taken again should be rejected")
+	}
+	unsigned := proto.Clone(h).(*plum.Hello)
+	unsigned.Nonce++
+	unsigned.Signature = nil
+	if _, err := pc.Hello(ctx, unsigned); err == nil {
+		t.Errorf("unsigned hello should be rejected")
+	}
+	forged := proto.Clone(h).(*plum.Hello)
+	forged.PublicKey = p.AddressBook[2].PublicKey
+	if _, err := pc.Hello(ctx, signed(forged)); err == nil {
+		t.Errorf("hello signed by another key should be rejected")
+	}
+	if !bytes.Equal(p.AddressBook[1].PublicKey, h.GetPublicKey()) {
+		t.Errorf("public key of the peer should not be changed by rejected hello")
+	}
+
+	//peers of a different chain or protocol do not run consensus together
+	other := proto.Clone(h).(*plum.Hello)
+	other.ChainId = "other-chain"
+	if _, err := pc.Hello(ctx, signed(other)); err == nil {
+		t.Errorf("hello from a different chain should be rejected")
+	}
+	other = proto.Clone(h).(*plum.Hello)
+	other.ProtocolVersion = util.ProtocolVersion + 1
+	if _, err := pc.Hello(ctx, signed(other)); err == nil {
+		t.Errorf("hello of a different protocol version should be rejected")
+	}
+}
+
+func TestServer_SetLogLevel(t *testing.T) {
+	defer logger.SetLevel(logger.GetLevel())
+
+	fc := plum.NewFarmerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	r, err := fc.SetLogLevel(ctx, &plum.LogLevel{Level: "debug"}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("could not set the log level: %v", err)
+	}
+	if r.GetLevel() != "debug" || logger.GetLevel() != logger.Debug {
+		t.Errorf("invalid log level. got: %s, want: %s", r.GetLevel(), "debug")
+	}
+
+	//empty level only reads the current one
+	if r, err := fc.SetLogLevel(ctx, &plum.LogLevel{}); err != nil || r.GetLevel() != "debug" {
+		t.Errorf("invalid log level. got: %v, err: %v", r, err)
+	}
+	if _, err := fc.SetLogLevel(ctx, &plum.LogLevel{Level: "verbose"}); err == nil {
+		t.Errorf("unknown level should be rejected")
+	}
+}
+
+func TestServer_SubscribeEvents(t *testing.T) {
+	p := GetInstance()
+	fc := plum.NewFarmerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	stream, err := fc.SubscribeEvents(ctx, &plum.EventFilter{Types: []plum.EventType{plum.EventType_ReputationUpdated}}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("could not subscribe events: %v", err)
+	}
+	//events are published once the subscription is made on the server
+	for p.events.count() == 0 {
+		<-time.After(time.Millisecond * 10)
+	}
+
+	p.emit(plum.EventType_BlockAppended, nil)
+	p.emit(plum.EventType_ReputationUpdated, &plum.Event{ReputationEvents: []*plum.ReputationEvent{{PeerId: 2}}})
+
+	e, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("could not receive the event: %v", err)
+	}
+	if e.GetType() != plum.EventType_ReputationUpdated || e.GetPeerId() != p.ID || len(e.GetReputationEvents()) != 1 {
+		t.Errorf("invalid event: %v", e)
+	}
+	if e.GetTimestamp() == nil {
+		t.Errorf("event should be stamped with its time")
+	}
+}
+
+func TestGetPeerState_XBFT(t *testing.T) {
+	consensusType := p.D.ConsensusType
+	p.D.ConsensusType = "XBFT"
+	defer func() { p.D.ConsensusType = consensusType }()
+	xbftPrimary := p.XBFTPrimary
+	p.XBFTPrimary = 3
+	defer func() { p.XBFTPrimary = xbftPrimary }()
+	p.setXBFTThreshold(3, []*plum.CommitteeMembers{{PeerId: 1}, {PeerId: 2}, {PeerId: 3}, {PeerId: 4}})
+	defer delete(p.XBFTThreshold, 3)
+
+	ps, err := getPeerState()
+	if err != nil {
+		t.Fatalf("could not get state of peer: %v", err)
+	}
+	if ps.GetCurrentPrimary() != 3 || ps.GetXbftPrimary() != 3 {
+		t.Errorf("invalid primary. got: %d, want: %d", ps.GetXbftPrimary(), 3)
+	}
+	if ps.GetXbftPhase() != p.XBFTPhase {
+		t.Errorf("invalid phase. got: %s, want: %s", ps.GetXbftPhase(), p.XBFTPhase)
+	}
+	if got, want := ps.GetThresholds()[int32(plum.XBFTPhase_XBFTCommit)], int32(p.XBFTThreshold[3][plum.XBFTPhase_XBFTCommit]); got != want {
+		t.Errorf("invalid threshold of commit. got: %d, want: %d", got, want)
+	}
+
+	var total float64
+	for id, r := range p.ReputationBook {
+		if ps.GetReputationBook()[id] != r {
+			t.Errorf("invalid reputation of %d. got: %v, want: %v", id, ps.GetReputationBook()[id], r)
+		}
+		total += r
+	}
+	if ps.GetTotalReputation() != total {
+		t.Errorf("invalid total reputation. got: %v, want: %v", ps.GetTotalReputation(), total)
+	}
+
+	//the certificates are not read while the dealer handles a message
+	p.D.stateMutex.Lock()
+	read := make(chan struct{})
+	go func() {
+		_, _ = getPeerState()
+		close(read)
+	}()
+	select {
+	case <-read:
+		t.Errorf("state of XBFT should not be read while the dealer writes it")
+	case <-time.After(100 * time.Millisecond):
+	}
+	p.D.stateMutex.Unlock()
+	<-read
+}
+
+func TestServer_GetMessageLog(t *testing.T) {
+	round := uint64(1 << 40)
+	for sender := uint32(0); sender < 3; sender++ {
+		p.MessageLog.Store(&plum.PBFTRequest{
+			Message:   &plum.PBFTMessage{Phase: plum.PBFTPhase_PBFTPrepare, Round: round, PeerId: sender},
+			Signature: []byte{byte(sender)},
+		})
+	}
+
+	fc := plum.NewFarmerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	dump, err := fc.GetMessageLog(ctx, &plum.MessageLogRequest{Round: round, Phases: []string{"PBFTPrepare"}, Senders: []uint32{1, 2}}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("could not get the message log: %v", err)
+	}
+	if len(dump.GetMessages()) != 2 {
+		t.Fatalf("invalid number of messages. got: %d, want: %d", len(dump.GetMessages()), 2)
+	}
+	if got := dump.GetMessages()[0].GetPbft().GetMessage().GetPeerId(); got != 1 {
+		t.Errorf("invalid sender of the message. got: %d, want: %d", got, 1)
+	}
+}
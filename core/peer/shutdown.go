@@ -0,0 +1,65 @@
+package peer
+
+import (
+	"time"
+)
+
+const (
+	//drainTimeout is how long to wait for the dealer to handle the messages in the queues when the peer stops
+	drainTimeout = time.Second * 5
+	//drainCheckInterval is how often the queues are checked while they are drained
+	drainCheckInterval = time.Millisecond * 100
+)
+
+//Stop stops this peer so that it can be started again from the disk by Init with the same id.
+//the messages received so far are handled first, then the dealer and the keeper stop.
+//the state of consensus is kept as a snapshot at the current height, which is resumed on restart
+func (p *peer) Stop() {
+	p.log().Infof("stopping the peer")
+	p.drain()
+	p.D.Stop()
+	p.K.stop()
+
+	if p.L.CurrentHeight() != 0 {
+		p.saveSnapshot()
+	}
+	if err := p.L.Flush(); err != nil {
+		p.log().Errorf("could not flush the ledger: %v", err)
+	}
+	if err := p.MessageLog.Close(); err != nil {
+		p.log().Errorf("could not close the message log: %v", err)
+	}
+	p.disconnectAll()
+	p.log().Infof("peer is stopped")
+}
+
+//drain waits until the dealer handles the messages in the queues, or the timeout passes.
+//the server should be stopped before, so that no new message arrives while draining
+func (p *peer) drain() {
+	timeout := time.After(drainTimeout)
+	t := time.NewTicker(drainCheckInterval)
+	defer t.Stop()
+	for !p.MQ.Empty() || !p.XBFTMQ.Empty() {
+		select {
+		case <-t.C:
+		case <-timeout:
+			p.log().Warnf("messages are left in the queues: %d PBFT, %d XBFT", p.MQ.GetN(), p.XBFTMQ.GetN())
+			return
+		}
+	}
+}
+
+//disconnectAll closes the connections to the peers in the address book
+func (p *peer) disconnectAll() {
+	for _, a := range p.AddressBook {
+		if a.clientConn == nil {
+			continue
+		}
+		if err := a.clientConn.Close(); err != nil {
+			p.log().Warnf("could not close the connection to peer %d: %v", a.PeerId, err)
+		}
+		a.clientConn = nil
+		a.consensusClient = nil
+		a.peerClient = nil
+	}
+}
@@ -0,0 +1,51 @@
+package peer
+
+import (
+	"github.com/yoseplee/plum/core/peer/mq"
+	"testing"
+	"time"
+)
+
+func TestDealer_Stop(t *testing.T) {
+	d := NewDealer("PBFT")
+	d.MQ = mq.NewPBFTQueue()
+	go d.Run()
+
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second * 3):
+		t.Fatalf("the dealer did not stop")
+	}
+}
+
+func TestPeer_resumeAppState(t *testing.T) {
+	p := GetInstance()
+	round, selected := p.ConsensusRound, p.SelectedCount
+	defer func() { p.ConsensusRound, p.SelectedCount = round, selected }()
+
+	if err := p.replayBlocks(chainOnLedger(1)); err != nil {
+		t.Fatalf("could not replay blocks: %v", err)
+	}
+	p.ConsensusRound, p.SelectedCount = 42, 7
+	p.saveSnapshot()
+
+	//the state kept on stop is resumed from the snapshot at the recovered height
+	p.ConsensusRound, p.SelectedCount = 0, 0
+	p.resumeAppState(p.L.CurrentHeight())
+	if p.ConsensusRound != 42 || p.SelectedCount != 7 {
+		t.Errorf("invalid resumed state. got: round %d, selected %d, want: round %d, selected %d", p.ConsensusRound, p.SelectedCount, 42, 7)
+	}
+
+	//a snapshot older than the recovered height is not of the last run
+	p.ConsensusRound = 0
+	p.resumeAppState(p.L.CurrentHeight() + 1)
+	if p.ConsensusRound != 0 {
+		t.Errorf("state should not be resumed from an older snapshot. got: round %d", p.ConsensusRound)
+	}
+}
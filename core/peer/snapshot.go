@@ -0,0 +1,51 @@
+package peer
+
+import (
+	"github.com/yoseplee/plum/core/plum"
+)
+
+//takeSnapshot stores the state of this peer at the current height if the ledger is due to take a snapshot.
+//it should be called right after a block is appended and the reputation book is updated by the block
+func (p *peer) takeSnapshot() {
+	if !p.L.SnapshotDue() {
+		return
+	}
+	p.saveSnapshot()
+}
+
+//saveSnapshot stores the state of this peer at the current height
+func (p *peer) saveSnapshot() {
+	reputationBook := make(map[uint32]float64)
+	for k, v := range p.ReputationBook {
+		reputationBook[k] = v
+	}
+
+	s := &plum.Snapshot{
+		Height: p.L.CurrentHeight(),
+		Header: p.L.CurrentBlockHeader(),
+		AppState: &plum.AppState{
+			ConsensusRound: p.ConsensusRound,
+			SelectedCount:  p.SelectedCount,
+		},
+		ReputationBook: reputationBook,
+		Validators:     p.validatorList(),
+	}
+
+	if err := p.L.SaveSnapshot(s); err != nil {
+		p.log().Errorf("could not take a snapshot at height %d: %v", s.GetHeight(), err)
+		return
+	}
+	p.log().Infof("snapshot is taken at height %d", s.GetHeight())
+}
+
+//resumeAppState takes the progress of consensus from the snapshot at the height, which is saved when the peer has stopped.
+//without it, the round is learned from the messages of other peers
+func (p *peer) resumeAppState(height uint64) {
+	s, err := p.L.LatestSnapshot()
+	if err != nil || s.GetHeight() != height {
+		return
+	}
+	p.ConsensusRound = s.GetAppState().GetConsensusRound()
+	p.SelectedCount = s.GetAppState().GetSelectedCount()
+	p.log().Infof("resume from round %d", p.ConsensusRound)
+}
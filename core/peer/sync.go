@@ -0,0 +1,223 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"io"
+	"time"
+)
+
+const (
+	//syncGap is how many heights this peer should be behind the sender of a message to start state sync.
+	//a peer which has just appended a block is one height ahead of the others for a while, so it is not counted as behind
+	syncGap uint64 = 2
+	//syncBatchSize is the maximum number of blocks requested at once
+	syncBatchSize uint64 = 100
+	TimeoutSync          = 30 * time.Second
+)
+
+//messageHeight returns the height of the ledger of the sender at the time the message was made, with its sender and round
+func messageHeight(message interface{}) (height uint64, sender uint32, round uint64) {
+	switch m := message.(type) {
+	case *plum.PBFTRequest:
+		return m.GetMessage().GetHeight(), m.GetMessage().GetPeerId(), m.GetMessage().GetRound()
+	case *plum.XBFTRequest:
+		return m.GetMessage().GetHeight(), m.GetMessage().GetPeerId(), m.GetMessage().GetRound()
+	default:
+		GetInstance().log().Panicf("invalid message type on checking height")
+		return 0, 0, 0
+	}
+}
+
+//behind decides whether this peer has fallen behind and needs state sync. the message should have been verified to be signed by a validator.
+//a single validator may lie about its height, so this peer is behind only when more than f validators have reported heights ahead
+func (d *Dealer) behind(message interface{}) bool {
+	p := GetInstance()
+	height, sender, _ := messageHeight(message)
+	if sender == p.ID || p.replaying() {
+		return false
+	}
+	if height >= p.L.CurrentHeight()+syncGap {
+		d.aheadMessages[sender] = message
+	}
+	return len(d.ahead()) > p.toleranceBase()
+}
+
+//ahead returns the latest messages of the validators still reporting heights at least syncGap ahead of this peer, dropping the others
+func (d *Dealer) ahead() []interface{} {
+	p := GetInstance()
+	var ms []interface{}
+	for sender, m := range d.aheadMessages {
+		if height, _, _ := messageHeight(m); height < p.L.CurrentHeight()+syncGap || !p.isValidator(sender) {
+			delete(d.aheadMessages, sender)
+			continue
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+//catchUp fetches the blocks this peer has missed from the validators ahead, replays them on the ledger,
+//then rejoins consensus. the height and the round are the lowest reported, which at least one correct validator has reached.
+//it runs on the dealer, so that no other consensus message is handled during the sync
+func (d *Dealer) catchUp() {
+	p := GetInstance()
+	ms := d.ahead()
+	d.aheadMessages = make(map[uint32]interface{})
+	if len(ms) == 0 {
+		return
+	}
+
+	height, _, _ := messageHeight(ms[0])
+	at := ms[0]
+	for _, m := range ms {
+		h, _, r := messageHeight(m)
+		if h < height {
+			height = h
+		}
+		if _, _, round := messageHeight(at); r < round {
+			at = m
+		}
+	}
+	p.log().Infof("peer is behind: height %d against %d reported by %d validators, start state sync", p.L.CurrentHeight(), height, len(ms))
+
+	for p.L.CurrentHeight() < height {
+		if !d.syncBatch(ms, height) {
+			p.log().Errorf("could not sync blocks from %d with any of the validators ahead", p.L.CurrentHeight()+1)
+			return
+		}
+	}
+
+	d.rejoin(at)
+	p.log().Infof("state sync done")
+}
+
+//syncBatch fetches and replays the next batch of blocks up to the height, trying the senders of the messages in turn
+func (d *Dealer) syncBatch(ms []interface{}, height uint64) bool {
+	p := GetInstance()
+	for _, m := range ms {
+		_, sender, _ := messageHeight(m)
+		from := p.L.CurrentHeight() + 1
+		to := from + syncBatchSize - 1
+		if to > height {
+			to = height
+		}
+
+		blocks, err := p.fetchBlocks(sender, from, to)
+		if err != nil {
+			p.log().Warnf("could not fetch blocks %d-%d from peer %d: %v", from, to, sender, err)
+			continue
+		}
+		if err := p.replayBlocks(blocks); err != nil {
+			p.log().Warnf("could not replay blocks %d-%d from peer %d: %v", from, to, sender, err)
+			if p.L.CurrentHeight() < from {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+//fetchBlocks requests blocks in the range from the peer, each with the certificate which has finalized it
+func (p *peer) fetchBlocks(peerID uint32, from, to uint64) ([]*plum.SyncBlock, error) {
+	conn, ok := p.AddressBook[peerID]
+	if !ok || conn.peerClient == nil {
+		return nil, fmt.Errorf("there is no connection to peer %d", peerID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), TimeoutSync)
+	defer cancel()
+
+	stream, err := conn.peerClient.GetBlocks(ctx, &plum.BlockRange{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*plum.SyncBlock
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("peer %d has no block from %d", peerID, from)
+	}
+	return blocks, nil
+}
+
+//replayBlocks verifies the blocks with their commit certificates then appends them to the ledger in order,
+//applying what consensus would have done on each
+func (p *peer) replayBlocks(blocks []*plum.SyncBlock) error {
+	for _, sb := range blocks {
+		b := sb.GetBlock()
+		expected := p.L.CurrentHeight() + 1
+		if b.GetHeader().GetId() != expected {
+			return fmt.Errorf("received block of height %d, expected %d", b.GetHeader().GetId(), expected)
+		}
+
+		if !block.Verify(b) {
+			return fmt.Errorf("invalid block of height %d", b.GetHeader().GetId())
+		}
+
+		if err := p.verifyCommitCertificate(b, sb.GetCertificate()); err != nil {
+			return fmt.Errorf("invalid commit certificate of height %d: %v", b.GetHeader().GetId(), err)
+		}
+
+		if err := p.L.AppendWithCertificate(b, sb.GetCertificate()); err != nil {
+			return err
+		}
+
+		p.applyBlock(b, certificateRound(sb.GetCertificate()))
+		p.takeSnapshot()
+	}
+	return nil
+}
+
+//rejoin resets the consensus state of this peer to the round of the message, discarding everything of the rounds it has missed
+func (d *Dealer) rejoin(message interface{}) {
+	p := GetInstance()
+	_, _, round := messageHeight(message)
+	if round > p.ConsensusRound {
+		p.ConsensusRound = round
+	}
+
+	switch m := message.(type) {
+	case *plum.PBFTRequest:
+		p.resetPBFTVote()
+		p.setPBFTPhase(plum.PBFTPhase_PBFTNewRound)
+		p.setPrimary(p.NewPrimary(p.ConsensusRound))
+		if p.ID == p.Primary {
+			p.Role = plum.ConsensusRole_Primary
+		} else {
+			p.Role = plum.ConsensusRole_Backup
+		}
+		d.CandidateBlock = nil
+		d.CandidateBlockDigest = nil
+		d.PBFTCommitMessages = nil
+		p.SetTimer(plum.PBFTPhase_PBFTNewRound)
+	case *plum.XBFTRequest:
+		// committee of the round is unknown, it will be learned from the pre-prepare of the primary
+		p.ConsensusState = plum.ConsensusState_PrePrepared
+		p.setXBFTPhase(plum.XBFTPhase_XBFTPrePrepare)
+		p.Role = plum.ConsensusRole_Backup
+		d.resetXBFTRoundState()
+		d.committeeMembers = nil
+		d.roundChangeCertificate = nil
+		if m.GetMessage().GetPhase() == plum.XBFTPhase_XBFTPrePrepare {
+			p.setXBFTPrimary(m.GetMessage().GetPeerId())
+		} else {
+			p.setXBFTPrimary(m.GetMessage().GetPrimaryId())
+		}
+		// round change is triggered by the keeper if the peer could not follow the round
+		p.SetTimer(plum.XBFTPhase_XBFTPrePrepare)
+	}
+}
@@ -0,0 +1,150 @@
+package peer
+
+import (
+	"context"
+	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/plum"
+	"google.golang.org/grpc"
+	"io"
+	"testing"
+)
+
+//chainOnLedger makes n blocks on top of the ledger, each finalized by commits of all the peers
+func chainOnLedger(n int) []*plum.SyncBlock {
+	keys := setSigningKeysForTest()
+	var blocks []*plum.SyncBlock
+	prev := GetInstance().L.CurrentBlockHeader()
+	for i := 0; i < n; i++ {
+		b := block.NewVersionedBlock(GetInstance().RetrieveTxs(), block.Digest(prev), prev.GetId()+1, GetInstance().L.MerkleTreeVersion())
+		blocks = append(blocks, &plum.SyncBlock{Block: b, Certificate: pbftCommitCertificateForTest(b, keys, len(keys))})
+		prev = b.GetHeader()
+	}
+	return blocks
+}
+
+//sealChainForTest binds the committee members set on the blocks to their headers, then links and certifies the blocks again
+func sealChainForTest(blocks []*plum.SyncBlock) {
+	keys := setSigningKeysForTest()
+	for i, sb := range blocks {
+		if i > 0 {
+			sb.Block.Header.PrevBlockHash = block.Digest(blocks[i-1].Block.GetHeader())
+		}
+		block.SealCommittee(sb.Block)
+		sb.Certificate = pbftCommitCertificateForTest(sb.Block, keys, len(keys))
+	}
+}
+
+func TestDealer_behind(t *testing.T) {
+	p := GetInstance()
+	h := p.L.CurrentHeight()
+	defer func() {
+		p.D.aheadMessages = make(map[uint32]interface{})
+	}()
+
+	if got := p.D.behind(&plum.PBFTRequest{Message: &plum.PBFTMessage{PeerId: 1, Height: h + 1}}); got == true {
+		t.Errorf("one height ahead should not be regarded as behind")
+	}
+
+	//f validators may lie about their heights
+	for id := uint32(1); id <= uint32(p.toleranceBase()); id++ {
+		if got := p.D.behind(&plum.XBFTRequest{Message: &plum.XBFTMessage{PeerId: id, Height: h + 100}}); got == true {
+			t.Errorf("peer should not be regarded as behind by %d validators", id)
+		}
+	}
+	if got := p.D.behind(&plum.XBFTRequest{Message: &plum.XBFTMessage{PeerId: 1, Height: h + syncGap}}); got == true {
+		t.Errorf("a validator reporting again should not be counted twice")
+	}
+
+	if got := p.D.behind(&plum.XBFTRequest{Message: &plum.XBFTMessage{PeerId: uint32(p.toleranceBase()) + 1, Height: h + syncGap}}); got == false {
+		t.Errorf("peer should be regarded as behind by f+1 validators")
+	}
+
+	if got := p.D.behind(&plum.PBFTRequest{Message: &plum.PBFTMessage{PeerId: p.ID, Height: h + syncGap}}); got == true {
+		t.Errorf("a message of this peer itself should not be regarded as behind")
+	}
+}
+
+func TestPeer_replayBlocks(t *testing.T) {
+	p := GetInstance()
+	before := p.L.CurrentHeight()
+
+	if err := p.replayBlocks(chainOnLedger(3)); err != nil {
+		t.Errorf("could not replay blocks: %v", err)
+	}
+	if got := p.L.CurrentHeight(); got != before+3 {
+		t.Errorf("invalid height after replay. got: %d, want: %d", got, before+3)
+	}
+
+	//tampered transaction
+	tampered := chainOnLedger(1)
+	tampered[0].Block.Body.Txs[0] = []byte("tampered")
+	if err := p.replayBlocks(tampered); err == nil {
+		t.Errorf("a tampered block should not be replayed")
+	}
+
+	//skipped height
+	skipped := chainOnLedger(2)[1:]
+	if err := p.replayBlocks(skipped); err == nil {
+		t.Errorf("a block of skipped height should not be replayed")
+	}
+
+	//not finalized
+	uncertified := chainOnLedger(1)
+	uncertified[0].Certificate = nil
+	if err := p.replayBlocks(uncertified); err == nil {
+		t.Errorf("a block without commit certificate should not be replayed")
+	}
+}
+
+func TestServer_GetBlocks(t *testing.T) {
+	p := GetInstance()
+	if err := p.replayBlocks(chainOnLedger(2)); err != nil {
+		t.Errorf("could not replay blocks: %v", err)
+	}
+
+	pc := plum.NewPeerClient(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := p.L.CurrentHeight()
+	stream, err := pc.GetBlocks(ctx, &plum.BlockRange{From: h - 1, To: h + 10}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("could not get blocks: %v", err)
+	}
+
+	var got []*plum.SyncBlock
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("could not receive blocks: %v", err)
+		}
+		got = append(got, b)
+	}
+
+	//the range should be cut at the current height
+	if len(got) != 2 {
+		t.Errorf("invalid number of blocks. got: %d, want: %d", len(got), 2)
+	}
+	for i, sb := range got {
+		if sb.GetBlock().GetHeader().GetId() != h-1+uint64(i) {
+			t.Errorf("invalid height of block. got: %d, want: %d", sb.GetBlock().GetHeader().GetId(), h-1+uint64(i))
+		}
+		if sb.GetCertificate().GetHeight() != sb.GetBlock().GetHeader().GetId() {
+			t.Errorf("block should be sent with its certificate")
+		}
+	}
+
+	//a range in reverse or beyond the current height has no blocks
+	for _, r := range []*plum.BlockRange{{From: h, To: h - 2}, {From: h + 5, To: h + 10}} {
+		stream, err := pc.GetBlocks(ctx, r)
+		if err != nil {
+			t.Fatalf("could not get blocks: %v", err)
+		}
+		if b, err := stream.Recv(); err != io.EOF {
+			t.Errorf("no block should be sent for %v. got: %v, %v", r, b, err)
+		}
+	}
+}
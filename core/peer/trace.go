@@ -0,0 +1,175 @@
+package peer
+
+import (
+	"context"
+	"github.com/yoseplee/plum/core/trace"
+)
+
+//names of the spans which are not consensus phases
+const (
+	spanRound  = "round"
+	spanAppend = "append"
+)
+
+//roundTrace follows a round at a height on this peer. its root span lasts for the round,
+//and a child span is made for each phase, from the first message of the phase handled to the next phase
+type roundTrace struct {
+	height uint64
+	round  uint64
+	root   *trace.Span
+	phase  *trace.Span
+	//seen is the phases which have had their span, late messages of them do not start it again
+	seen map[string]bool
+}
+
+//traceKey identifies the phase of a round at a height whose first message from other peers is linked to the span of the phase
+type traceKey struct {
+	height uint64
+	round  uint64
+	phase  string
+}
+
+//messagePhase returns the height, round and name of the phase of the consensus message
+func messagePhase(message interface{}) (uint64, uint64, string) {
+	height, _, round := messageHeight(message)
+	_, phase := messageLabels(message)
+	return height, round, phase
+}
+
+//receiveTrace keeps the span context of the sender found in the metadata of the message, which is linked on handling it
+func (p *peer) receiveTrace(ctx context.Context, message interface{}) {
+	if !trace.Enabled() {
+		return
+	}
+	sc, ok := trace.Extract(ctx)
+	if !ok {
+		return
+	}
+	height, round, phase := messagePhase(message)
+	key := traceKey{height: height, round: round, phase: phase}
+
+	p.traceMutex.Lock()
+	defer p.traceMutex.Unlock()
+	if _, exists := p.remoteSpans[key]; !exists {
+		p.remoteSpans[key] = sc
+	}
+}
+
+//sendingTrace returns the span context to be sent along with the message, which is of the phase this peer is in
+func (p *peer) sendingTrace(message interface{}) trace.SpanContext {
+	if !trace.Enabled() {
+		return trace.SpanContext{}
+	}
+	height, round, _ := messagePhase(message)
+
+	p.traceMutex.Lock()
+	defer p.traceMutex.Unlock()
+	if t := p.roundTrace; t != nil && t.height == height && t.round == round {
+		if t.phase != nil {
+			return t.phase.Context()
+		}
+		return t.root.Context()
+	}
+	return trace.SpanContext{}
+}
+
+//tracePhase starts the span of the phase of the message if it is the first one of the phase in the round,
+//ending the span of the previous phase. it should be called when a message of the current round is handled
+func (p *peer) tracePhase(message interface{}) {
+	if !trace.Enabled() {
+		return
+	}
+	height, round, phase := messagePhase(message)
+
+	p.traceMutex.Lock()
+	defer p.traceMutex.Unlock()
+	t := p.startRoundTrace(height, round)
+	if t.seen[phase] {
+		return
+	}
+	p.startPhaseSpan(t, phase)
+	t.phase.AddLink(p.remoteSpans[traceKey{height: height, round: round, phase: phase}])
+}
+
+//traceAppend starts the span of appending the block of the current round, which ends by finishRoundTrace
+func (p *peer) traceAppend() {
+	if !trace.Enabled() {
+		return
+	}
+	p.traceMutex.Lock()
+	defer p.traceMutex.Unlock()
+	p.startPhaseSpan(p.startRoundTrace(p.L.CurrentHeight(), p.ConsensusRound), spanAppend)
+}
+
+//finishRoundTrace ends the spans of the current round with how it has finished, such as appended or round change
+func (p *peer) finishRoundTrace(status string) {
+	if !trace.Enabled() {
+		return
+	}
+	p.traceMutex.Lock()
+	defer p.traceMutex.Unlock()
+	p.endRoundTrace(status)
+}
+
+//startRoundTrace returns the trace of the round, which is started if it is not the one being traced.
+//the previous round is ended as abandoned then, as this peer has moved on by state sync or round change of others
+func (p *peer) startRoundTrace(height, round uint64) *roundTrace {
+	if t := p.roundTrace; t != nil && t.height == height && t.round == round {
+		return t
+	}
+	p.endRoundTrace("abandoned")
+
+	p.roundTrace = &roundTrace{
+		height: height,
+		round:  round,
+		seen:   make(map[string]bool),
+		root: trace.Start(spanRound, trace.RoundTraceID(p.chainID, height, round), trace.SpanID{}, trace.Attributes{
+			"peer":      p.ID,
+			"height":    height,
+			"round":     round,
+			"consensus": p.D.ConsensusType,
+		}),
+	}
+	return p.roundTrace
+}
+
+func (p *peer) startPhaseSpan(t *roundTrace, name string) {
+	t.phase.End()
+	t.seen[name] = true
+	t.phase = trace.Start(name, t.root.Context().TraceID, t.root.Context().SpanID, trace.Attributes{
+		"peer":   p.ID,
+		"height": t.height,
+		"round":  t.round,
+	})
+}
+
+func (p *peer) endRoundTrace(status string) {
+	t := p.roundTrace
+	if t == nil {
+		return
+	}
+	t.phase.End()
+	t.root.SetAttribute("status", status)
+	t.root.SetAttribute("committeeSize", p.committeeSize())
+	t.root.End()
+	p.roundTrace = nil
+
+	for k := range p.remoteSpans {
+		if k.height < t.height || (k.height == t.height && k.round <= t.round) {
+			delete(p.remoteSpans, k)
+		}
+	}
+}
+
+//committeeSize returns the number of peers running the round, which is the committee in XBFT and the validators in PBFT
+func (p *peer) committeeSize() int {
+	if p.D.ConsensusType == "XBFT" {
+		return len(p.D.committeeMembers)
+	}
+	return p.validatorCount()
+}
+
+//tracedContext puts the span context for the message into the context of the rpc sending it
+func tracedContext(ctx context.Context, message interface{}) context.Context {
+	return trace.Inject(ctx, GetInstance().sendingTrace(message))
+}
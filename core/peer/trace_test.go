@@ -0,0 +1,82 @@
+package peer
+
+import (
+	"context"
+	"github.com/yoseplee/plum/core/plum"
+	"github.com/yoseplee/plum/core/trace"
+	"google.golang.org/grpc/metadata"
+	"sync"
+	"testing"
+)
+
+type spanRecorder struct {
+	spans []trace.SpanData
+	mutex sync.Mutex
+}
+
+func (r *spanRecorder) Export(d trace.SpanData) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.spans = append(r.spans, d)
+	return nil
+}
+
+func (r *spanRecorder) Close() error {
+	return nil
+}
+
+func TestPeer_tracePhase(t *testing.T) {
+	p := GetInstance()
+	r := &spanRecorder{}
+	trace.SetExporter(r)
+	defer trace.Shutdown()
+
+	height, round := p.L.CurrentHeight(), p.ConsensusRound
+	message := func(phase plum.XBFTPhase) *plum.XBFTRequest {
+		return &plum.XBFTRequest{Message: &plum.XBFTMessage{Phase: phase, Height: height, Round: round, PeerId: 1}}
+	}
+
+	//the sender of the prepare message is linked to the span of prepare
+	sender := trace.SpanContext{TraceID: trace.RoundTraceID(p.chainID, height, round), SpanID: trace.SpanID{1}}
+	md, _ := metadata.FromOutgoingContext(trace.Inject(context.Background(), sender))
+	p.receiveTrace(metadata.NewIncomingContext(context.Background(), md), message(plum.XBFTPhase_XBFTPrepare))
+
+	p.tracePhase(message(plum.XBFTPhase_XBFTPrePrepare))
+	p.tracePhase(message(plum.XBFTPhase_XBFTPrepare))
+	p.tracePhase(message(plum.XBFTPhase_XBFTPrePrepare))
+	if sc := p.sendingTrace(message(plum.XBFTPhase_XBFTCommit)); !sc.IsValid() {
+		t.Errorf("messages of the round should be sent with the span of the phase")
+	}
+	p.traceAppend()
+	p.finishRoundTrace("appended")
+
+	var names []string
+	for _, s := range r.spans {
+		names = append(names, s.Name)
+	}
+	want := []string{"XBFTPrePrepare", "XBFTPrepare", spanAppend, spanRound}
+	if len(names) != len(want) {
+		t.Fatalf("invalid spans. got: %v, want: %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("invalid spans. got: %v, want: %v", names, want)
+		}
+	}
+
+	root := r.spans[len(r.spans)-1]
+	for _, s := range r.spans[:len(r.spans)-1] {
+		if s.ParentSpanID != root.SpanID || s.TraceID != root.TraceID {
+			t.Errorf("span of a phase should be under the round: %v", s)
+		}
+	}
+	if links := r.spans[1].Links; len(links) != 1 || links[0] != sender.String() {
+		t.Errorf("span of prepare should be linked to the sender. got: %v", links)
+	}
+	if root.Attributes["status"] != "appended" {
+		t.Errorf("invalid status of the round: %v", root.Attributes["status"])
+	}
+	if len(p.remoteSpans) != 0 {
+		t.Errorf("span contexts of the finished round should be dropped: %v", p.remoteSpans)
+	}
+}
@@ -5,15 +5,14 @@ import (
 	"github.com/yoseplee/plum/core/plum"
 	"github.com/yoseplee/plum/core/util"
 	"github.com/yoseplee/vrf"
-	"log"
 )
 
 func (d *Dealer) startXBFT() {
-	log.Printf("Dealer has started up on peer %d | type: %s\n", GetInstance().ID, d.ConsensusType)
+	GetInstance().log().Infof("dealer has started up")
 	for {
 		select {
 		case <-d.stopSig:
-			log.Printf("Dealer stopped on peer %d\n", GetInstance().ID)
+			GetInstance().log().Infof("dealer stopped")
 			return
 		default:
 			d.scheduleXBFT(scheduleMq)
@@ -29,24 +28,47 @@ func (d *Dealer) scheduleXBFT(criteria scheduleCriteria) {
 		if err != nil {
 			break
 		}
+		d.stateMutex.Lock()
 		d.handleXBFT(m.D)
+		d.stateMutex.Unlock()
 	case scheduleHeap:
 		m, err := d.ReservedXBFTMessage.Pop()
 		if err != nil {
 			break
 		}
+		d.stateMutex.Lock()
 		d.handleXBFT(m)
+		d.stateMutex.Unlock()
 	}
 }
 
 func (d *Dealer) handleXBFT(m *plum.XBFTRequest) {
 	p := GetInstance()
-	//log.Printf("[p %d] handle %s | current_round(%d), current_phase(%s), n_mq(%d), n_reserved(%d)\n", p.ID, util.MakeString(m), p.ConsensusRound, p.XBFTPhase.String(), d.XBFTMQ.GetN(), d.ReservedXBFTMessage.Last)
-	//p.PrintPeer()
+	p.log().Debugf("handle %s | n_mq(%d), n_reserved(%d)", util.MakeString(m), d.XBFTMQ.GetN(), d.ReservedXBFTMessage.GetLast()+1)
 
-	if !d.roundCheck(m) || !p.VerifyConsensusMessageSignature(m) {
+	if !p.replayable(m) {
+		p.log().Debugf("drop %s made again in replay", util.MakeString(m))
 		return
 	}
+	if !p.VerifyConsensusMessageSignature(m) {
+		return
+	}
+
+	if d.behind(m) {
+		d.catchUp()
+	}
+
+	//a peer out of the validator set only follows the chain
+	if !p.isValidator(p.ID) {
+		return
+	}
+
+	if !d.roundCheck(m) {
+		return
+	}
+	d.detectEquivocation(m)
+	p.tracePhase(m)
+	p.MessageLog.Store(m)
 
 	switch m.Message.GetPhase() {
 	case plum.XBFTPhase_XBFTPrePrepare:
@@ -60,7 +82,7 @@ func (d *Dealer) handleXBFT(m *plum.XBFTRequest) {
 	case plum.XBFTPhase_XBFTRoundChange:
 		handleXBFTRoundChange(m)
 	default:
-		log.Println("failed to handle consensus message due to the invalid phase")
+		p.log().Warnf("failed to handle consensus message due to the invalid phase %s", m.GetMessage().GetPhase())
 	}
 }
 
@@ -75,7 +97,7 @@ func handleXBFTRoundChange(m *plum.XBFTRequest) {
 	//		p.L.CurrentBlockHeader().MerkleRoot,
 	//		m.GetMessage().GetSelectionValue(),
 	//	) {
-	//		log.Println("invalid verification of selection during the round change(seed: merkle root) at round", m.GetMessage().GetRound())
+	//		p.log().Warnf("invalid verification of selection during the round change(seed: merkle root) at round %d", m.GetMessage().GetRound())
 	//		return
 	//	}
 	//} else {
@@ -85,17 +107,27 @@ func handleXBFTRoundChange(m *plum.XBFTRequest) {
 	//		p.L.CurrentBlockHeader().PrevBlockHash,
 	//		m.GetMessage().GetSelectionValue(),
 	//	) {
-	//		log.Println("invalid verification of selection during the round change(seed: prev block hash) at round", m.GetMessage().GetRound())
+	//		p.log().Warnf("invalid verification of selection during the round change(seed: prev block hash) at round %d", m.GetMessage().GetRound())
 	//		return
 	//	}
 	//}
 
-	//store message into the log
-	p.XBFTMessageLog.Store(m)
+	// a signer is counted once: its round change of a later round takes the place of the earlier one, without its reputation summed again
+	if i := signerIndex(p.D.roundChangeCertificate, senderID); i >= 0 {
+		if p.D.roundChangeCertificate[i].GetMessage().GetRound() >= m.GetMessage().GetRound() {
+			p.log().Debugf("duplicate round change of peer %d at round %d", senderID, m.GetMessage().GetRound())
+			return
+		}
+		p.D.roundChangeCertificate[i] = m
+		p.D.roundChangeCommitteeMembers = removeCommitteeMember(p.D.roundChangeCommitteeMembers, senderID)
+	} else {
+		p.D.roundChangeReputationSum += p.ReputationBook[senderID]
+		// forming a round change certificate... don't need distinctive primary id for data
+		p.D.roundChangeCertificate = append(p.D.roundChangeCertificate, m)
+	}
 
-	p.D.roundChangeReputationSum += p.ReputationBook[senderID]
 	selectionValue := m.GetMessage().GetSelectionValue()
-	if Selection(selectionValue) {
+	if p.Selection(selectionValue) {
 		p.D.roundChangeCommitteeMembers = append(p.D.roundChangeCommitteeMembers, &plum.CommitteeMembers{
 			PeerId:         m.GetMessage().GetPeerId(),
 			Round:          m.GetMessage().GetRound(),
@@ -104,14 +136,10 @@ func handleXBFTRoundChange(m *plum.XBFTRequest) {
 		})
 	}
 
-	// forming a round change certificate... don't need distinctive primary id for data
-	p.D.roundChangeCertificate = append(p.D.roundChangeCertificate, m)
-
 	p.K.setXBFTTimer(plum.XBFTPhase_XBFTRoundChange)
 
 	repRatio := p.D.roundChangeReputationSum / p.D.totalReputationAtRound
-	//log.Println("reputation ratio for round change:", repRatio)
-	//log.Println("collected number of committee member:", len(p.D.roundChangeCommitteeMembers), "/", p.minimumCommitteeSize())
+	p.log().Debugf("reputation ratio for round change: %v, committee members: %d / %d", repRatio, len(p.D.roundChangeCommitteeMembers), p.minimumCommitteeSize())
 
 	if repRatio > 0.5 && len(p.D.roundChangeCommitteeMembers) >= p.minimumCommitteeSize() {
 
@@ -125,11 +153,11 @@ func handleXBFTRoundChange(m *plum.XBFTRequest) {
 		}
 
 		if p.ConsensusRound < highestRoundAtRcc {
-			log.Printf("update consensus round from %d to %d because there was higher consensus round in the round change certificate", p.ConsensusRound, highestRoundAtRcc)
+			p.log().Infof("update consensus round from %d to %d because there was higher consensus round in the round change certificate", p.ConsensusRound, highestRoundAtRcc)
 			p.ConsensusRound = highestRoundAtRcc
 		}
 
-		log.Printf("Handle Round change %d -> %d", p.ConsensusRound, p.ConsensusRound+1)
+		p.log().Infof("handle round change %d -> %d", p.ConsensusRound, p.ConsensusRound+1)
 		p.ConsensusRound++
 
 		// 2. Calculate Summation of Reputation at round
@@ -140,17 +168,8 @@ func handleXBFTRoundChange(m *plum.XBFTRequest) {
 
 		// 4. Reset node states
 		p.ConsensusState = plum.ConsensusState_PrePrepared
-		p.XBFTPhase = plum.XBFTPhase_XBFTPrePrepare
-		p.D.ReservedPrepareMessage = make(map[uint32][]*plum.XBFTRequest)
-		p.D.ReservedCommitMessage = make(map[uint32][]*plum.XBFTRequest)
-		p.D.CandidateBlocks = make(map[uint32]*plum.Block)
-		p.D.CandidateBlockDigests = make(map[uint32][]byte)
-		p.D.CandidateBlockCertificates = make(map[uint32]map[plum.XBFTPhase][]*plum.XBFTRequest)
-		p.D.CandidateCommitteeMembers = make(map[uint32][]*plum.CommitteeMembers)
-		p.D.receivedReputationSum = make(map[uint32]float64)
-		p.D.totalReputationAtRound = p.RepSum()
-		p.D.roundChangeReputationSum = 0.0
-		p.D.roundChangeCommitteeMembers = nil
+		p.setXBFTPhase(plum.XBFTPhase_XBFTPrePrepare)
+		p.D.resetXBFTRoundState()
 
 		// 5. Set node role based on the selection result
 		p.assignRoleByCommitteeMember()
@@ -167,20 +186,26 @@ func handleXBFTRoundChange(m *plum.XBFTRequest) {
 			p.Role = plum.ConsensusRole_Primary
 
 			// 8.2. Create a new Candidate Block (OR from the prepared certification)
-			p.D.setXBFTCandidateBlock(p.ID, p.nextRoundCandidateBlock(&plum.Certificate{Cert: p.D.roundChangeCertificate}))
+			cb, certified := p.nextRoundCandidateBlock(&plum.Certificate{Cert: p.D.roundChangeCertificate})
 
-			log.Println("newly added round changed committee member")
-			util.PrintCommitteeMembers(p.D.CandidateBlocks[p.ID].CommitteeMembers)
+			p.log().Debugf("newly added round changed committee member:%s", util.CommitteeMembersString(cb.CommitteeMembers))
 
-			// 8.3. Append committee member to round changed committee members
-			for _, cm := range p.D.CandidateBlocks[p.ID].CommitteeMembers {
-				p.D.CandidateBlocks[p.ID].RoundChangedCommitteeMembers = append(p.D.CandidateBlocks[p.ID].RoundChangedCommitteeMembers, cm)
-			}
+			// a certified block is proposed again as it is, since peers may have prepared, committed or appended its digest
+			if !certified {
+				// 8.3. Append committee member to round changed committee members
+				for _, cm := range cb.CommitteeMembers {
+					cb.RoundChangedCommitteeMembers = append(cb.RoundChangedCommitteeMembers, cm)
+				}
 
-			// 8.4. Set block's committee member as the primary's committee member
-			p.D.CandidateBlocks[p.ID].CommitteeMembers = p.D.committeeMembers
+				// 8.4. Set block's committee member as the primary's committee member
+				cb.CommitteeMembers = p.D.committeeMembers
 
-			// 8.5. Multicast a Pre-prepare message to all
+				// 8.5. Bind the committee members to the header of the new block
+				block.SealCommittee(cb)
+			}
+			p.D.setXBFTCandidateBlock(p.ID, cb)
+
+			// 8.6. Multicast a Pre-prepare message to all
 			consensusMessage := &plum.XBFTMessage{
 				Phase:                  plum.XBFTPhase_XBFTPrePrepare,
 				Round:                  p.ConsensusRound,
@@ -206,100 +231,98 @@ func handleXBFTPrePrepare(m *plum.XBFTRequest) {
 	senderID := m.GetMessage().GetPeerId()
 	receivedPrimary, err := findCommitteeMemberById(senderID, m.GetBlock().CommitteeMembers)
 	if err != nil {
-		log.Println("could not find a received primary", err)
+		p.log().Warnf("could not find a received primary: %v", err)
 	}
 
-	//util.DebugMsg(fmt.Sprintf("received pre-prepare message from peer-%d with %v", m.GetMessage().GetPeerId(), receivedPrimary.SelectionValue))
+	p.log().Debugf("received pre-prepare message from peer %d with %v", senderID, receivedPrimary.GetSelectionValue())
 
-	// 1. verify the candidate block - not implemented yet
-	//if !block.Verify(m.GetBlock()) {
-	//	log.Panic("invalid block")
-	//}
-
-	// 2. Store Message
-	p.XBFTMessageLog.Store(m)
+	// 1. verify the candidate block: the merkle tree is not shipped, so the root is recomputed from the transactions
+	if !block.Verify(m.GetBlock()) {
+		p.log().Warnf("invalid candidate block from peer %d", senderID)
+		return
+	}
 
-	// 3. Set Candidate Block
+	// 2. Set Candidate Block
 	p.D.setXBFTCandidateBlock(senderID, m.GetBlock())
 
-	// 4. Set threshold for received primary's committee members
+	// 3. Set threshold for received primary's committee members
 	p.setXBFTThreshold(senderID, m.GetBlock().GetCommitteeMembers())
 
-	// 5. if the received Primary is the recognized Primary,
+	// 4. if the received Primary is the recognized Primary,
 	if p.XBFTPrimary == senderID {
-		// 5.1. Change current Committee member according to candidate block from the received primary
+		// 4.1. Change current Committee member according to candidate block from the received primary
 		p.D.committeeMembers = m.GetBlock().GetCommitteeMembers()
-		//log.Println("received from the recognized primary")
-		//util.PrintCommitteeMembers(p.D.committeeMembers)
 
-		// 5.3. Change the role according to committee member in the candidate block of received primary
+		// 4.3. Change the role according to committee member in the candidate block of received primary
 		p.assignRoleByCommitteeMember()
 
-		// 5.5. Set timer
+		// 4.5. Set timer
 		p.SetTimer(m.Message.Phase)
 
 		// [EXPERIMENT] verify the candidate block - if peer 0 or 1 => discard!
 		if senderID == 0 || senderID == 1 {
-			log.Println("got invalid block from a malicious node -", senderID)
+			p.log().Warnf("got invalid block from a malicious node %d", senderID)
 			return
 		}
 
-		// 5.4. Set Phase: pre-prepare
-		p.XBFTPhase = plum.XBFTPhase_XBFTPrePrepare
+		// 4.4. Set Phase: pre-prepare
+		p.setXBFTPhase(plum.XBFTPhase_XBFTPrePrepare)
 		p.ConsensusState = plum.ConsensusState_Idle
 
-		// 5.6. Handle all the reserved prepare messages
+		// 4.6. Handle all the reserved prepare messages
 		handleAllTheReservedPrepareMessages(p)
 	} else {
-		//util.DebugMsg("received pre-prepare message from not recognized node, comparing the two SVs")
+		p.log().Debugf("received pre-prepare message from peer %d which is not the recognized primary, comparing the two selection values", senderID)
 
-		// 6. Compare current Primary's SV(Selection value) and received Primary's SV
+		// 5. Compare current Primary's SV(Selection value) and received Primary's SV
+		// a peer which has just caught up by state sync does not know the committee, so any received primary wins
+		selectionValueOfCurrentPrimary := -1.0
 		currentPrimary, err := findCommitteeMemberById(p.XBFTPrimary, p.D.committeeMembers)
 		if err != nil {
-			log.Println("could not find current primary", err)
+			p.log().Warnf("could not find current primary: %v", err)
+		} else {
+			selectionValueOfCurrentPrimary = currentPrimary.SelectionValue
 		}
-		selectionValueOfCurrentPrimary := currentPrimary.SelectionValue
 		selectionValueOfReceivedPrimary := receivedPrimary.SelectionValue
 
-		// 7. If the received Primary has higher SV than current Primary,
+		// 6. If the received Primary has higher SV than current Primary,
 		if selectionValueOfReceivedPrimary > selectionValueOfCurrentPrimary {
-			//log.Println("received from the primary with the higher selection value")
 
-			// 7.1. If the peer has committed certification, keep the current primary instead of changing
+			// 6.1. If the peer has committed certification, keep the current primary instead of changing
 			if p.ConsensusState == plum.ConsensusState_Committed {
-				//log.Println("but keep current primary because this peer has already been sent select message to all")
+				p.log().Debugf("keep current primary %d against peer %d of the higher selection value as this peer has already sent select message to all", p.XBFTPrimary, senderID)
 				handleAllTheReservedCommitMessages(p, senderID)
 				return
 			}
 
-			// 7.2. Change current Primary to received Primary
+			// 6.2. Change current Primary to received Primary
 			p.setXBFTPrimary(m.GetMessage().GetPeerId())
 
-			// 7.3. Change current Committee member according to candidate block from the received primary
+			// 6.3. Change current Committee member according to candidate block from the received primary
 			p.D.committeeMembers = m.GetBlock().GetCommitteeMembers()
 
-			// 7.4. Change the role according to committee members in the candidate block of received primary
+			// 6.4. Change the role according to committee members in the candidate block of received primary
 			p.assignRoleByCommitteeMember()
 
-			// 7.5. Set timer
+			// 6.5. Set timer
 			p.SetTimer(m.Message.Phase)
 
 			// [EXPERIMENT] verify the candidate block - if peer 0 or 1 => discard!
 			if senderID == 0 || senderID == 1 {
-				log.Println("got invalid block from a malicious node -", senderID)
+				p.log().Warnf("got invalid block from a malicious node %d", senderID)
 				return
 			}
 
-			// 7.6. Set Phase: pre-prepare as the primary has changed
-			p.XBFTPhase = plum.XBFTPhase_XBFTPrePrepare
+			// 6.6. Set Phase: pre-prepare as the primary has changed
+			p.setXBFTPhase(plum.XBFTPhase_XBFTPrePrepare)
 			p.ConsensusState = plum.ConsensusState_Idle
 
-			// 7.7. Handle all the reserved prepare messages
+			// 6.7. Handle all the reserved prepare messages
 			handleAllTheReservedPrepareMessages(p)
 		}
 	}
 
-	// 8. Send Prepare to all the committee members (Committee member only excluding the primary)
+	// 7. Send Prepare to all the committee members (Committee member only excluding the primary)
 	_, exists := p.D.CandidateBlocks[p.XBFTPrimary]
 	if p.Role == plum.ConsensusRole_CommitteeMember && exists && p.ID != p.XBFTPrimary {
 		consensusMessage := &plum.XBFTMessage{
@@ -317,10 +340,10 @@ func handleXBFTPrePrepare(m *plum.XBFTRequest) {
 		})
 	}
 
-	// 9. Handle all the commit messages which is corresponding to the pre-prepare messages
+	// 8. Handle all the commit messages which is corresponding to the pre-prepare messages
 	handleAllTheReservedCommitMessages(p, senderID)
 
-	// 10. Reset round change certificate
+	// 9. Reset round change certificate
 	p.D.roundChangeCertificate = nil
 }
 
@@ -349,30 +372,29 @@ func handleXBFTPrepare(m *plum.XBFTRequest) {
 
 	// 4. Check block digest
 	if !block.CompareBlockDigest(p.D.CandidateBlockDigests[p.XBFTPrimary], m.GetMessage().GetDigest()) {
-		log.Println("different block digest detected. current primary is", p.XBFTPrimary, ", received primary is", m.GetMessage().GetPrimaryId())
+		p.log().Warnf("different block digest detected. current primary is %d, received primary is %d", p.XBFTPrimary, m.GetMessage().GetPrimaryId())
 		return
 	}
 
-	// 5. Store the message
-	p.XBFTMessageLog.Store(m)
-
-	// 6. Add the message to the preparedCertificate
-	makeCert(p.XBFTPrimary, plum.XBFTPhase_XBFTPrepare, m)
+	// 5. Add the message to the preparedCertificate, once for a signer
+	if !makeCert(p.XBFTPrimary, plum.XBFTPhase_XBFTPrepare, m) {
+		return
+	}
 
-	// 7. Set timer
+	// 6. Set timer
 	pCert, pCertExists := pCert(p.XBFTPrimary)
 	if len(pCert) == 0 {
 		//start the timer because this is the first time to prepare
 		p.SetTimer(plum.XBFTPhase_XBFTPrepare)
 	}
 
-	// 8. If preparedCertification is formed successfully,
+	// 7. If preparedCertification is formed successfully,
 	if pCertExists {
 
-		// 8.1. Change node state to be XBFT_Prepare
-		p.XBFTPhase = plum.XBFTPhase_XBFTPrepare
+		// 7.1. Change node state to be XBFT_Prepare
+		p.setXBFTPhase(plum.XBFTPhase_XBFTPrepare)
 
-		// 8.2. Multicast Commit message to all (including itself)
+		// 7.2. Multicast Commit message to all (including itself)
 		consensusMessage := &plum.XBFTMessage{
 			Phase:               plum.XBFTPhase_XBFTCommit,
 			Round:               p.ConsensusRound,
@@ -409,12 +431,15 @@ func handleXBFTCommit(m *plum.XBFTRequest) {
 
 	// 2. Check block digest
 	if !block.CompareBlockDigest(p.D.CandidateBlockDigests[receivedPrimary], m.GetMessage().GetDigest()) {
-		log.Println("different block digest is detected at", m.GetMessage().GetPhase())
+		p.log().Warnf("different block digest is detected at %s", m.GetMessage().GetPhase())
 		return
 	}
 
 	// verify received prepared certificate
-	p.verifyCertificate(m.GetMessage().GetPreparedCertificate())
+	if !p.verifyCertificate(m.GetMessage().GetPreparedCertificate()) {
+		p.log().Warnf("invalid prepared certificate of peer %d at %s", m.GetMessage().GetPeerId(), m.GetMessage().GetPhase())
+		return
+	}
 
 	// 3. If it is from the recognized primary
 	if receivedPrimary == p.XBFTPrimary {
@@ -426,8 +451,10 @@ func handleXBFTCommit(m *plum.XBFTRequest) {
 			return
 		}
 
-		// 3.2. Add this message for committed certification
-		makeCert(receivedPrimary, plum.XBFTPhase_XBFTCommit, m)
+		// 3.2. Add this message for committed certification, once for a signer
+		if !makeCert(receivedPrimary, plum.XBFTPhase_XBFTCommit, m) {
+			return
+		}
 
 		// 3.3. Set timer
 		cCert, cCertExists := cCert(p.XBFTPrimary)
@@ -440,13 +467,13 @@ func handleXBFTCommit(m *plum.XBFTRequest) {
 		if cCertExists && p.ConsensusState != plum.ConsensusState_Committed {
 
 			// 3.4.1. Change node state to be XBFT_Commit
-			p.XBFTPhase = plum.XBFTPhase_XBFTCommit
+			p.setXBFTPhase(plum.XBFTPhase_XBFTCommit)
 			p.ConsensusState = plum.ConsensusState_Committed
 
 			// 3.4.2. Generate vrf hash and corresponding proof
 			pi, vrfHash, proveErr := vrf.Prove(p.PublicKey, p.PrivateKey, p.D.CandidateBlockDigests[p.XBFTPrimary])
 			if proveErr != nil {
-				log.Println("could not prove:", proveErr)
+				p.log().Errorf("could not prove: %v", proveErr)
 				return
 			}
 
@@ -454,7 +481,7 @@ func handleXBFTCommit(m *plum.XBFTRequest) {
 			selectionValue := p.selectionValue(vrfHash, p.ID)
 
 			// 3.4.4. Increase Tentative Selected Count because this peer has selected as a committee member for the next round
-			if Selection(selectionValue) {
+			if p.Selection(selectionValue) {
 				p.TentativeSelectedCount++
 			}
 
@@ -486,8 +513,6 @@ func handleXBFTCommit(m *plum.XBFTRequest) {
 		// 3.5. If commit certification is not formed yet, add the message to form the certification
 		makeCert(receivedPrimary, plum.XBFTPhase_XBFTCommit, m)
 	}
-	// 4. Store message
-	p.XBFTMessageLog.Store(m)
 }
 
 func handleXBFTSelect(m *plum.XBFTRequest) {
@@ -502,7 +527,7 @@ func handleXBFTSelect(m *plum.XBFTRequest) {
 		return
 	} else if !block.CompareBlockDigest(p.D.CandidateBlockDigests[receivedPrimaryID], m.GetMessage().GetDigest()) {
 		// 2. Check block digest
-		log.Println("different digest of block is detected at", m.GetMessage().GetPhase())
+		p.log().Warnf("different digest of block is detected at %s", m.GetMessage().GetPhase())
 		return
 	}
 
@@ -514,33 +539,42 @@ func handleXBFTSelect(m *plum.XBFTRequest) {
 		return
 	}
 
-	p.SetTimer(plum.XBFTPhase_XBFTSelect)
-
 	// verify received prepared certificate
-	p.verifyCertificate(m.GetMessage().GetPreparedCertificate())
+	if !p.verifyCertificate(m.GetMessage().GetPreparedCertificate()) {
+		p.log().Warnf("invalid prepared certificate of peer %d at %s", m.GetMessage().GetPeerId(), m.GetMessage().GetPhase())
+		return
+	}
 
 	// verify received committed certificate
-	p.verifyCertificate(m.GetMessage().GetCommittedCertificate())
+	if !p.verifyCertificate(m.GetMessage().GetCommittedCertificate()) {
+		p.log().Warnf("invalid committed certificate of peer %d at %s", m.GetMessage().GetPeerId(), m.GetMessage().GetPhase())
+		return
+	}
+
+	p.SetTimer(plum.XBFTPhase_XBFTSelect)
 
 	// 4. If has committed certificate, proceed
 	if cCertExists {
 
+		// a signer is counted once for the primary
+		if signerIndex(p.D.SelectMessages[receivedPrimaryID], m.GetMessage().GetPeerId()) >= 0 {
+			p.log().Debugf("duplicate select of peer %d for primary %d", m.GetMessage().GetPeerId(), receivedPrimaryID)
+			return
+		}
+
 		if !verifySelect(
 			m.GetMessage().GetPeerId(),
 			m.GetMessage().GetProof(),
 			p.D.CandidateBlockDigests[receivedPrimaryID],
 			m.GetMessage().GetSelectionValue(),
 		) {
-			log.Println("invalid verification of selection at round", m.GetMessage().GetRound())
+			p.log().Warnf("invalid verification of selection at round %d", m.GetMessage().GetRound())
 			return
 		}
 
-		// 4.1. Store the message
-		p.XBFTMessageLog.Store(m)
-
-		// 4.2. Add to candidateCommitteeMember
+		// 4.1. Add to candidateCommitteeMember
 		selectionValue := m.GetMessage().GetSelectionValue()
-		if Selection(selectionValue) {
+		if p.Selection(selectionValue) {
 			p.D.CandidateCommitteeMembers[receivedPrimaryID] = append(p.D.CandidateCommitteeMembers[receivedPrimaryID], &plum.CommitteeMembers{
 				PeerId:         m.GetMessage().GetPeerId(),
 				Round:          m.GetMessage().GetRound(),
@@ -549,15 +583,15 @@ func handleXBFTSelect(m *plum.XBFTRequest) {
 			})
 		}
 
-		// 4.3. Sum reputation of the sender
+		// 4.2. Sum reputation of the sender, keeping the message for the commit certificate
+		p.D.SelectMessages[receivedPrimaryID] = append(p.D.SelectMessages[receivedPrimaryID], m)
 		p.D.receivedReputationSum[receivedPrimaryID] += p.ReputationBook[m.GetMessage().GetPeerId()]
 		repRatio := p.D.receivedReputationSum[receivedPrimaryID] / p.D.totalReputationAtRound
-		//log.Println("Rep ratio at selection for", receivedPrimaryID, ":", repRatio)
-		//log.Println("collected number of committee member:", len(p.D.CandidateCommitteeMembers[receivedPrimaryID]), "/", p.minimumCommitteeSize())
+		p.log().Debugf("reputation ratio at selection for %d: %v, committee members: %d / %d", receivedPrimaryID, repRatio, len(p.D.CandidateCommitteeMembers[receivedPrimaryID]), p.minimumCommitteeSize())
 
-		// 4.4. If it has collected messages from the all nodes on the same primary but the number of committee size is lacking, let round change
+		// 4.3. If it has collected messages from the all nodes on the same primary but the number of committee size is lacking, let round change
 		if repRatio > 0.99999999 && len(p.D.CandidateCommitteeMembers[receivedPrimaryID]) < p.minimumCommitteeSize() {
-			log.Println("committee size is lacking")
+			p.log().Warnf("committee size is lacking")
 			p.D.triggerXBFTRoundChange()
 			return
 		}
@@ -568,79 +602,82 @@ func handleXBFTSelect(m *plum.XBFTRequest) {
 		}
 		repRatioForAll := reputationSumForAll / p.D.totalReputationAtRound
 
-		// 4.5. If it has collected messages from the all nodes on different primaries but the number of committee size is still lacking, let round change
+		// 4.4. If it has collected messages from the all nodes on different primaries but the number of committee size is still lacking, let round change
 		// TODO: have to implement to search because any of them may have sufficient number of committee size
 		if repRatioForAll > 0.99999999 && len(p.D.CandidateCommitteeMembers[receivedPrimaryID]) < p.minimumCommitteeSize() {
-			log.Println("committee size is lacking")
+			p.log().Warnf("committee size is lacking")
 			p.D.triggerXBFTRoundChange()
 			return
 		}
 
-		// 4.6. If the summation is larger than 50% of total reputation
+		// 4.5. If the summation is larger than 50% of total reputation
 		if repRatio > 0.5 && len(p.D.CandidateCommitteeMembers[receivedPrimaryID]) >= p.minimumCommitteeSize() {
 
-			// 4.6.1. Append the block
-			log.Printf("Append Block height: %d, from: %d | at round %d\n", p.L.Height, receivedPrimaryID, p.ConsensusRound)
-			err := p.L.Append(p.D.CandidateBlocks[receivedPrimaryID])
+			// 4.5.1. Append the block along with the committed certificate and the select messages as its certificate
+			p.log().Infof("append block from primary %d", receivedPrimaryID)
+			p.traceAppend()
+			cCert, _ := cCert(receivedPrimaryID)
+			certificate := &plum.CommitCertificate{
+				Height:        p.D.CandidateBlocks[receivedPrimaryID].GetHeader().GetId(),
+				BlockDigest:   p.D.CandidateBlockDigests[receivedPrimaryID],
+				XbftCommitted: &plum.Certificate{Cert: cCert},
+				XbftSelected:  &plum.Certificate{Cert: p.D.SelectMessages[receivedPrimaryID]},
+			}
+			err := p.L.AppendWithCertificate(p.D.CandidateBlocks[receivedPrimaryID], certificate)
 			if err != nil {
 				p.PrintPeer()
-				log.Fatalf("could not append block of %d: %v", receivedPrimaryID, err)
+				p.log().Fatalf("could not append block of %d: %v", receivedPrimaryID, err)
 			}
 
-			// 4.6.2. Increase Selected Count
+			// 4.5.2. Increase Selected Count
 			_, involved := findCommitteeMemberById(p.ID, p.D.CandidateBlocks[receivedPrimaryID].CommitteeMembers)
 			if involved == nil {
 				p.SelectedCount++
 			}
 
-			// 4.6.3. Update Reputation, Note that the committee member is not updated one in the phase
-			p.RepDecrease(p.D.CandidateBlocks[receivedPrimaryID].RoundChangedCommitteeMembers)
-			p.RepIncrease(p.D.CandidateBlocks[receivedPrimaryID].CommitteeMembers)
+			// 4.5.3. Update Reputation, Note that the committee member is not updated one in the phase
+			p.applyBlock(p.D.CandidateBlocks[receivedPrimaryID], p.ConsensusRound)
+			p.observeCommit()
+			p.finishRoundTrace("appended")
 
-			// 4.6.4. Increase Round
+			// 4.5.4. Increase Round
 			p.ConsensusRound++
+			p.takeSnapshot()
 
-			// 4.6.5. Calculate Summation of Reputation at that round
+			// 4.5.5. Calculate Summation of Reputation at that round
 			p.D.totalReputationAtRound = GetInstance().RepSum()
 
-			// 4.6.6. Set committeeMember from candidateCommitteeMember
-			p.D.committeeMembers = p.D.CandidateCommitteeMembers[receivedPrimaryID]
+			// 4.5.6. Set committeeMember from candidateCommitteeMember
+			p.setCommitteeMembers(p.D.CandidateCommitteeMembers[receivedPrimaryID])
 
-			// 4.6.7. Reset node states
+			// 4.5.7. Reset node states
 			p.ConsensusState = plum.ConsensusState_PrePrepared
-			p.XBFTPhase = plum.XBFTPhase_XBFTPrePrepare
-			p.D.ReservedPrepareMessage = make(map[uint32][]*plum.XBFTRequest)
-			p.D.ReservedCommitMessage = make(map[uint32][]*plum.XBFTRequest)
-			p.D.CandidateBlocks = make(map[uint32]*plum.Block)
-			p.D.CandidateBlockDigests = make(map[uint32][]byte)
-			p.D.CandidateBlockCertificates = make(map[uint32]map[plum.XBFTPhase][]*plum.XBFTRequest)
-			p.D.CandidateCommitteeMembers = make(map[uint32][]*plum.CommitteeMembers)
-			p.D.receivedReputationSum = make(map[uint32]float64)
-			p.D.totalReputationAtRound = p.RepSum()
-			p.D.roundChangeReputationSum = 0.0
-			p.D.roundChangeCommitteeMembers = nil
+			p.setXBFTPhase(plum.XBFTPhase_XBFTPrePrepare)
+			p.D.resetXBFTRoundState()
 
 			p.SetTimer(plum.XBFTPhase_XBFTPrePrepare)
 
-			// 4.6.8. Set node role based on the selection result
+			// 4.5.8. Set node role based on the selection result
 			p.assignRoleByCommitteeMember()
 
-			// 4.6.9. Find a new primary among the collected selection results
+			// 4.5.9. Find a new primary among the collected selection results
 			primary, _ := findXBFTPrimary(p.D.committeeMembers)
 
-			// 4.6.10. Set a new primary
+			// 4.5.10. Set a new primary
 			p.setXBFTPrimary(primary)
 
-			// 4.6.11. If this node is the primary among the collected selection results
+			// 4.5.11. If this node is the primary among the collected selection results
 			if p.ID == primary {
-				// 4.6.11.1. Change its role to be the primary
+				// 4.5.11.1. Change its role to be the primary
 				p.Role = plum.ConsensusRole_Primary
 
-				// 4.6.11.2. Create a new Candidate Block
-				p.D.setXBFTCandidateBlock(p.ID, p.NewCandidateBlock())
-				p.D.CandidateBlocks[p.ID].CommitteeMembers = p.D.committeeMembers
+				// 4.5.11.2. Create a new Candidate Block with the committee members bound to its header
+				cb := p.NewCandidateBlock()
+				cb.CommitteeMembers = p.D.committeeMembers
+				block.SealCommittee(cb)
+				p.D.setXBFTCandidateBlock(p.ID, cb)
 
-				// 4.6.11.3. Multicast a Pre-prepare message to all
+				// 4.5.11.3. Multicast a Pre-prepare message to all
 				consensusMessage := &plum.XBFTMessage{
 					Phase:  plum.XBFTPhase_XBFTPrePrepare,
 					Round:  p.ConsensusRound,
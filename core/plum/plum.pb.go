@@ -25,6 +25,173 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type EventType int32
+
+const (
+	EventType_PhaseChanged         EventType = 0
+	EventType_BlockAppended        EventType = 1
+	EventType_RoundChangeTriggered EventType = 2
+	EventType_PrimaryChanged       EventType = 3
+	EventType_CommitteeSelected    EventType = 4
+	EventType_ReputationUpdated    EventType = 5
+)
+
+var EventType_name = map[int32]string{
+	0: "PhaseChanged",
+	1: "BlockAppended",
+	2: "RoundChangeTriggered",
+	3: "PrimaryChanged",
+	4: "CommitteeSelected",
+	5: "ReputationUpdated",
+}
+
+var EventType_value = map[string]int32{
+	"PhaseChanged":         0,
+	"BlockAppended":        1,
+	"RoundChangeTriggered": 2,
+	"PrimaryChanged":       3,
+	"CommitteeSelected":    4,
+	"ReputationUpdated":    5,
+}
+
+func (x EventType) String() string {
+	return proto.EnumName(EventType_name, int32(x))
+}
+
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{0}
+}
+
+type MessageType int32
+
+const (
+	MessageType_UnknownMessageType         MessageType = 0
+	MessageType_PBFTMessageType            MessageType = 1
+	MessageType_XBFTMessageType            MessageType = 2
+	MessageType_ValidatorUpdateMessageType MessageType = 3
+	MessageType_HelloMessageType           MessageType = 4
+)
+
+var MessageType_name = map[int32]string{
+	0: "UnknownMessageType",
+	1: "PBFTMessageType",
+	2: "XBFTMessageType",
+	3: "ValidatorUpdateMessageType",
+	4: "HelloMessageType",
+}
+
+var MessageType_value = map[string]int32{
+	"UnknownMessageType":         0,
+	"PBFTMessageType":            1,
+	"XBFTMessageType":            2,
+	"ValidatorUpdateMessageType": 3,
+	"HelloMessageType":           4,
+}
+
+func (x MessageType) String() string {
+	return proto.EnumName(MessageType_name, int32(x))
+}
+
+func (MessageType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{1}
+}
+
+// CommitteeSizeRule decides the least number of committee members to commit a block
+type CommitteeSizeRule int32
+
+const (
+	//the minimum size of committee is fixed
+	CommitteeSizeRule_FixedCommitteeSize CommitteeSizeRule = 0
+	//the minimum size of committee is the one to tolerate faulty members of the expected committee, but not less than the fixed one
+	CommitteeSizeRule_ExpectedCommitteeSize CommitteeSizeRule = 1
+)
+
+var CommitteeSizeRule_name = map[int32]string{
+	0: "FixedCommitteeSize",
+	1: "ExpectedCommitteeSize",
+}
+
+var CommitteeSizeRule_value = map[string]int32{
+	"FixedCommitteeSize":    0,
+	"ExpectedCommitteeSize": 1,
+}
+
+func (x CommitteeSizeRule) String() string {
+	return proto.EnumName(CommitteeSizeRule_name, int32(x))
+}
+
+func (CommitteeSizeRule) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{2}
+}
+
+type ReputationEventType int32
+
+const (
+	ReputationEventType_ReputationIncrease ReputationEventType = 0
+	ReputationEventType_ReputationDecrease ReputationEventType = 1
+	ReputationEventType_ReputationJoin     ReputationEventType = 2
+	ReputationEventType_ReputationLeave    ReputationEventType = 3
+	ReputationEventType_ReputationDecay    ReputationEventType = 4
+	ReputationEventType_ReputationExpire   ReputationEventType = 5
+	ReputationEventType_ReputationSlash    ReputationEventType = 6
+)
+
+var ReputationEventType_name = map[int32]string{
+	0: "ReputationIncrease",
+	1: "ReputationDecrease",
+	2: "ReputationJoin",
+	3: "ReputationLeave",
+	4: "ReputationDecay",
+	5: "ReputationExpire",
+	6: "ReputationSlash",
+}
+
+var ReputationEventType_value = map[string]int32{
+	"ReputationIncrease": 0,
+	"ReputationDecrease": 1,
+	"ReputationJoin":     2,
+	"ReputationLeave":    3,
+	"ReputationDecay":    4,
+	"ReputationExpire":   5,
+	"ReputationSlash":    6,
+}
+
+func (x ReputationEventType) String() string {
+	return proto.EnumName(ReputationEventType_name, int32(x))
+}
+
+func (ReputationEventType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{3}
+}
+
+type ValidatorUpdateType int32
+
+const (
+	ValidatorUpdateType_ValidatorJoin    ValidatorUpdateType = 0
+	ValidatorUpdateType_ValidatorLeave   ValidatorUpdateType = 1
+	ValidatorUpdateType_ValidatorReplace ValidatorUpdateType = 2
+)
+
+var ValidatorUpdateType_name = map[int32]string{
+	0: "ValidatorJoin",
+	1: "ValidatorLeave",
+	2: "ValidatorReplace",
+}
+
+var ValidatorUpdateType_value = map[string]int32{
+	"ValidatorJoin":    0,
+	"ValidatorLeave":   1,
+	"ValidatorReplace": 2,
+}
+
+func (x ValidatorUpdateType) String() string {
+	return proto.EnumName(ValidatorUpdateType_name, int32(x))
+}
+
+func (ValidatorUpdateType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{4}
+}
+
 type PBFTPhase int32
 
 const (
@@ -56,7 +223,7 @@ func (x PBFTPhase) String() string {
 }
 
 func (PBFTPhase) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{0}
+	return fileDescriptor_6954aaea537d5982, []int{5}
 }
 
 type XBFTPhase int32
@@ -93,7 +260,7 @@ func (x XBFTPhase) String() string {
 }
 
 func (XBFTPhase) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{1}
+	return fileDescriptor_6954aaea537d5982, []int{6}
 }
 
 type ConsensusState int32
@@ -127,7 +294,7 @@ func (x ConsensusState) String() string {
 }
 
 func (ConsensusState) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{2}
+	return fileDescriptor_6954aaea537d5982, []int{7}
 }
 
 type ResponseStatus int32
@@ -152,7 +319,7 @@ func (x ResponseStatus) String() string {
 }
 
 func (ResponseStatus) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{3}
+	return fileDescriptor_6954aaea537d5982, []int{8}
 }
 
 type ConsensusValidationCode int32
@@ -186,7 +353,7 @@ func (x ConsensusValidationCode) String() string {
 }
 
 func (ConsensusValidationCode) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{4}
+	return fileDescriptor_6954aaea537d5982, []int{9}
 }
 
 type ConsensusRole int32
@@ -214,7 +381,7 @@ func (x ConsensusRole) String() string {
 }
 
 func (ConsensusRole) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{5}
+	return fileDescriptor_6954aaea537d5982, []int{10}
 }
 
 type Ping struct {
@@ -295,6 +462,121 @@ func (m *Pong) GetMessage() string {
 	return ""
 }
 
+// Hello introduces a peer to another on start up, with what they should agree on to run consensus together
+type Hello struct {
+	Id              uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ipv4            string `protobuf:"bytes,2,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Port            string `protobuf:"bytes,3,opt,name=port,proto3" json:"port,omitempty"`
+	PublicKey       []byte `protobuf:"bytes,4,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+	ProtocolVersion uint32 `protobuf:"varint,5,opt,name=protocolVersion,proto3" json:"protocolVersion,omitempty"`
+	ChainId         string `protobuf:"bytes,6,opt,name=chainId,proto3" json:"chainId,omitempty"`
+	Height          uint64 `protobuf:"varint,7,opt,name=height,proto3" json:"height,omitempty"`
+	//ready is true once the peer runs consensus
+	Ready bool `protobuf:"varint,8,opt,name=ready,proto3" json:"ready,omitempty"`
+	//nonce increases on every hello of the peer, so that a hello taken once is not accepted again
+	Nonce uint64 `protobuf:"varint,9,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	//signature of the hello without it by the key of the peer
+	Signature            []byte   `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Hello) Reset()         { *m = Hello{} }
+func (m *Hello) String() string { return proto.CompactTextString(m) }
+func (*Hello) ProtoMessage()    {}
+func (*Hello) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{2}
+}
+
+func (m *Hello) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Hello.Unmarshal(m, b)
+}
+func (m *Hello) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Hello.Marshal(b, m, deterministic)
+}
+func (m *Hello) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Hello.Merge(m, src)
+}
+func (m *Hello) XXX_Size() int {
+	return xxx_messageInfo_Hello.Size(m)
+}
+func (m *Hello) XXX_DiscardUnknown() {
+	xxx_messageInfo_Hello.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Hello proto.InternalMessageInfo
+
+func (m *Hello) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Hello) GetIpv4() string {
+	if m != nil {
+		return m.Ipv4
+	}
+	return ""
+}
+
+func (m *Hello) GetPort() string {
+	if m != nil {
+		return m.Port
+	}
+	return ""
+}
+
+func (m *Hello) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *Hello) GetProtocolVersion() uint32 {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *Hello) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+func (m *Hello) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Hello) GetReady() bool {
+	if m != nil {
+		return m.Ready
+	}
+	return false
+}
+
+func (m *Hello) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *Hello) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
 type PublicKey struct {
 	Id                   uint32   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	Ipv4                 string   `protobuf:"bytes,2,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
@@ -309,7 +591,7 @@ func (m *PublicKey) Reset()         { *m = PublicKey{} }
 func (m *PublicKey) String() string { return proto.CompactTextString(m) }
 func (*PublicKey) ProtoMessage()    {}
 func (*PublicKey) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{2}
+	return fileDescriptor_6954aaea537d5982, []int{3}
 }
 
 func (m *PublicKey) XXX_Unmarshal(b []byte) error {
@@ -341,175 +623,1774 @@ func (m *PublicKey) GetIpv4() string {
 	if m != nil {
 		return m.Ipv4
 	}
-	return ""
+	return ""
+}
+
+func (m *PublicKey) GetPort() string {
+	if m != nil {
+		return m.Port
+	}
+	return ""
+}
+
+func (m *PublicKey) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+type PeerState struct {
+	Id                     uint32          `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ipv4                   string          `protobuf:"bytes,2,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Port                   string          `protobuf:"bytes,3,opt,name=port,proto3" json:"port,omitempty"`
+	Role                   ConsensusRole   `protobuf:"varint,4,opt,name=role,proto3,enum=plum.ConsensusRole" json:"role,omitempty"`
+	ConsensusRound         uint64          `protobuf:"varint,5,opt,name=consensusRound,proto3" json:"consensusRound,omitempty"`
+	CurrentPrimary         uint32          `protobuf:"varint,6,opt,name=currentPrimary,proto3" json:"currentPrimary,omitempty"`
+	ConsensusPhase         PBFTPhase       `protobuf:"varint,7,opt,name=consensusPhase,proto3,enum=plum.PBFTPhase" json:"consensusPhase,omitempty"`
+	Vote                   map[int32]int32 `protobuf:"bytes,8,rep,name=vote,proto3" json:"vote,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	ConsensusState         ConsensusState  `protobuf:"varint,9,opt,name=consensusState,proto3,enum=plum.ConsensusState" json:"consensusState,omitempty"`
+	BlockHeight            uint64          `protobuf:"varint,10,opt,name=blockHeight,proto3" json:"blockHeight,omitempty"`
+	QueueLength            uint64          `protobuf:"varint,11,opt,name=queueLength,proto3" json:"queueLength,omitempty"`
+	HeapLength             int64           `protobuf:"varint,12,opt,name=heapLength,proto3" json:"heapLength,omitempty"`
+	Reputation             float64         `protobuf:"fixed64,13,opt,name=reputation,proto3" json:"reputation,omitempty"`
+	SelectedCount          uint64          `protobuf:"varint,14,opt,name=selectedCount,proto3" json:"selectedCount,omitempty"`
+	TentativeSelectedCount uint64          `protobuf:"varint,15,opt,name=tentativeSelectedCount,proto3" json:"tentativeSelectedCount,omitempty"`
+	//consensusPhase and vote are of PBFT, the fields below are of XBFT. currentPrimary is the primary of either
+	XbftPhase        XBFTPhase           `protobuf:"varint,16,opt,name=xbftPhase,proto3,enum=plum.XBFTPhase" json:"xbftPhase,omitempty"`
+	XbftPrimary      uint32              `protobuf:"varint,17,opt,name=xbftPrimary,proto3" json:"xbftPrimary,omitempty"`
+	CommitteeMembers []*CommitteeMembers `protobuf:"bytes,18,rep,name=committeeMembers,proto3" json:"committeeMembers,omitempty"`
+	//number of the messages of each XBFTPhase collected for the block of the primary, against the thresholds to certify it
+	Certificates         map[int32]int32    `protobuf:"bytes,19,rep,name=certificates,proto3" json:"certificates,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Thresholds           map[int32]int32    `protobuf:"bytes,20,rep,name=thresholds,proto3" json:"thresholds,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	ReputationBook       map[uint32]float64 `protobuf:"bytes,21,rep,name=reputationBook,proto3" json:"reputationBook,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+	TotalReputation      float64            `protobuf:"fixed64,22,opt,name=totalReputation,proto3" json:"totalReputation,omitempty"`
+	ValidatorNonce       uint64             `protobuf:"varint,23,opt,name=validatorNonce,proto3" json:"validatorNonce,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *PeerState) Reset()         { *m = PeerState{} }
+func (m *PeerState) String() string { return proto.CompactTextString(m) }
+func (*PeerState) ProtoMessage()    {}
+func (*PeerState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{4}
+}
+
+func (m *PeerState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PeerState.Unmarshal(m, b)
+}
+func (m *PeerState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PeerState.Marshal(b, m, deterministic)
+}
+func (m *PeerState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PeerState.Merge(m, src)
+}
+func (m *PeerState) XXX_Size() int {
+	return xxx_messageInfo_PeerState.Size(m)
+}
+func (m *PeerState) XXX_DiscardUnknown() {
+	xxx_messageInfo_PeerState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PeerState proto.InternalMessageInfo
+
+func (m *PeerState) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *PeerState) GetIpv4() string {
+	if m != nil {
+		return m.Ipv4
+	}
+	return ""
+}
+
+func (m *PeerState) GetPort() string {
+	if m != nil {
+		return m.Port
+	}
+	return ""
+}
+
+func (m *PeerState) GetRole() ConsensusRole {
+	if m != nil {
+		return m.Role
+	}
+	return ConsensusRole_Primary
+}
+
+func (m *PeerState) GetConsensusRound() uint64 {
+	if m != nil {
+		return m.ConsensusRound
+	}
+	return 0
+}
+
+func (m *PeerState) GetCurrentPrimary() uint32 {
+	if m != nil {
+		return m.CurrentPrimary
+	}
+	return 0
+}
+
+func (m *PeerState) GetConsensusPhase() PBFTPhase {
+	if m != nil {
+		return m.ConsensusPhase
+	}
+	return PBFTPhase_PBFTRoundChange
+}
+
+func (m *PeerState) GetVote() map[int32]int32 {
+	if m != nil {
+		return m.Vote
+	}
+	return nil
+}
+
+func (m *PeerState) GetConsensusState() ConsensusState {
+	if m != nil {
+		return m.ConsensusState
+	}
+	return ConsensusState_Idle
+}
+
+func (m *PeerState) GetBlockHeight() uint64 {
+	if m != nil {
+		return m.BlockHeight
+	}
+	return 0
+}
+
+func (m *PeerState) GetQueueLength() uint64 {
+	if m != nil {
+		return m.QueueLength
+	}
+	return 0
+}
+
+func (m *PeerState) GetHeapLength() int64 {
+	if m != nil {
+		return m.HeapLength
+	}
+	return 0
+}
+
+func (m *PeerState) GetReputation() float64 {
+	if m != nil {
+		return m.Reputation
+	}
+	return 0
+}
+
+func (m *PeerState) GetSelectedCount() uint64 {
+	if m != nil {
+		return m.SelectedCount
+	}
+	return 0
+}
+
+func (m *PeerState) GetTentativeSelectedCount() uint64 {
+	if m != nil {
+		return m.TentativeSelectedCount
+	}
+	return 0
+}
+
+func (m *PeerState) GetXbftPhase() XBFTPhase {
+	if m != nil {
+		return m.XbftPhase
+	}
+	return XBFTPhase_XBFTRoundChange
+}
+
+func (m *PeerState) GetXbftPrimary() uint32 {
+	if m != nil {
+		return m.XbftPrimary
+	}
+	return 0
+}
+
+func (m *PeerState) GetCommitteeMembers() []*CommitteeMembers {
+	if m != nil {
+		return m.CommitteeMembers
+	}
+	return nil
+}
+
+func (m *PeerState) GetCertificates() map[int32]int32 {
+	if m != nil {
+		return m.Certificates
+	}
+	return nil
+}
+
+func (m *PeerState) GetThresholds() map[int32]int32 {
+	if m != nil {
+		return m.Thresholds
+	}
+	return nil
+}
+
+func (m *PeerState) GetReputationBook() map[uint32]float64 {
+	if m != nil {
+		return m.ReputationBook
+	}
+	return nil
+}
+
+func (m *PeerState) GetTotalReputation() float64 {
+	if m != nil {
+		return m.TotalReputation
+	}
+	return 0
+}
+
+func (m *PeerState) GetValidatorNonce() uint64 {
+	if m != nil {
+		return m.ValidatorNonce
+	}
+	return 0
+}
+
+// BlockRange is an inclusive range of block heights to request for state sync
+type BlockRange struct {
+	From                 uint64   `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   uint64   `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BlockRange) Reset()         { *m = BlockRange{} }
+func (m *BlockRange) String() string { return proto.CompactTextString(m) }
+func (*BlockRange) ProtoMessage()    {}
+func (*BlockRange) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{5}
+}
+
+func (m *BlockRange) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockRange.Unmarshal(m, b)
+}
+func (m *BlockRange) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockRange.Marshal(b, m, deterministic)
+}
+func (m *BlockRange) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockRange.Merge(m, src)
+}
+func (m *BlockRange) XXX_Size() int {
+	return xxx_messageInfo_BlockRange.Size(m)
+}
+func (m *BlockRange) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockRange.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockRange proto.InternalMessageInfo
+
+func (m *BlockRange) GetFrom() uint64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *BlockRange) GetTo() uint64 {
+	if m != nil {
+		return m.To
+	}
+	return 0
+}
+
+// SyncBlock is a block with the certificate which has finalized it
+type SyncBlock struct {
+	Block                *Block             `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Certificate          *CommitCertificate `protobuf:"bytes,2,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *SyncBlock) Reset()         { *m = SyncBlock{} }
+func (m *SyncBlock) String() string { return proto.CompactTextString(m) }
+func (*SyncBlock) ProtoMessage()    {}
+func (*SyncBlock) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{6}
+}
+
+func (m *SyncBlock) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncBlock.Unmarshal(m, b)
+}
+func (m *SyncBlock) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncBlock.Marshal(b, m, deterministic)
+}
+func (m *SyncBlock) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncBlock.Merge(m, src)
+}
+func (m *SyncBlock) XXX_Size() int {
+	return xxx_messageInfo_SyncBlock.Size(m)
+}
+func (m *SyncBlock) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncBlock.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncBlock proto.InternalMessageInfo
+
+func (m *SyncBlock) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *SyncBlock) GetCertificate() *CommitCertificate {
+	if m != nil {
+		return m.Certificate
+	}
+	return nil
+}
+
+// Snapshot is the state of a peer at the height, so that it can be restored without the blocks before it
+type Snapshot struct {
+	Height               uint64             `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Header               *Header            `protobuf:"bytes,2,opt,name=header,proto3" json:"header,omitempty"`
+	AppState             *AppState          `protobuf:"bytes,3,opt,name=appState,proto3" json:"appState,omitempty"`
+	ReputationBook       map[uint32]float64 `protobuf:"bytes,4,rep,name=reputationBook,proto3" json:"reputationBook,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"fixed64,2,opt,name=value,proto3"`
+	Validators           []*Validator       `protobuf:"bytes,5,rep,name=validators,proto3" json:"validators,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *Snapshot) Reset()         { *m = Snapshot{} }
+func (m *Snapshot) String() string { return proto.CompactTextString(m) }
+func (*Snapshot) ProtoMessage()    {}
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{7}
+}
+
+func (m *Snapshot) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Snapshot.Unmarshal(m, b)
+}
+func (m *Snapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Snapshot.Marshal(b, m, deterministic)
+}
+func (m *Snapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Snapshot.Merge(m, src)
+}
+func (m *Snapshot) XXX_Size() int {
+	return xxx_messageInfo_Snapshot.Size(m)
+}
+func (m *Snapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_Snapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Snapshot proto.InternalMessageInfo
+
+func (m *Snapshot) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Snapshot) GetHeader() *Header {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *Snapshot) GetAppState() *AppState {
+	if m != nil {
+		return m.AppState
+	}
+	return nil
+}
+
+func (m *Snapshot) GetReputationBook() map[uint32]float64 {
+	if m != nil {
+		return m.ReputationBook
+	}
+	return nil
+}
+
+func (m *Snapshot) GetValidators() []*Validator {
+	if m != nil {
+		return m.Validators
+	}
+	return nil
+}
+
+// ReputationRecord is the change of reputation made by a committed block.
+// the reputation book at a height is derivable from the records on top of a snapshot or the genesis
+type ReputationRecord struct {
+	Height uint64             `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Events []*ReputationEvent `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	//number of the validator updates applied on the chain up to the block, which the next update should be signed with
+	ValidatorNonce uint64 `protobuf:"varint,3,opt,name=validatorNonce,proto3" json:"validatorNonce,omitempty"`
+	//validator updates applied by the block, the ones skipped as invalid are not in it
+	ValidatorUpdates     []*ValidatorUpdate `protobuf:"bytes,4,rep,name=validatorUpdates,proto3" json:"validatorUpdates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ReputationRecord) Reset()         { *m = ReputationRecord{} }
+func (m *ReputationRecord) String() string { return proto.CompactTextString(m) }
+func (*ReputationRecord) ProtoMessage()    {}
+func (*ReputationRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{8}
+}
+
+func (m *ReputationRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReputationRecord.Unmarshal(m, b)
+}
+func (m *ReputationRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReputationRecord.Marshal(b, m, deterministic)
+}
+func (m *ReputationRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReputationRecord.Merge(m, src)
+}
+func (m *ReputationRecord) XXX_Size() int {
+	return xxx_messageInfo_ReputationRecord.Size(m)
+}
+func (m *ReputationRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReputationRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReputationRecord proto.InternalMessageInfo
+
+func (m *ReputationRecord) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *ReputationRecord) GetEvents() []*ReputationEvent {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+func (m *ReputationRecord) GetValidatorNonce() uint64 {
+	if m != nil {
+		return m.ValidatorNonce
+	}
+	return 0
+}
+
+func (m *ReputationRecord) GetValidatorUpdates() []*ValidatorUpdate {
+	if m != nil {
+		return m.ValidatorUpdates
+	}
+	return nil
+}
+
+// ReputationEvent is a change of reputation of a peer, made by the block of the height committed in the round
+type ReputationEvent struct {
+	PeerId uint32              `protobuf:"varint,1,opt,name=peerId,proto3" json:"peerId,omitempty"`
+	Type   ReputationEventType `protobuf:"varint,2,opt,name=type,proto3,enum=plum.ReputationEventType" json:"type,omitempty"`
+	Before float64             `protobuf:"fixed64,3,opt,name=before,proto3" json:"before,omitempty"`
+	After  float64             `protobuf:"fixed64,4,opt,name=after,proto3" json:"after,omitempty"`
+	Height uint64              `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	Round  uint64              `protobuf:"varint,6,opt,name=round,proto3" json:"round,omitempty"`
+	Delta  float64             `protobuf:"fixed64,7,opt,name=delta,proto3" json:"delta,omitempty"`
+	Reason string              `protobuf:"bytes,8,opt,name=reason,proto3" json:"reason,omitempty"`
+	//slot of the equivocation penalized by a slash, so that it is not penalized again after restart even if the block body is pruned
+	Slot                 *SlashedSlot `protobuf:"bytes,9,opt,name=slot,proto3" json:"slot,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *ReputationEvent) Reset()         { *m = ReputationEvent{} }
+func (m *ReputationEvent) String() string { return proto.CompactTextString(m) }
+func (*ReputationEvent) ProtoMessage()    {}
+func (*ReputationEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{9}
+}
+
+func (m *ReputationEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReputationEvent.Unmarshal(m, b)
+}
+func (m *ReputationEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReputationEvent.Marshal(b, m, deterministic)
+}
+func (m *ReputationEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReputationEvent.Merge(m, src)
+}
+func (m *ReputationEvent) XXX_Size() int {
+	return xxx_messageInfo_ReputationEvent.Size(m)
+}
+func (m *ReputationEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReputationEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReputationEvent proto.InternalMessageInfo
+
+func (m *ReputationEvent) GetPeerId() uint32 {
+	if m != nil {
+		return m.PeerId
+	}
+	return 0
+}
+
+func (m *ReputationEvent) GetType() ReputationEventType {
+	if m != nil {
+		return m.Type
+	}
+	return ReputationEventType_ReputationIncrease
+}
+
+func (m *ReputationEvent) GetBefore() float64 {
+	if m != nil {
+		return m.Before
+	}
+	return 0
+}
+
+func (m *ReputationEvent) GetAfter() float64 {
+	if m != nil {
+		return m.After
+	}
+	return 0
+}
+
+func (m *ReputationEvent) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *ReputationEvent) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *ReputationEvent) GetDelta() float64 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *ReputationEvent) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *ReputationEvent) GetSlot() *SlashedSlot {
+	if m != nil {
+		return m.Slot
+	}
+	return nil
+}
+
+// SlashedSlot is what a validator has signed two digests for
+type SlashedSlot struct {
+	Sender               uint32   `protobuf:"varint,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Height               uint64   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Round                uint64   `protobuf:"varint,3,opt,name=round,proto3" json:"round,omitempty"`
+	Phase                string   `protobuf:"bytes,4,opt,name=phase,proto3" json:"phase,omitempty"`
+	Primary              uint32   `protobuf:"varint,5,opt,name=primary,proto3" json:"primary,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SlashedSlot) Reset()         { *m = SlashedSlot{} }
+func (m *SlashedSlot) String() string { return proto.CompactTextString(m) }
+func (*SlashedSlot) ProtoMessage()    {}
+func (*SlashedSlot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{10}
+}
+
+func (m *SlashedSlot) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SlashedSlot.Unmarshal(m, b)
+}
+func (m *SlashedSlot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SlashedSlot.Marshal(b, m, deterministic)
+}
+func (m *SlashedSlot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SlashedSlot.Merge(m, src)
+}
+func (m *SlashedSlot) XXX_Size() int {
+	return xxx_messageInfo_SlashedSlot.Size(m)
+}
+func (m *SlashedSlot) XXX_DiscardUnknown() {
+	xxx_messageInfo_SlashedSlot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SlashedSlot proto.InternalMessageInfo
+
+func (m *SlashedSlot) GetSender() uint32 {
+	if m != nil {
+		return m.Sender
+	}
+	return 0
+}
+
+func (m *SlashedSlot) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *SlashedSlot) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *SlashedSlot) GetPhase() string {
+	if m != nil {
+		return m.Phase
+	}
+	return ""
+}
+
+func (m *SlashedSlot) GetPrimary() uint32 {
+	if m != nil {
+		return m.Primary
+	}
+	return 0
+}
+
+// ReputationHistoryRequest asks the changes of reputation of a peer in an inclusive range of heights. to of 0 means the current height
+type ReputationHistoryRequest struct {
+	PeerId               uint32   `protobuf:"varint,1,opt,name=peerId,proto3" json:"peerId,omitempty"`
+	FromHeight           uint64   `protobuf:"varint,2,opt,name=fromHeight,proto3" json:"fromHeight,omitempty"`
+	ToHeight             uint64   `protobuf:"varint,3,opt,name=toHeight,proto3" json:"toHeight,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReputationHistoryRequest) Reset()         { *m = ReputationHistoryRequest{} }
+func (m *ReputationHistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*ReputationHistoryRequest) ProtoMessage()    {}
+func (*ReputationHistoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{11}
+}
+
+func (m *ReputationHistoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReputationHistoryRequest.Unmarshal(m, b)
+}
+func (m *ReputationHistoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReputationHistoryRequest.Marshal(b, m, deterministic)
+}
+func (m *ReputationHistoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReputationHistoryRequest.Merge(m, src)
+}
+func (m *ReputationHistoryRequest) XXX_Size() int {
+	return xxx_messageInfo_ReputationHistoryRequest.Size(m)
+}
+func (m *ReputationHistoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReputationHistoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReputationHistoryRequest proto.InternalMessageInfo
+
+func (m *ReputationHistoryRequest) GetPeerId() uint32 {
+	if m != nil {
+		return m.PeerId
+	}
+	return 0
+}
+
+func (m *ReputationHistoryRequest) GetFromHeight() uint64 {
+	if m != nil {
+		return m.FromHeight
+	}
+	return 0
+}
+
+func (m *ReputationHistoryRequest) GetToHeight() uint64 {
+	if m != nil {
+		return m.ToHeight
+	}
+	return 0
+}
+
+// ReputationHistory is the changes of reputation of a peer in the order they are made
+type ReputationHistory struct {
+	PeerId               uint32             `protobuf:"varint,1,opt,name=peerId,proto3" json:"peerId,omitempty"`
+	Events               []*ReputationEvent `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ReputationHistory) Reset()         { *m = ReputationHistory{} }
+func (m *ReputationHistory) String() string { return proto.CompactTextString(m) }
+func (*ReputationHistory) ProtoMessage()    {}
+func (*ReputationHistory) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{12}
+}
+
+func (m *ReputationHistory) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReputationHistory.Unmarshal(m, b)
+}
+func (m *ReputationHistory) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReputationHistory.Marshal(b, m, deterministic)
+}
+func (m *ReputationHistory) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReputationHistory.Merge(m, src)
+}
+func (m *ReputationHistory) XXX_Size() int {
+	return xxx_messageInfo_ReputationHistory.Size(m)
+}
+func (m *ReputationHistory) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReputationHistory.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReputationHistory proto.InternalMessageInfo
+
+func (m *ReputationHistory) GetPeerId() uint32 {
+	if m != nil {
+		return m.PeerId
+	}
+	return 0
+}
+
+func (m *ReputationHistory) GetEvents() []*ReputationEvent {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+// EventFilter selects the types of events to subscribe. empty types mean all of them
+type EventFilter struct {
+	Types                []EventType `protobuf:"varint,1,rep,packed,name=types,proto3,enum=plum.EventType" json:"types,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *EventFilter) Reset()         { *m = EventFilter{} }
+func (m *EventFilter) String() string { return proto.CompactTextString(m) }
+func (*EventFilter) ProtoMessage()    {}
+func (*EventFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{13}
+}
+
+func (m *EventFilter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EventFilter.Unmarshal(m, b)
+}
+func (m *EventFilter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EventFilter.Marshal(b, m, deterministic)
+}
+func (m *EventFilter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EventFilter.Merge(m, src)
+}
+func (m *EventFilter) XXX_Size() int {
+	return xxx_messageInfo_EventFilter.Size(m)
+}
+func (m *EventFilter) XXX_DiscardUnknown() {
+	xxx_messageInfo_EventFilter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EventFilter proto.InternalMessageInfo
+
+func (m *EventFilter) GetTypes() []EventType {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
+// LoggedMessage is a consensus message kept in the message log of a peer, with the time it has been handled
+type LoggedMessage struct {
+	Timestamp *timestamp.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Types that are valid to be assigned to Request:
+	//	*LoggedMessage_Pbft
+	//	*LoggedMessage_Xbft
+	Request              isLoggedMessage_Request `protobuf_oneof:"request"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *LoggedMessage) Reset()         { *m = LoggedMessage{} }
+func (m *LoggedMessage) String() string { return proto.CompactTextString(m) }
+func (*LoggedMessage) ProtoMessage()    {}
+func (*LoggedMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{14}
+}
+
+func (m *LoggedMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoggedMessage.Unmarshal(m, b)
+}
+func (m *LoggedMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoggedMessage.Marshal(b, m, deterministic)
+}
+func (m *LoggedMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoggedMessage.Merge(m, src)
+}
+func (m *LoggedMessage) XXX_Size() int {
+	return xxx_messageInfo_LoggedMessage.Size(m)
+}
+func (m *LoggedMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoggedMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoggedMessage proto.InternalMessageInfo
+
+func (m *LoggedMessage) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+type isLoggedMessage_Request interface {
+	isLoggedMessage_Request()
+}
+
+type LoggedMessage_Pbft struct {
+	Pbft *PBFTRequest `protobuf:"bytes,2,opt,name=pbft,proto3,oneof"`
+}
+
+type LoggedMessage_Xbft struct {
+	Xbft *XBFTRequest `protobuf:"bytes,3,opt,name=xbft,proto3,oneof"`
+}
+
+func (*LoggedMessage_Pbft) isLoggedMessage_Request() {}
+
+func (*LoggedMessage_Xbft) isLoggedMessage_Request() {}
+
+func (m *LoggedMessage) GetRequest() isLoggedMessage_Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *LoggedMessage) GetPbft() *PBFTRequest {
+	if x, ok := m.GetRequest().(*LoggedMessage_Pbft); ok {
+		return x.Pbft
+	}
+	return nil
+}
+
+func (m *LoggedMessage) GetXbft() *XBFTRequest {
+	if x, ok := m.GetRequest().(*LoggedMessage_Xbft); ok {
+		return x.Xbft
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*LoggedMessage) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*LoggedMessage_Pbft)(nil),
+		(*LoggedMessage_Xbft)(nil),
+	}
+}
+
+// MessageLogRequest asks the messages of the round in the message log.
+// phases are the names of the phases such as XBFTPrepare, empty phases or senders mean all of them
+type MessageLogRequest struct {
+	Round                uint64   `protobuf:"varint,1,opt,name=round,proto3" json:"round,omitempty"`
+	Phases               []string `protobuf:"bytes,2,rep,name=phases,proto3" json:"phases,omitempty"`
+	Senders              []uint32 `protobuf:"varint,3,rep,packed,name=senders,proto3" json:"senders,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MessageLogRequest) Reset()         { *m = MessageLogRequest{} }
+func (m *MessageLogRequest) String() string { return proto.CompactTextString(m) }
+func (*MessageLogRequest) ProtoMessage()    {}
+func (*MessageLogRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{15}
+}
+
+func (m *MessageLogRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MessageLogRequest.Unmarshal(m, b)
+}
+func (m *MessageLogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MessageLogRequest.Marshal(b, m, deterministic)
+}
+func (m *MessageLogRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MessageLogRequest.Merge(m, src)
+}
+func (m *MessageLogRequest) XXX_Size() int {
+	return xxx_messageInfo_MessageLogRequest.Size(m)
+}
+func (m *MessageLogRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MessageLogRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MessageLogRequest proto.InternalMessageInfo
+
+func (m *MessageLogRequest) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *MessageLogRequest) GetPhases() []string {
+	if m != nil {
+		return m.Phases
+	}
+	return nil
+}
+
+func (m *MessageLogRequest) GetSenders() []uint32 {
+	if m != nil {
+		return m.Senders
+	}
+	return nil
+}
+
+type MessageLogDump struct {
+	Round                uint64           `protobuf:"varint,1,opt,name=round,proto3" json:"round,omitempty"`
+	Messages             []*LoggedMessage `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *MessageLogDump) Reset()         { *m = MessageLogDump{} }
+func (m *MessageLogDump) String() string { return proto.CompactTextString(m) }
+func (*MessageLogDump) ProtoMessage()    {}
+func (*MessageLogDump) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{16}
+}
+
+func (m *MessageLogDump) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MessageLogDump.Unmarshal(m, b)
+}
+func (m *MessageLogDump) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MessageLogDump.Marshal(b, m, deterministic)
+}
+func (m *MessageLogDump) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MessageLogDump.Merge(m, src)
+}
+func (m *MessageLogDump) XXX_Size() int {
+	return xxx_messageInfo_MessageLogDump.Size(m)
+}
+func (m *MessageLogDump) XXX_DiscardUnknown() {
+	xxx_messageInfo_MessageLogDump.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MessageLogDump proto.InternalMessageInfo
+
+func (m *MessageLogDump) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *MessageLogDump) GetMessages() []*LoggedMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+// Event is what has happened in consensus of a peer, pushed to the subscribers as it happens.
+// seq increases by one for every event of the peer, so that a subscriber finds the events dropped for being slow
+type Event struct {
+	Seq                  uint64               `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Type                 EventType            `protobuf:"varint,2,opt,name=type,proto3,enum=plum.EventType" json:"type,omitempty"`
+	Timestamp            *timestamp.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	PeerId               uint32               `protobuf:"varint,4,opt,name=peerId,proto3" json:"peerId,omitempty"`
+	Height               uint64               `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	Round                uint64               `protobuf:"varint,6,opt,name=round,proto3" json:"round,omitempty"`
+	Phase                string               `protobuf:"bytes,7,opt,name=phase,proto3" json:"phase,omitempty"`
+	PrimaryId            uint32               `protobuf:"varint,8,opt,name=primaryId,proto3" json:"primaryId,omitempty"`
+	BlockDigest          []byte               `protobuf:"bytes,9,opt,name=blockDigest,proto3" json:"blockDigest,omitempty"`
+	CommitteeMembers     []*CommitteeMembers  `protobuf:"bytes,10,rep,name=committeeMembers,proto3" json:"committeeMembers,omitempty"`
+	ReputationEvents     []*ReputationEvent   `protobuf:"bytes,11,rep,name=reputationEvents,proto3" json:"reputationEvents,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+func (*Event) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{17}
+}
+
+func (m *Event) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Event.Unmarshal(m, b)
+}
+func (m *Event) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Event.Marshal(b, m, deterministic)
+}
+func (m *Event) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Event.Merge(m, src)
+}
+func (m *Event) XXX_Size() int {
+	return xxx_messageInfo_Event.Size(m)
+}
+func (m *Event) XXX_DiscardUnknown() {
+	xxx_messageInfo_Event.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Event proto.InternalMessageInfo
+
+func (m *Event) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *Event) GetType() EventType {
+	if m != nil {
+		return m.Type
+	}
+	return EventType_PhaseChanged
+}
+
+func (m *Event) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *Event) GetPeerId() uint32 {
+	if m != nil {
+		return m.PeerId
+	}
+	return 0
+}
+
+func (m *Event) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Event) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *Event) GetPhase() string {
+	if m != nil {
+		return m.Phase
+	}
+	return ""
+}
+
+func (m *Event) GetPrimaryId() uint32 {
+	if m != nil {
+		return m.PrimaryId
+	}
+	return 0
+}
+
+func (m *Event) GetBlockDigest() []byte {
+	if m != nil {
+		return m.BlockDigest
+	}
+	return nil
+}
+
+func (m *Event) GetCommitteeMembers() []*CommitteeMembers {
+	if m != nil {
+		return m.CommitteeMembers
+	}
+	return nil
+}
+
+func (m *Event) GetReputationEvents() []*ReputationEvent {
+	if m != nil {
+		return m.ReputationEvents
+	}
+	return nil
+}
+
+// LogLevel is the level of logs written by a peer such as debug, info, warn and error. empty level leaves it as it is
+type LogLevel struct {
+	Level                string   `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogLevel) Reset()         { *m = LogLevel{} }
+func (m *LogLevel) String() string { return proto.CompactTextString(m) }
+func (*LogLevel) ProtoMessage()    {}
+func (*LogLevel) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{18}
+}
+
+func (m *LogLevel) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogLevel.Unmarshal(m, b)
+}
+func (m *LogLevel) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogLevel.Marshal(b, m, deterministic)
+}
+func (m *LogLevel) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogLevel.Merge(m, src)
+}
+func (m *LogLevel) XXX_Size() int {
+	return xxx_messageInfo_LogLevel.Size(m)
+}
+func (m *LogLevel) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogLevel.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogLevel proto.InternalMessageInfo
+
+func (m *LogLevel) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+// AppState is the progress of consensus of a peer which is not written in blocks
+type AppState struct {
+	ConsensusRound       uint64   `protobuf:"varint,1,opt,name=consensusRound,proto3" json:"consensusRound,omitempty"`
+	SelectedCount        uint64   `protobuf:"varint,2,opt,name=selectedCount,proto3" json:"selectedCount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AppState) Reset()         { *m = AppState{} }
+func (m *AppState) String() string { return proto.CompactTextString(m) }
+func (*AppState) ProtoMessage()    {}
+func (*AppState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{19}
+}
+
+func (m *AppState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AppState.Unmarshal(m, b)
+}
+func (m *AppState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AppState.Marshal(b, m, deterministic)
+}
+func (m *AppState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AppState.Merge(m, src)
+}
+func (m *AppState) XXX_Size() int {
+	return xxx_messageInfo_AppState.Size(m)
+}
+func (m *AppState) XXX_DiscardUnknown() {
+	xxx_messageInfo_AppState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AppState proto.InternalMessageInfo
+
+func (m *AppState) GetConsensusRound() uint64 {
+	if m != nil {
+		return m.ConsensusRound
+	}
+	return 0
+}
+
+func (m *AppState) GetSelectedCount() uint64 {
+	if m != nil {
+		return m.SelectedCount
+	}
+	return 0
+}
+
+// GenesisConfig is the initial state of a chain. it is the only transaction of the genesis block, so that the merkle root covers it
+type GenesisConfig struct {
+	ChainId              string               `protobuf:"bytes,1,opt,name=chainId,proto3" json:"chainId,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Validators           []*Validator         `protobuf:"bytes,3,rep,name=validators,proto3" json:"validators,omitempty"`
+	ConsensusParams      *ConsensusParams     `protobuf:"bytes,4,opt,name=consensusParams,proto3" json:"consensusParams,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *GenesisConfig) Reset()         { *m = GenesisConfig{} }
+func (m *GenesisConfig) String() string { return proto.CompactTextString(m) }
+func (*GenesisConfig) ProtoMessage()    {}
+func (*GenesisConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{20}
+}
+
+func (m *GenesisConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GenesisConfig.Unmarshal(m, b)
+}
+func (m *GenesisConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GenesisConfig.Marshal(b, m, deterministic)
+}
+func (m *GenesisConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GenesisConfig.Merge(m, src)
+}
+func (m *GenesisConfig) XXX_Size() int {
+	return xxx_messageInfo_GenesisConfig.Size(m)
+}
+func (m *GenesisConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_GenesisConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GenesisConfig proto.InternalMessageInfo
+
+func (m *GenesisConfig) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+func (m *GenesisConfig) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *GenesisConfig) GetValidators() []*Validator {
+	if m != nil {
+		return m.Validators
+	}
+	return nil
+}
+
+func (m *GenesisConfig) GetConsensusParams() *ConsensusParams {
+	if m != nil {
+		return m.ConsensusParams
+	}
+	return nil
+}
+
+// Validator is a peer which participates in consensus from the genesis block
+type Validator struct {
+	Id                   uint32   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,2,opt,name=publicKey,proto3" json:"publicKey,omitempty"`
+	Reputation           float64  `protobuf:"fixed64,3,opt,name=reputation,proto3" json:"reputation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Validator) Reset()         { *m = Validator{} }
+func (m *Validator) String() string { return proto.CompactTextString(m) }
+func (*Validator) ProtoMessage()    {}
+func (*Validator) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{21}
+}
+
+func (m *Validator) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Validator.Unmarshal(m, b)
+}
+func (m *Validator) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Validator.Marshal(b, m, deterministic)
+}
+func (m *Validator) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Validator.Merge(m, src)
+}
+func (m *Validator) XXX_Size() int {
+	return xxx_messageInfo_Validator.Size(m)
+}
+func (m *Validator) XXX_DiscardUnknown() {
+	xxx_messageInfo_Validator.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Validator proto.InternalMessageInfo
+
+func (m *Validator) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Validator) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *Validator) GetReputation() float64 {
+	if m != nil {
+		return m.Reputation
+	}
+	return 0
+}
+
+// ValidatorUpdate changes the validator set. it is shipped as a transaction and takes effect from the height after the block including it
+type ValidatorUpdate struct {
+	Type ValidatorUpdateType `protobuf:"varint,1,opt,name=type,proto3,enum=plum.ValidatorUpdateType" json:"type,omitempty"`
+	//validator to join, or to take the place of the target on replace
+	Validator *Validator `protobuf:"bytes,2,opt,name=validator,proto3" json:"validator,omitempty"`
+	//validator to leave, or to be replaced
+	TargetId uint32 `protobuf:"varint,3,opt,name=targetId,proto3" json:"targetId,omitempty"`
+	//address of the joining validator
+	Ipv4          string `protobuf:"bytes,4,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
+	Port          string `protobuf:"bytes,5,opt,name=port,proto3" json:"port,omitempty"`
+	ContainerName string `protobuf:"bytes,6,opt,name=containerName,proto3" json:"containerName,omitempty"`
+	//number of the validator updates applied on the chain before this one. the update is applied only at this nonce, so never twice
+	Nonce                uint64   `protobuf:"varint,7,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ValidatorUpdate) Reset()         { *m = ValidatorUpdate{} }
+func (m *ValidatorUpdate) String() string { return proto.CompactTextString(m) }
+func (*ValidatorUpdate) ProtoMessage()    {}
+func (*ValidatorUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{22}
+}
+
+func (m *ValidatorUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidatorUpdate.Unmarshal(m, b)
+}
+func (m *ValidatorUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidatorUpdate.Marshal(b, m, deterministic)
+}
+func (m *ValidatorUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidatorUpdate.Merge(m, src)
+}
+func (m *ValidatorUpdate) XXX_Size() int {
+	return xxx_messageInfo_ValidatorUpdate.Size(m)
+}
+func (m *ValidatorUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidatorUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidatorUpdate proto.InternalMessageInfo
+
+func (m *ValidatorUpdate) GetType() ValidatorUpdateType {
+	if m != nil {
+		return m.Type
+	}
+	return ValidatorUpdateType_ValidatorJoin
+}
+
+func (m *ValidatorUpdate) GetValidator() *Validator {
+	if m != nil {
+		return m.Validator
+	}
+	return nil
+}
+
+func (m *ValidatorUpdate) GetTargetId() uint32 {
+	if m != nil {
+		return m.TargetId
+	}
+	return 0
+}
+
+func (m *ValidatorUpdate) GetIpv4() string {
+	if m != nil {
+		return m.Ipv4
+	}
+	return ""
+}
+
+func (m *ValidatorUpdate) GetPort() string {
+	if m != nil {
+		return m.Port
+	}
+	return ""
+}
+
+func (m *ValidatorUpdate) GetContainerName() string {
+	if m != nil {
+		return m.ContainerName
+	}
+	return ""
+}
+
+func (m *ValidatorUpdate) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+// SignedValidatorUpdate is a validator update with the signatures of the validators of the current set.
+// it is applied when more than 2/3 of the validators have signed it
+type SignedValidatorUpdate struct {
+	Update               *ValidatorUpdate      `protobuf:"bytes,1,opt,name=update,proto3" json:"update,omitempty"`
+	Signatures           []*ValidatorSignature `protobuf:"bytes,4,rep,name=signatures,proto3" json:"signatures,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *SignedValidatorUpdate) Reset()         { *m = SignedValidatorUpdate{} }
+func (m *SignedValidatorUpdate) String() string { return proto.CompactTextString(m) }
+func (*SignedValidatorUpdate) ProtoMessage()    {}
+func (*SignedValidatorUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{23}
+}
+
+func (m *SignedValidatorUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignedValidatorUpdate.Unmarshal(m, b)
+}
+func (m *SignedValidatorUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignedValidatorUpdate.Marshal(b, m, deterministic)
+}
+func (m *SignedValidatorUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignedValidatorUpdate.Merge(m, src)
+}
+func (m *SignedValidatorUpdate) XXX_Size() int {
+	return xxx_messageInfo_SignedValidatorUpdate.Size(m)
+}
+func (m *SignedValidatorUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignedValidatorUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignedValidatorUpdate proto.InternalMessageInfo
+
+func (m *SignedValidatorUpdate) GetUpdate() *ValidatorUpdate {
+	if m != nil {
+		return m.Update
+	}
+	return nil
+}
+
+func (m *SignedValidatorUpdate) GetSignatures() []*ValidatorSignature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
+type ValidatorSignature struct {
+	SignerId             uint32   `protobuf:"varint,1,opt,name=signerId,proto3" json:"signerId,omitempty"`
+	Signature            []byte   `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ValidatorSignature) Reset()         { *m = ValidatorSignature{} }
+func (m *ValidatorSignature) String() string { return proto.CompactTextString(m) }
+func (*ValidatorSignature) ProtoMessage()    {}
+func (*ValidatorSignature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{24}
+}
+
+func (m *ValidatorSignature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ValidatorSignature.Unmarshal(m, b)
+}
+func (m *ValidatorSignature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ValidatorSignature.Marshal(b, m, deterministic)
+}
+func (m *ValidatorSignature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ValidatorSignature.Merge(m, src)
+}
+func (m *ValidatorSignature) XXX_Size() int {
+	return xxx_messageInfo_ValidatorSignature.Size(m)
+}
+func (m *ValidatorSignature) XXX_DiscardUnknown() {
+	xxx_messageInfo_ValidatorSignature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ValidatorSignature proto.InternalMessageInfo
+
+func (m *ValidatorSignature) GetSignerId() uint32 {
+	if m != nil {
+		return m.SignerId
+	}
+	return 0
+}
+
+func (m *ValidatorSignature) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// Evidence proves that a validator has signed two messages of different digests for the same height, round and phase.
+// it holds the two signed messages of either consensus, without the blocks they carry
+type Evidence struct {
+	Pbft                 []*PBFTRequest `protobuf:"bytes,1,rep,name=pbft,proto3" json:"pbft,omitempty"`
+	Xbft                 []*XBFTRequest `protobuf:"bytes,2,rep,name=xbft,proto3" json:"xbft,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *Evidence) Reset()         { *m = Evidence{} }
+func (m *Evidence) String() string { return proto.CompactTextString(m) }
+func (*Evidence) ProtoMessage()    {}
+func (*Evidence) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{25}
+}
+
+func (m *Evidence) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Evidence.Unmarshal(m, b)
+}
+func (m *Evidence) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Evidence.Marshal(b, m, deterministic)
+}
+func (m *Evidence) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Evidence.Merge(m, src)
+}
+func (m *Evidence) XXX_Size() int {
+	return xxx_messageInfo_Evidence.Size(m)
+}
+func (m *Evidence) XXX_DiscardUnknown() {
+	xxx_messageInfo_Evidence.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Evidence proto.InternalMessageInfo
+
+func (m *Evidence) GetPbft() []*PBFTRequest {
+	if m != nil {
+		return m.Pbft
+	}
+	return nil
+}
+
+func (m *Evidence) GetXbft() []*XBFTRequest {
+	if m != nil {
+		return m.Xbft
+	}
+	return nil
+}
+
+// ConsensusParams are the parameters of consensus every peer of the chain should agree on
+type ConsensusParams struct {
+	MerkleTreeVersion    uint32                  `protobuf:"varint,1,opt,name=merkleTreeVersion,proto3" json:"merkleTreeVersion,omitempty"`
+	ReputationPolicy     *ReputationPolicyParams `protobuf:"bytes,2,opt,name=reputationPolicy,proto3" json:"reputationPolicy,omitempty"`
+	Selection            *SelectionParams        `protobuf:"bytes,3,opt,name=selection,proto3" json:"selection,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *ConsensusParams) Reset()         { *m = ConsensusParams{} }
+func (m *ConsensusParams) String() string { return proto.CompactTextString(m) }
+func (*ConsensusParams) ProtoMessage()    {}
+func (*ConsensusParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{26}
+}
+
+func (m *ConsensusParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConsensusParams.Unmarshal(m, b)
+}
+func (m *ConsensusParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConsensusParams.Marshal(b, m, deterministic)
+}
+func (m *ConsensusParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConsensusParams.Merge(m, src)
+}
+func (m *ConsensusParams) XXX_Size() int {
+	return xxx_messageInfo_ConsensusParams.Size(m)
+}
+func (m *ConsensusParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConsensusParams.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConsensusParams proto.InternalMessageInfo
+
+func (m *ConsensusParams) GetMerkleTreeVersion() uint32 {
+	if m != nil {
+		return m.MerkleTreeVersion
+	}
+	return 0
 }
 
-func (m *PublicKey) GetPort() string {
+func (m *ConsensusParams) GetReputationPolicy() *ReputationPolicyParams {
 	if m != nil {
-		return m.Port
+		return m.ReputationPolicy
 	}
-	return ""
+	return nil
 }
 
-func (m *PublicKey) GetKey() []byte {
+func (m *ConsensusParams) GetSelection() *SelectionParams {
 	if m != nil {
-		return m.Key
+		return m.Selection
 	}
 	return nil
 }
 
-type PeerState struct {
-	Id                     uint32          `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Ipv4                   string          `protobuf:"bytes,2,opt,name=ipv4,proto3" json:"ipv4,omitempty"`
-	Port                   string          `protobuf:"bytes,3,opt,name=port,proto3" json:"port,omitempty"`
-	Role                   ConsensusRole   `protobuf:"varint,4,opt,name=role,proto3,enum=plum.ConsensusRole" json:"role,omitempty"`
-	ConsensusRound         uint64          `protobuf:"varint,5,opt,name=consensusRound,proto3" json:"consensusRound,omitempty"`
-	CurrentPrimary         uint32          `protobuf:"varint,6,opt,name=currentPrimary,proto3" json:"currentPrimary,omitempty"`
-	ConsensusPhase         PBFTPhase       `protobuf:"varint,7,opt,name=consensusPhase,proto3,enum=plum.PBFTPhase" json:"consensusPhase,omitempty"`
-	Vote                   map[int32]int32 `protobuf:"bytes,8,rep,name=vote,proto3" json:"vote,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
-	ConsensusState         ConsensusState  `protobuf:"varint,9,opt,name=consensusState,proto3,enum=plum.ConsensusState" json:"consensusState,omitempty"`
-	BlockHeight            uint64          `protobuf:"varint,10,opt,name=blockHeight,proto3" json:"blockHeight,omitempty"`
-	QueueLength            uint64          `protobuf:"varint,11,opt,name=queueLength,proto3" json:"queueLength,omitempty"`
-	HeapLength             int64           `protobuf:"varint,12,opt,name=heapLength,proto3" json:"heapLength,omitempty"`
-	Reputation             float64         `protobuf:"fixed64,13,opt,name=reputation,proto3" json:"reputation,omitempty"`
-	SelectedCount          uint64          `protobuf:"varint,14,opt,name=selectedCount,proto3" json:"selectedCount,omitempty"`
-	TentativeSelectedCount uint64          `protobuf:"varint,15,opt,name=tentativeSelectedCount,proto3" json:"tentativeSelectedCount,omitempty"`
-	XXX_NoUnkeyedLiteral   struct{}        `json:"-"`
-	XXX_unrecognized       []byte          `json:"-"`
-	XXX_sizecache          int32           `json:"-"`
+// SelectionParams decides how XBFT selects its committee. all the values are written explicitly
+type SelectionParams struct {
+	//a peer is selected if its selection value is larger than the threshold
+	Threshold float64 `protobuf:"fixed64,1,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	//selection value is the ratio of the vrf hash plus reputation ratio multiplied by the weight
+	ReputationWeight     float64           `protobuf:"fixed64,2,opt,name=reputationWeight,proto3" json:"reputationWeight,omitempty"`
+	CommitteeSizeRule    CommitteeSizeRule `protobuf:"varint,3,opt,name=committeeSizeRule,proto3,enum=plum.CommitteeSizeRule" json:"committeeSizeRule,omitempty"`
+	MinCommitteeSize     uint32            `protobuf:"varint,4,opt,name=minCommitteeSize,proto3" json:"minCommitteeSize,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
-func (m *PeerState) Reset()         { *m = PeerState{} }
-func (m *PeerState) String() string { return proto.CompactTextString(m) }
-func (*PeerState) ProtoMessage()    {}
-func (*PeerState) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{3}
+func (m *SelectionParams) Reset()         { *m = SelectionParams{} }
+func (m *SelectionParams) String() string { return proto.CompactTextString(m) }
+func (*SelectionParams) ProtoMessage()    {}
+func (*SelectionParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{27}
 }
 
-func (m *PeerState) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_PeerState.Unmarshal(m, b)
+func (m *SelectionParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SelectionParams.Unmarshal(m, b)
 }
-func (m *PeerState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_PeerState.Marshal(b, m, deterministic)
+func (m *SelectionParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SelectionParams.Marshal(b, m, deterministic)
 }
-func (m *PeerState) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_PeerState.Merge(m, src)
+func (m *SelectionParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SelectionParams.Merge(m, src)
 }
-func (m *PeerState) XXX_Size() int {
-	return xxx_messageInfo_PeerState.Size(m)
+func (m *SelectionParams) XXX_Size() int {
+	return xxx_messageInfo_SelectionParams.Size(m)
 }
-func (m *PeerState) XXX_DiscardUnknown() {
-	xxx_messageInfo_PeerState.DiscardUnknown(m)
+func (m *SelectionParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_SelectionParams.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_PeerState proto.InternalMessageInfo
+var xxx_messageInfo_SelectionParams proto.InternalMessageInfo
 
-func (m *PeerState) GetId() uint32 {
+func (m *SelectionParams) GetThreshold() float64 {
 	if m != nil {
-		return m.Id
+		return m.Threshold
 	}
 	return 0
 }
 
-func (m *PeerState) GetIpv4() string {
+func (m *SelectionParams) GetReputationWeight() float64 {
 	if m != nil {
-		return m.Ipv4
+		return m.ReputationWeight
 	}
-	return ""
+	return 0
 }
 
-func (m *PeerState) GetPort() string {
+func (m *SelectionParams) GetCommitteeSizeRule() CommitteeSizeRule {
 	if m != nil {
-		return m.Port
+		return m.CommitteeSizeRule
 	}
-	return ""
+	return CommitteeSizeRule_FixedCommitteeSize
 }
 
-func (m *PeerState) GetRole() ConsensusRole {
+func (m *SelectionParams) GetMinCommitteeSize() uint32 {
 	if m != nil {
-		return m.Role
+		return m.MinCommitteeSize
 	}
-	return ConsensusRole_Primary
+	return 0
 }
 
-func (m *PeerState) GetConsensusRound() uint64 {
-	if m != nil {
-		return m.ConsensusRound
-	}
-	return 0
+// ReputationPolicyParams selects how reputation of validators changes. zero values take the defaults of the policy
+type ReputationPolicyParams struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Increase             float64  `protobuf:"fixed64,2,opt,name=increase,proto3" json:"increase,omitempty"`
+	Decrease             float64  `protobuf:"fixed64,3,opt,name=decrease,proto3" json:"decrease,omitempty"`
+	Decay                float64  `protobuf:"fixed64,4,opt,name=decay,proto3" json:"decay,omitempty"`
+	Base                 float64  `protobuf:"fixed64,5,opt,name=base,proto3" json:"base,omitempty"`
+	Window               uint64   `protobuf:"varint,6,opt,name=window,proto3" json:"window,omitempty"`
+	Min                  float64  `protobuf:"fixed64,7,opt,name=min,proto3" json:"min,omitempty"`
+	Max                  float64  `protobuf:"fixed64,8,opt,name=max,proto3" json:"max,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *PeerState) GetCurrentPrimary() uint32 {
-	if m != nil {
-		return m.CurrentPrimary
-	}
-	return 0
+func (m *ReputationPolicyParams) Reset()         { *m = ReputationPolicyParams{} }
+func (m *ReputationPolicyParams) String() string { return proto.CompactTextString(m) }
+func (*ReputationPolicyParams) ProtoMessage()    {}
+func (*ReputationPolicyParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{28}
 }
 
-func (m *PeerState) GetConsensusPhase() PBFTPhase {
-	if m != nil {
-		return m.ConsensusPhase
-	}
-	return PBFTPhase_PBFTRoundChange
+func (m *ReputationPolicyParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReputationPolicyParams.Unmarshal(m, b)
+}
+func (m *ReputationPolicyParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReputationPolicyParams.Marshal(b, m, deterministic)
+}
+func (m *ReputationPolicyParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReputationPolicyParams.Merge(m, src)
+}
+func (m *ReputationPolicyParams) XXX_Size() int {
+	return xxx_messageInfo_ReputationPolicyParams.Size(m)
+}
+func (m *ReputationPolicyParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReputationPolicyParams.DiscardUnknown(m)
 }
 
-func (m *PeerState) GetVote() map[int32]int32 {
+var xxx_messageInfo_ReputationPolicyParams proto.InternalMessageInfo
+
+func (m *ReputationPolicyParams) GetName() string {
 	if m != nil {
-		return m.Vote
+		return m.Name
 	}
-	return nil
+	return ""
 }
 
-func (m *PeerState) GetConsensusState() ConsensusState {
+func (m *ReputationPolicyParams) GetIncrease() float64 {
 	if m != nil {
-		return m.ConsensusState
+		return m.Increase
 	}
-	return ConsensusState_Idle
+	return 0
 }
 
-func (m *PeerState) GetBlockHeight() uint64 {
+func (m *ReputationPolicyParams) GetDecrease() float64 {
 	if m != nil {
-		return m.BlockHeight
+		return m.Decrease
 	}
 	return 0
 }
 
-func (m *PeerState) GetQueueLength() uint64 {
+func (m *ReputationPolicyParams) GetDecay() float64 {
 	if m != nil {
-		return m.QueueLength
+		return m.Decay
 	}
 	return 0
 }
 
-func (m *PeerState) GetHeapLength() int64 {
+func (m *ReputationPolicyParams) GetBase() float64 {
 	if m != nil {
-		return m.HeapLength
+		return m.Base
 	}
 	return 0
 }
 
-func (m *PeerState) GetReputation() float64 {
+func (m *ReputationPolicyParams) GetWindow() uint64 {
 	if m != nil {
-		return m.Reputation
+		return m.Window
 	}
 	return 0
 }
 
-func (m *PeerState) GetSelectedCount() uint64 {
+func (m *ReputationPolicyParams) GetMin() float64 {
 	if m != nil {
-		return m.SelectedCount
+		return m.Min
 	}
 	return 0
 }
 
-func (m *PeerState) GetTentativeSelectedCount() uint64 {
+func (m *ReputationPolicyParams) GetMax() float64 {
 	if m != nil {
-		return m.TentativeSelectedCount
+		return m.Max
 	}
 	return 0
 }
 
-//Empty is for message without content
+// Empty is for message without content
 type Empty struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -520,7 +2401,7 @@ func (m *Empty) Reset()         { *m = Empty{} }
 func (m *Empty) String() string { return proto.CompactTextString(m) }
 func (*Empty) ProtoMessage()    {}
 func (*Empty) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{4}
+	return fileDescriptor_6954aaea537d5982, []int{29}
 }
 
 func (m *Empty) XXX_Unmarshal(b []byte) error {
@@ -541,7 +2422,7 @@ func (m *Empty) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Empty proto.InternalMessageInfo
 
-//bytes is []byte in golang
+// bytes is []byte in golang
 type Envelope struct {
 	Payload              []byte   `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
 	Signature            []byte   `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
@@ -554,7 +2435,7 @@ func (m *Envelope) Reset()         { *m = Envelope{} }
 func (m *Envelope) String() string { return proto.CompactTextString(m) }
 func (*Envelope) ProtoMessage()    {}
 func (*Envelope) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{5}
+	return fileDescriptor_6954aaea537d5982, []int{30}
 }
 
 func (m *Envelope) XXX_Unmarshal(b []byte) error {
@@ -589,6 +2470,79 @@ func (m *Envelope) GetSignature() []byte {
 	return nil
 }
 
+// SigningEnvelope is what a consensus message is signed as.
+// binding the chain, the protocol version, the type and the height keeps a signature from being replayed on another network or message
+type SigningEnvelope struct {
+	ChainId              string      `protobuf:"bytes,1,opt,name=chainId,proto3" json:"chainId,omitempty"`
+	ProtocolVersion      uint32      `protobuf:"varint,2,opt,name=protocolVersion,proto3" json:"protocolVersion,omitempty"`
+	MessageType          MessageType `protobuf:"varint,3,opt,name=messageType,proto3,enum=plum.MessageType" json:"messageType,omitempty"`
+	Height               uint64      `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	Payload              []byte      `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *SigningEnvelope) Reset()         { *m = SigningEnvelope{} }
+func (m *SigningEnvelope) String() string { return proto.CompactTextString(m) }
+func (*SigningEnvelope) ProtoMessage()    {}
+func (*SigningEnvelope) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{31}
+}
+
+func (m *SigningEnvelope) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SigningEnvelope.Unmarshal(m, b)
+}
+func (m *SigningEnvelope) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SigningEnvelope.Marshal(b, m, deterministic)
+}
+func (m *SigningEnvelope) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SigningEnvelope.Merge(m, src)
+}
+func (m *SigningEnvelope) XXX_Size() int {
+	return xxx_messageInfo_SigningEnvelope.Size(m)
+}
+func (m *SigningEnvelope) XXX_DiscardUnknown() {
+	xxx_messageInfo_SigningEnvelope.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SigningEnvelope proto.InternalMessageInfo
+
+func (m *SigningEnvelope) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+func (m *SigningEnvelope) GetProtocolVersion() uint32 {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *SigningEnvelope) GetMessageType() MessageType {
+	if m != nil {
+		return m.MessageType
+	}
+	return MessageType_UnknownMessageType
+}
+
+func (m *SigningEnvelope) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *SigningEnvelope) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
 type PBFTRequest struct {
 	Message              *PBFTMessage `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	Signature            []byte       `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
@@ -602,7 +2556,7 @@ func (m *PBFTRequest) Reset()         { *m = PBFTRequest{} }
 func (m *PBFTRequest) String() string { return proto.CompactTextString(m) }
 func (*PBFTRequest) ProtoMessage()    {}
 func (*PBFTRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{6}
+	return fileDescriptor_6954aaea537d5982, []int{32}
 }
 
 func (m *PBFTRequest) XXX_Unmarshal(b []byte) error {
@@ -659,7 +2613,7 @@ func (m *PBFTResponse) Reset()         { *m = PBFTResponse{} }
 func (m *PBFTResponse) String() string { return proto.CompactTextString(m) }
 func (*PBFTResponse) ProtoMessage()    {}
 func (*PBFTResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{7}
+	return fileDescriptor_6954aaea537d5982, []int{33}
 }
 
 func (m *PBFTResponse) XXX_Unmarshal(b []byte) error {
@@ -737,6 +2691,7 @@ type PBFTMessage struct {
 	Round                uint64    `protobuf:"varint,2,opt,name=round,proto3" json:"round,omitempty"`
 	Digest               []byte    `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
 	PeerId               uint32    `protobuf:"varint,4,opt,name=peerId,proto3" json:"peerId,omitempty"`
+	Height               uint64    `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
@@ -746,7 +2701,7 @@ func (m *PBFTMessage) Reset()         { *m = PBFTMessage{} }
 func (m *PBFTMessage) String() string { return proto.CompactTextString(m) }
 func (*PBFTMessage) ProtoMessage()    {}
 func (*PBFTMessage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{8}
+	return fileDescriptor_6954aaea537d5982, []int{34}
 }
 
 func (m *PBFTMessage) XXX_Unmarshal(b []byte) error {
@@ -795,6 +2750,13 @@ func (m *PBFTMessage) GetPeerId() uint32 {
 	return 0
 }
 
+func (m *PBFTMessage) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
 type XBFTRequest struct {
 	Message              *XBFTMessage `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	Signature            []byte       `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
@@ -808,7 +2770,7 @@ func (m *XBFTRequest) Reset()         { *m = XBFTRequest{} }
 func (m *XBFTRequest) String() string { return proto.CompactTextString(m) }
 func (*XBFTRequest) ProtoMessage()    {}
 func (*XBFTRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{9}
+	return fileDescriptor_6954aaea537d5982, []int{35}
 }
 
 func (m *XBFTRequest) XXX_Unmarshal(b []byte) error {
@@ -865,7 +2827,7 @@ func (m *XBFTResponse) Reset()         { *m = XBFTResponse{} }
 func (m *XBFTResponse) String() string { return proto.CompactTextString(m) }
 func (*XBFTResponse) ProtoMessage()    {}
 func (*XBFTResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{10}
+	return fileDescriptor_6954aaea537d5982, []int{36}
 }
 
 func (m *XBFTResponse) XXX_Unmarshal(b []byte) error {
@@ -959,7 +2921,7 @@ func (m *XBFTMessage) Reset()         { *m = XBFTMessage{} }
 func (m *XBFTMessage) String() string { return proto.CompactTextString(m) }
 func (*XBFTMessage) ProtoMessage()    {}
 func (*XBFTMessage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{11}
+	return fileDescriptor_6954aaea537d5982, []int{37}
 }
 
 func (m *XBFTMessage) XXX_Unmarshal(b []byte) error {
@@ -1071,7 +3033,7 @@ func (m *CommitteeMembers) Reset()         { *m = CommitteeMembers{} }
 func (m *CommitteeMembers) String() string { return proto.CompactTextString(m) }
 func (*CommitteeMembers) ProtoMessage()    {}
 func (*CommitteeMembers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{12}
+	return fileDescriptor_6954aaea537d5982, []int{38}
 }
 
 func (m *CommitteeMembers) XXX_Unmarshal(b []byte) error {
@@ -1131,7 +3093,7 @@ func (m *Certificate) Reset()         { *m = Certificate{} }
 func (m *Certificate) String() string { return proto.CompactTextString(m) }
 func (*Certificate) ProtoMessage()    {}
 func (*Certificate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{13}
+	return fileDescriptor_6954aaea537d5982, []int{39}
 }
 
 func (m *Certificate) XXX_Unmarshal(b []byte) error {
@@ -1159,6 +3121,79 @@ func (m *Certificate) GetCert() []*XBFTRequest {
 	return nil
 }
 
+// CommitCertificate is the set of signed messages which has finalized a block at the height.
+// PBFT keeps the commit messages. XBFT keeps the committed certificate of the committee and the select messages of all peers
+type CommitCertificate struct {
+	Height               uint64         `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	BlockDigest          []byte         `protobuf:"bytes,2,opt,name=blockDigest,proto3" json:"blockDigest,omitempty"`
+	PbftCommits          []*PBFTRequest `protobuf:"bytes,3,rep,name=pbftCommits,proto3" json:"pbftCommits,omitempty"`
+	XbftCommitted        *Certificate   `protobuf:"bytes,4,opt,name=xbftCommitted,proto3" json:"xbftCommitted,omitempty"`
+	XbftSelected         *Certificate   `protobuf:"bytes,5,opt,name=xbftSelected,proto3" json:"xbftSelected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *CommitCertificate) Reset()         { *m = CommitCertificate{} }
+func (m *CommitCertificate) String() string { return proto.CompactTextString(m) }
+func (*CommitCertificate) ProtoMessage()    {}
+func (*CommitCertificate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{40}
+}
+
+func (m *CommitCertificate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CommitCertificate.Unmarshal(m, b)
+}
+func (m *CommitCertificate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CommitCertificate.Marshal(b, m, deterministic)
+}
+func (m *CommitCertificate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitCertificate.Merge(m, src)
+}
+func (m *CommitCertificate) XXX_Size() int {
+	return xxx_messageInfo_CommitCertificate.Size(m)
+}
+func (m *CommitCertificate) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitCertificate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitCertificate proto.InternalMessageInfo
+
+func (m *CommitCertificate) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *CommitCertificate) GetBlockDigest() []byte {
+	if m != nil {
+		return m.BlockDigest
+	}
+	return nil
+}
+
+func (m *CommitCertificate) GetPbftCommits() []*PBFTRequest {
+	if m != nil {
+		return m.PbftCommits
+	}
+	return nil
+}
+
+func (m *CommitCertificate) GetXbftCommitted() *Certificate {
+	if m != nil {
+		return m.XbftCommitted
+	}
+	return nil
+}
+
+func (m *CommitCertificate) GetXbftSelected() *Certificate {
+	if m != nil {
+		return m.XbftSelected
+	}
+	return nil
+}
+
 type Block struct {
 	Header                       *Header             `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	Body                         *Body               `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
@@ -1173,7 +3208,7 @@ func (m *Block) Reset()         { *m = Block{} }
 func (m *Block) String() string { return proto.CompactTextString(m) }
 func (*Block) ProtoMessage()    {}
 func (*Block) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{14}
+	return fileDescriptor_6954aaea537d5982, []int{41}
 }
 
 func (m *Block) XXX_Unmarshal(b []byte) error {
@@ -1223,20 +3258,25 @@ func (m *Block) GetRoundChangedCommitteeMembers() []*CommitteeMembers {
 }
 
 type Header struct {
-	Id                   uint64               `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	MerkleRoot           []byte               `protobuf:"bytes,2,opt,name=merkleRoot,proto3" json:"merkleRoot,omitempty"`
-	PrevBlockHash        []byte               `protobuf:"bytes,3,opt,name=prevBlockHash,proto3" json:"prevBlockHash,omitempty"`
-	Time                 *timestamp.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	Id            uint64               `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	MerkleRoot    []byte               `protobuf:"bytes,2,opt,name=merkleRoot,proto3" json:"merkleRoot,omitempty"`
+	PrevBlockHash []byte               `protobuf:"bytes,3,opt,name=prevBlockHash,proto3" json:"prevBlockHash,omitempty"`
+	Time          *timestamp.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	//format of the merkle tree that merkleRoot is built with, the chain uses the one of its genesis block
+	MerkleTreeVersion uint32 `protobuf:"varint,5,opt,name=merkleTreeVersion,proto3" json:"merkleTreeVersion,omitempty"`
+	//hash of the committee members and the round changed committee members of the block, so that the digest of the header covers them.
+	//it is empty for a block without them
+	CommitteeDigest      []byte   `protobuf:"bytes,6,opt,name=committeeDigest,proto3" json:"committeeDigest,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Header) Reset()         { *m = Header{} }
 func (m *Header) String() string { return proto.CompactTextString(m) }
 func (*Header) ProtoMessage()    {}
 func (*Header) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{15}
+	return fileDescriptor_6954aaea537d5982, []int{42}
 }
 
 func (m *Header) XXX_Unmarshal(b []byte) error {
@@ -1285,148 +3325,66 @@ func (m *Header) GetTime() *timestamp.Timestamp {
 	return nil
 }
 
-type Body struct {
-	MerkleTree           *MerkleTree `protobuf:"bytes,1,opt,name=merkleTree,proto3" json:"merkleTree,omitempty"`
-	Txs                  [][]byte    `protobuf:"bytes,2,rep,name=Txs,proto3" json:"Txs,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
-}
-
-func (m *Body) Reset()         { *m = Body{} }
-func (m *Body) String() string { return proto.CompactTextString(m) }
-func (*Body) ProtoMessage()    {}
-func (*Body) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{16}
-}
-
-func (m *Body) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Body.Unmarshal(m, b)
-}
-func (m *Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Body.Marshal(b, m, deterministic)
-}
-func (m *Body) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Body.Merge(m, src)
-}
-func (m *Body) XXX_Size() int {
-	return xxx_messageInfo_Body.Size(m)
-}
-func (m *Body) XXX_DiscardUnknown() {
-	xxx_messageInfo_Body.DiscardUnknown(m)
-}
-
-var xxx_messageInfo_Body proto.InternalMessageInfo
-
-func (m *Body) GetMerkleTree() *MerkleTree {
-	if m != nil {
-		return m.MerkleTree
-	}
-	return nil
-}
-
-func (m *Body) GetTxs() [][]byte {
+func (m *Header) GetMerkleTreeVersion() uint32 {
 	if m != nil {
-		return m.Txs
+		return m.MerkleTreeVersion
 	}
-	return nil
-}
-
-type MerkleTree struct {
-	Root                 *MerkleNode `protobuf:"bytes,1,opt,name=Root,proto3" json:"Root,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
-}
-
-func (m *MerkleTree) Reset()         { *m = MerkleTree{} }
-func (m *MerkleTree) String() string { return proto.CompactTextString(m) }
-func (*MerkleTree) ProtoMessage()    {}
-func (*MerkleTree) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{17}
-}
-
-func (m *MerkleTree) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MerkleTree.Unmarshal(m, b)
-}
-func (m *MerkleTree) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MerkleTree.Marshal(b, m, deterministic)
-}
-func (m *MerkleTree) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MerkleTree.Merge(m, src)
-}
-func (m *MerkleTree) XXX_Size() int {
-	return xxx_messageInfo_MerkleTree.Size(m)
-}
-func (m *MerkleTree) XXX_DiscardUnknown() {
-	xxx_messageInfo_MerkleTree.DiscardUnknown(m)
+	return 0
 }
 
-var xxx_messageInfo_MerkleTree proto.InternalMessageInfo
-
-func (m *MerkleTree) GetRoot() *MerkleNode {
+func (m *Header) GetCommitteeDigest() []byte {
 	if m != nil {
-		return m.Root
+		return m.CommitteeDigest
 	}
 	return nil
 }
 
-type MerkleNode struct {
-	L                    *MerkleNode `protobuf:"bytes,1,opt,name=L,proto3" json:"L,omitempty"`
-	R                    *MerkleNode `protobuf:"bytes,2,opt,name=R,proto3" json:"R,omitempty"`
-	D                    []byte      `protobuf:"bytes,3,opt,name=D,proto3" json:"D,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+// merkle tree is no longer shipped with the block; it is recomputed from Txs and checked against the header's merkleRoot
+type Body struct {
+	Txs                  [][]byte `protobuf:"bytes,2,rep,name=Txs,proto3" json:"Txs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *MerkleNode) Reset()         { *m = MerkleNode{} }
-func (m *MerkleNode) String() string { return proto.CompactTextString(m) }
-func (*MerkleNode) ProtoMessage()    {}
-func (*MerkleNode) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6954aaea537d5982, []int{18}
+func (m *Body) Reset()         { *m = Body{} }
+func (m *Body) String() string { return proto.CompactTextString(m) }
+func (*Body) ProtoMessage()    {}
+func (*Body) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6954aaea537d5982, []int{43}
 }
 
-func (m *MerkleNode) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MerkleNode.Unmarshal(m, b)
-}
-func (m *MerkleNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MerkleNode.Marshal(b, m, deterministic)
+func (m *Body) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Body.Unmarshal(m, b)
 }
-func (m *MerkleNode) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MerkleNode.Merge(m, src)
+func (m *Body) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Body.Marshal(b, m, deterministic)
 }
-func (m *MerkleNode) XXX_Size() int {
-	return xxx_messageInfo_MerkleNode.Size(m)
+func (m *Body) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Body.Merge(m, src)
 }
-func (m *MerkleNode) XXX_DiscardUnknown() {
-	xxx_messageInfo_MerkleNode.DiscardUnknown(m)
+func (m *Body) XXX_Size() int {
+	return xxx_messageInfo_Body.Size(m)
 }
-
-var xxx_messageInfo_MerkleNode proto.InternalMessageInfo
-
-func (m *MerkleNode) GetL() *MerkleNode {
-	if m != nil {
-		return m.L
-	}
-	return nil
+func (m *Body) XXX_DiscardUnknown() {
+	xxx_messageInfo_Body.DiscardUnknown(m)
 }
 
-func (m *MerkleNode) GetR() *MerkleNode {
-	if m != nil {
-		return m.R
-	}
-	return nil
-}
+var xxx_messageInfo_Body proto.InternalMessageInfo
 
-func (m *MerkleNode) GetD() []byte {
+func (m *Body) GetTxs() [][]byte {
 	if m != nil {
-		return m.D
+		return m.Txs
 	}
 	return nil
 }
 
 func init() {
+	proto.RegisterEnum("plum.EventType", EventType_name, EventType_value)
+	proto.RegisterEnum("plum.MessageType", MessageType_name, MessageType_value)
+	proto.RegisterEnum("plum.CommitteeSizeRule", CommitteeSizeRule_name, CommitteeSizeRule_value)
+	proto.RegisterEnum("plum.ReputationEventType", ReputationEventType_name, ReputationEventType_value)
+	proto.RegisterEnum("plum.ValidatorUpdateType", ValidatorUpdateType_name, ValidatorUpdateType_value)
 	proto.RegisterEnum("plum.PBFTPhase", PBFTPhase_name, PBFTPhase_value)
 	proto.RegisterEnum("plum.XBFTPhase", XBFTPhase_name, XBFTPhase_value)
 	proto.RegisterEnum("plum.ConsensusState", ConsensusState_name, ConsensusState_value)
@@ -1435,11 +3393,41 @@ func init() {
 	proto.RegisterEnum("plum.ConsensusRole", ConsensusRole_name, ConsensusRole_value)
 	proto.RegisterType((*Ping)(nil), "plum.Ping")
 	proto.RegisterType((*Pong)(nil), "plum.Pong")
+	proto.RegisterType((*Hello)(nil), "plum.Hello")
 	proto.RegisterType((*PublicKey)(nil), "plum.PublicKey")
 	proto.RegisterType((*PeerState)(nil), "plum.PeerState")
+	proto.RegisterMapType((map[int32]int32)(nil), "plum.PeerState.CertificatesEntry")
+	proto.RegisterMapType((map[uint32]float64)(nil), "plum.PeerState.ReputationBookEntry")
+	proto.RegisterMapType((map[int32]int32)(nil), "plum.PeerState.ThresholdsEntry")
 	proto.RegisterMapType((map[int32]int32)(nil), "plum.PeerState.VoteEntry")
+	proto.RegisterType((*BlockRange)(nil), "plum.BlockRange")
+	proto.RegisterType((*SyncBlock)(nil), "plum.SyncBlock")
+	proto.RegisterType((*Snapshot)(nil), "plum.Snapshot")
+	proto.RegisterMapType((map[uint32]float64)(nil), "plum.Snapshot.ReputationBookEntry")
+	proto.RegisterType((*ReputationRecord)(nil), "plum.ReputationRecord")
+	proto.RegisterType((*ReputationEvent)(nil), "plum.ReputationEvent")
+	proto.RegisterType((*SlashedSlot)(nil), "plum.SlashedSlot")
+	proto.RegisterType((*ReputationHistoryRequest)(nil), "plum.ReputationHistoryRequest")
+	proto.RegisterType((*ReputationHistory)(nil), "plum.ReputationHistory")
+	proto.RegisterType((*EventFilter)(nil), "plum.EventFilter")
+	proto.RegisterType((*LoggedMessage)(nil), "plum.LoggedMessage")
+	proto.RegisterType((*MessageLogRequest)(nil), "plum.MessageLogRequest")
+	proto.RegisterType((*MessageLogDump)(nil), "plum.MessageLogDump")
+	proto.RegisterType((*Event)(nil), "plum.Event")
+	proto.RegisterType((*LogLevel)(nil), "plum.LogLevel")
+	proto.RegisterType((*AppState)(nil), "plum.AppState")
+	proto.RegisterType((*GenesisConfig)(nil), "plum.GenesisConfig")
+	proto.RegisterType((*Validator)(nil), "plum.Validator")
+	proto.RegisterType((*ValidatorUpdate)(nil), "plum.ValidatorUpdate")
+	proto.RegisterType((*SignedValidatorUpdate)(nil), "plum.SignedValidatorUpdate")
+	proto.RegisterType((*ValidatorSignature)(nil), "plum.ValidatorSignature")
+	proto.RegisterType((*Evidence)(nil), "plum.Evidence")
+	proto.RegisterType((*ConsensusParams)(nil), "plum.ConsensusParams")
+	proto.RegisterType((*SelectionParams)(nil), "plum.SelectionParams")
+	proto.RegisterType((*ReputationPolicyParams)(nil), "plum.ReputationPolicyParams")
 	proto.RegisterType((*Empty)(nil), "plum.Empty")
 	proto.RegisterType((*Envelope)(nil), "plum.Envelope")
+	proto.RegisterType((*SigningEnvelope)(nil), "plum.SigningEnvelope")
 	proto.RegisterType((*PBFTRequest)(nil), "plum.PBFTRequest")
 	proto.RegisterType((*PBFTResponse)(nil), "plum.PBFTResponse")
 	proto.RegisterType((*PBFTMessage)(nil), "plum.PBFTMessage")
@@ -1448,120 +3436,232 @@ func init() {
 	proto.RegisterType((*XBFTMessage)(nil), "plum.XBFTMessage")
 	proto.RegisterType((*CommitteeMembers)(nil), "plum.CommitteeMembers")
 	proto.RegisterType((*Certificate)(nil), "plum.Certificate")
+	proto.RegisterType((*CommitCertificate)(nil), "plum.CommitCertificate")
 	proto.RegisterType((*Block)(nil), "plum.Block")
 	proto.RegisterType((*Header)(nil), "plum.Header")
 	proto.RegisterType((*Body)(nil), "plum.Body")
-	proto.RegisterType((*MerkleTree)(nil), "plum.MerkleTree")
-	proto.RegisterType((*MerkleNode)(nil), "plum.MerkleNode")
 }
 
-func init() { proto.RegisterFile("plum.proto", fileDescriptor_6954aaea537d5982) }
+func init() {
+	proto.RegisterFile("plum.proto", fileDescriptor_6954aaea537d5982)
+}
 
 var fileDescriptor_6954aaea537d5982 = []byte{
-	// 1501 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x57, 0xdd, 0x6e, 0x1b, 0xc5,
-	0x17, 0xcf, 0xda, 0x6b, 0xc7, 0x3e, 0x76, 0x9c, 0xfd, 0x4f, 0xfb, 0x0f, 0x8b, 0x55, 0x82, 0x59,
-	0xa5, 0x10, 0x42, 0x71, 0x23, 0x53, 0x28, 0x42, 0x70, 0x81, 0xd3, 0xb4, 0x09, 0xb4, 0xc5, 0x9a,
-	0x84, 0xc8, 0xea, 0x05, 0xd2, 0xc6, 0x7b, 0xe2, 0xac, 0xb2, 0xbb, 0xb3, 0x9d, 0x9d, 0x75, 0x6b,
-	0x10, 0x3c, 0x02, 0x12, 0x8f, 0xc4, 0x05, 0x8f, 0xc3, 0x23, 0x20, 0xa1, 0x99, 0xd9, 0xb5, 0xd7,
-	0xce, 0x87, 0x2a, 0x24, 0x24, 0xee, 0xf6, 0xfc, 0xce, 0x6f, 0xce, 0xcc, 0xf9, 0x9a, 0x39, 0x0b,
-	0x10, 0x07, 0x69, 0xd8, 0x8d, 0x39, 0x13, 0x8c, 0x98, 0xf2, 0xbb, 0xfd, 0xee, 0x98, 0xb1, 0x71,
-	0x80, 0xf7, 0x15, 0x76, 0x9a, 0x9e, 0xdd, 0x17, 0x7e, 0x88, 0x89, 0x70, 0xc3, 0x58, 0xd3, 0x9c,
-	0x36, 0x98, 0x03, 0x3f, 0x1a, 0x13, 0x02, 0x66, 0xe4, 0x86, 0x68, 0x1b, 0x1d, 0x63, 0xbb, 0x4e,
-	0xd5, 0xb7, 0xd3, 0x01, 0x73, 0xc0, 0xa2, 0x31, 0xb1, 0x61, 0x35, 0xc4, 0x24, 0x71, 0xc7, 0xb9,
-	0x3a, 0x17, 0x9d, 0xef, 0xa1, 0x3e, 0x48, 0x4f, 0x03, 0x7f, 0xf4, 0x2d, 0x4e, 0x49, 0x0b, 0x4a,
-	0xbe, 0xa7, 0x18, 0x6b, 0xb4, 0xe4, 0x7b, 0xd2, 0xa4, 0x1f, 0x4f, 0x1e, 0xd8, 0x25, 0x6d, 0x52,
-	0x7e, 0x4b, 0x2c, 0x66, 0x5c, 0xd8, 0x65, 0x8d, 0xc9, 0x6f, 0x62, 0x41, 0xf9, 0x02, 0xa7, 0xb6,
-	0xd9, 0x31, 0xb6, 0x9b, 0x54, 0x7e, 0x3a, 0x7f, 0x99, 0x50, 0x1f, 0x20, 0xf2, 0x23, 0xe1, 0x0a,
-	0xfc, 0xc7, 0x76, 0x3f, 0x00, 0x93, 0xb3, 0x00, 0x95, 0xe1, 0x56, 0xef, 0x56, 0x57, 0x05, 0x67,
-	0x8f, 0x45, 0x09, 0x46, 0x49, 0x9a, 0x50, 0x16, 0x20, 0x55, 0x04, 0xf2, 0x3e, 0xb4, 0x46, 0x73,
-	0x38, 0x8d, 0x3c, 0xbb, 0xd2, 0x31, 0xb6, 0x4d, 0xba, 0x84, 0x2a, 0x5e, 0xca, 0x39, 0x46, 0x62,
-	0xc0, 0xfd, 0xd0, 0xe5, 0x53, 0xbb, 0xaa, 0x0e, 0xb5, 0x84, 0x92, 0x87, 0x05, 0x7b, 0x83, 0x73,
-	0x37, 0x41, 0x7b, 0x55, 0x1d, 0x61, 0x5d, 0x1f, 0x61, 0xd0, 0x7f, 0x7c, 0xac, 0x60, 0xba, 0x44,
-	0x23, 0x1f, 0x83, 0x39, 0x61, 0x02, 0xed, 0x5a, 0xa7, 0xbc, 0xdd, 0xe8, 0xbd, 0x9d, 0xd1, 0xf3,
-	0x40, 0x74, 0x4f, 0x98, 0xc0, 0xfd, 0x48, 0xf0, 0x29, 0x55, 0x34, 0xf2, 0x65, 0x61, 0x1f, 0xc5,
-	0xb0, 0xeb, 0x6a, 0x9f, 0xdb, 0x4b, 0xae, 0x2a, 0x1d, 0x5d, 0xe2, 0x92, 0x0e, 0x34, 0x4e, 0x03,
-	0x36, 0xba, 0x38, 0x40, 0x7f, 0x7c, 0x2e, 0x6c, 0x50, 0x2e, 0x17, 0x21, 0xc9, 0x78, 0x99, 0x62,
-	0x8a, 0x4f, 0x31, 0x1a, 0x8b, 0x73, 0xbb, 0xa1, 0x19, 0x05, 0x88, 0x6c, 0x02, 0x9c, 0xa3, 0x1b,
-	0x67, 0x84, 0x66, 0xc7, 0xd8, 0x2e, 0xd3, 0x02, 0x22, 0xf5, 0x1c, 0xe3, 0x54, 0xb8, 0xc2, 0x67,
-	0x91, 0xbd, 0xd6, 0x31, 0xb6, 0x0d, 0x5a, 0x40, 0xc8, 0x16, 0xac, 0x25, 0x18, 0xe0, 0x48, 0xa0,
-	0xb7, 0xc7, 0xd2, 0x48, 0xd8, 0x2d, 0xb5, 0xc7, 0x22, 0x48, 0x3e, 0x83, 0x0d, 0x81, 0x91, 0x5c,
-	0x32, 0xc1, 0xa3, 0x05, 0xfa, 0xba, 0xa2, 0x5f, 0xa3, 0x6d, 0x3f, 0x84, 0xfa, 0x2c, 0x64, 0x79,
-	0x95, 0xc9, 0x32, 0xaa, 0xa8, 0x2a, 0x23, 0xb7, 0xa1, 0x32, 0x71, 0x83, 0x14, 0x55, 0x21, 0x55,
-	0xa8, 0x16, 0xbe, 0x28, 0x7d, 0x6e, 0x38, 0xab, 0x50, 0xd9, 0x0f, 0x63, 0x31, 0x75, 0xfa, 0x50,
-	0xdb, 0x8f, 0x26, 0x18, 0xb0, 0x18, 0x65, 0x17, 0xc4, 0xee, 0x34, 0x60, 0xae, 0xae, 0xc5, 0x26,
-	0xcd, 0x45, 0x72, 0x07, 0xea, 0x89, 0x3f, 0x8e, 0x5c, 0x91, 0x72, 0x6d, 0xac, 0x49, 0xe7, 0x80,
-	0xf3, 0x13, 0x34, 0x64, 0xc6, 0x29, 0xbe, 0x4c, 0x31, 0x11, 0xe4, 0xa3, 0xc5, 0x66, 0x6a, 0xf4,
-	0xfe, 0x37, 0xaf, 0x8a, 0x67, 0x5a, 0x31, 0xeb, 0xaf, 0x9b, 0x2d, 0x93, 0xf7, 0xa0, 0xa2, 0xd2,
-	0xa5, 0xaa, 0xbe, 0xd1, 0x6b, 0x68, 0x43, 0x7d, 0x09, 0x51, 0xad, 0x71, 0x7e, 0x33, 0xa0, 0xa9,
-	0x77, 0x4f, 0x62, 0x99, 0x7e, 0x72, 0x0f, 0xaa, 0x89, 0x70, 0x45, 0x9a, 0xa8, 0xdd, 0x67, 0xb5,
-	0x92, 0xeb, 0x8f, 0x94, 0x8e, 0x66, 0x1c, 0x42, 0xa0, 0x1c, 0x26, 0x63, 0xbd, 0xf3, 0xc1, 0x0a,
-	0x95, 0x02, 0xf9, 0x14, 0x2a, 0xc8, 0x39, 0xe3, 0x6a, 0xd7, 0x56, 0xef, 0x9d, 0xa5, 0x62, 0x3b,
-	0x71, 0x03, 0xdf, 0x53, 0xd9, 0xdd, 0x63, 0x1e, 0x1e, 0xac, 0x50, 0xcd, 0xee, 0xd7, 0xa0, 0xca,
-	0x31, 0x49, 0x03, 0xe1, 0xfc, 0xa8, 0x03, 0x92, 0x39, 0x4b, 0xee, 0x42, 0x25, 0x56, 0x4d, 0x62,
-	0x5c, 0xdd, 0x24, 0x5a, 0x2b, 0xb3, 0xc5, 0x55, 0x6f, 0x96, 0x54, 0xce, 0xb5, 0x40, 0x36, 0xa0,
-	0xea, 0xf9, 0x63, 0x4c, 0x74, 0xe7, 0x37, 0x69, 0x26, 0x49, 0x3c, 0x46, 0xe4, 0x87, 0x9e, 0xea,
-	0xfe, 0x35, 0x9a, 0x49, 0x32, 0x19, 0xc3, 0x37, 0x48, 0xc6, 0xf0, 0x5f, 0x4b, 0xc6, 0xf0, 0x3f,
-	0x96, 0x8c, 0x3f, 0xca, 0x3a, 0x22, 0x37, 0x67, 0x63, 0xf8, 0xc6, 0xd9, 0x38, 0xd7, 0xb7, 0x49,
-	0x59, 0xc1, 0x99, 0x54, 0xc8, 0x92, 0x79, 0x4d, 0x96, 0x2a, 0xc5, 0x2c, 0xc9, 0x8b, 0x56, 0xdf,
-	0x00, 0x3e, 0x8b, 0x4e, 0x54, 0x8b, 0x56, 0xd5, 0xd5, 0xb1, 0x84, 0xca, 0x53, 0xc4, 0x9c, 0xb1,
-	0x33, 0x75, 0xbf, 0x36, 0xa9, 0x16, 0x64, 0x9e, 0x62, 0x7d, 0x13, 0x1f, 0x7a, 0x76, 0x4d, 0x19,
-	0x9e, 0x03, 0x64, 0x0f, 0x6e, 0xc5, 0x1c, 0x63, 0x97, 0xa3, 0xb7, 0x87, 0x5c, 0xf8, 0x67, 0xfe,
-	0x28, 0xbf, 0x39, 0x67, 0xe9, 0x2f, 0x28, 0xe8, 0x55, 0x6c, 0xb2, 0x0f, 0xb7, 0x47, 0x2c, 0x0c,
-	0x7d, 0x21, 0x16, 0xad, 0xc0, 0x75, 0x56, 0xae, 0xa4, 0x93, 0x43, 0xd8, 0x50, 0x81, 0xdb, 0x3b,
-	0x77, 0xa3, 0x31, 0x16, 0x0d, 0x35, 0xae, 0x33, 0x74, 0xcd, 0x02, 0xe7, 0x17, 0xb0, 0xf6, 0xb2,
-	0x2d, 0xf0, 0x19, 0x86, 0xa7, 0xc8, 0x93, 0x42, 0x78, 0x8d, 0x85, 0xf0, 0x5e, 0x9d, 0xbc, 0xcb,
-	0x41, 0x2f, 0xdf, 0x1c, 0x74, 0xb3, 0x10, 0x74, 0xe7, 0x01, 0x34, 0x8a, 0x9e, 0xdd, 0x05, 0x73,
-	0x84, 0x5c, 0xd8, 0x86, 0x7a, 0xc9, 0x0a, 0x5d, 0x95, 0x75, 0x1e, 0x55, 0x6a, 0xe7, 0x4f, 0x03,
-	0x2a, 0xaa, 0x45, 0xc8, 0x96, 0x2c, 0x1d, 0xd7, 0x43, 0x9e, 0x35, 0x62, 0x53, 0x2f, 0x39, 0x50,
-	0x18, 0xcd, 0x74, 0x64, 0x13, 0xcc, 0x53, 0xe6, 0x4d, 0xd5, 0xc1, 0x1b, 0x3d, 0xc8, 0x7a, 0x8c,
-	0x79, 0x53, 0xaa, 0x70, 0xd2, 0x07, 0x6b, 0xb4, 0x14, 0x05, 0xbb, 0xac, 0x8e, 0xb0, 0x91, 0x77,
-	0xc6, 0xa2, 0x96, 0x5e, 0xe2, 0x93, 0x17, 0x70, 0xa7, 0x10, 0x63, 0x6f, 0x79, 0x85, 0x6d, 0xde,
-	0x68, 0xef, 0xc6, 0xb5, 0xce, 0xaf, 0x06, 0x54, 0xb5, 0x4b, 0x85, 0xa9, 0xc6, 0x54, 0x53, 0xcd,
-	0x26, 0x40, 0x88, 0xfc, 0x22, 0x40, 0xca, 0x98, 0xc8, 0xae, 0x97, 0x02, 0x22, 0x9f, 0xca, 0x98,
-	0xe3, 0x44, 0x45, 0xeb, 0xc0, 0x4d, 0xce, 0xb3, 0x0b, 0x6f, 0x11, 0x24, 0x5d, 0x30, 0xe5, 0x84,
-	0xa7, 0x72, 0xd3, 0xe8, 0xb5, 0xbb, 0x7a, 0xfc, 0xeb, 0xe6, 0xe3, 0x5f, 0xf7, 0x38, 0x1f, 0xff,
-	0xa8, 0xe2, 0x39, 0xdf, 0x80, 0x29, 0xc3, 0x47, 0x76, 0xf3, 0xdd, 0x8f, 0x39, 0xe6, 0x77, 0xa1,
-	0xa5, 0x5d, 0x7c, 0x36, 0xc3, 0x69, 0x81, 0x23, 0xdf, 0xd3, 0xe3, 0xd7, 0x89, 0x5d, 0xea, 0x94,
-	0xe5, 0xd4, 0x76, 0xfc, 0x3a, 0x71, 0x7a, 0x00, 0x73, 0x2e, 0xd9, 0x02, 0x53, 0x79, 0x72, 0x85,
-	0xad, 0xe7, 0xcc, 0x43, 0xaa, 0xb4, 0xce, 0x8b, 0x7c, 0x8d, 0xc4, 0xc8, 0x26, 0x18, 0x4f, 0xaf,
-	0x5d, 0x60, 0x3c, 0x95, 0x7a, 0x9a, 0xe5, 0xfe, 0x0a, 0x3d, 0x25, 0x4d, 0x30, 0x1e, 0x65, 0x71,
-	0x31, 0x1e, 0xed, 0x8c, 0xa1, 0x3e, 0x7b, 0x45, 0xc8, 0x2d, 0x58, 0x57, 0xef, 0xe0, 0x3c, 0x3b,
-	0xd6, 0x0a, 0xb1, 0xf4, 0xe3, 0xf8, 0x1c, 0x5f, 0x29, 0xdc, 0x32, 0x08, 0x81, 0x96, 0x5a, 0xc3,
-	0x71, 0xa0, 0xdb, 0xde, 0x2a, 0x91, 0x75, 0xfd, 0x5e, 0xe5, 0x40, 0x99, 0xb4, 0x00, 0x24, 0xa0,
-	0xb3, 0x6b, 0x99, 0x3b, 0xaf, 0xa0, 0x3e, 0x2c, 0x6e, 0x34, 0xbc, 0xb4, 0x11, 0x81, 0xd6, 0x70,
-	0xd1, 0xac, 0x21, 0xcd, 0x0e, 0x0b, 0x66, 0x4b, 0xd2, 0xec, 0x70, 0x6e, 0xb6, 0x9c, 0xcb, 0x7a,
-	0xa6, 0xb1, 0x4c, 0x79, 0xda, 0x61, 0xf1, 0xb4, 0x95, 0x9d, 0x13, 0x68, 0x2d, 0x0e, 0x79, 0xa4,
-	0x06, 0xe6, 0xa1, 0x17, 0xc8, 0x2d, 0xe5, 0xa9, 0x67, 0xdb, 0x49, 0xd7, 0x9a, 0x50, 0x9b, 0x49,
-	0x25, 0xb2, 0x06, 0xf5, 0xbc, 0x3a, 0x3d, 0xab, 0x2c, 0x95, 0xf9, 0xec, 0x64, 0x99, 0x3b, 0x1f,
-	0x42, 0x6b, 0xf1, 0x0d, 0x22, 0x0d, 0x58, 0x3d, 0x4a, 0x47, 0x23, 0x4c, 0x12, 0x6b, 0x85, 0x00,
-	0x54, 0x1f, 0xbb, 0x7e, 0x20, 0xad, 0xee, 0x9c, 0xc1, 0x5b, 0xd7, 0xbc, 0x36, 0x72, 0x8d, 0x2c,
-	0xb7, 0xef, 0x52, 0x61, 0xad, 0x48, 0xe1, 0x30, 0x9a, 0x48, 0x82, 0x65, 0x48, 0xcf, 0xfa, 0xae,
-	0x97, 0x5d, 0x05, 0x3a, 0xc2, 0x4a, 0xd6, 0x5b, 0x5a, 0x65, 0xe9, 0xaa, 0xf2, 0xf1, 0x98, 0xb1,
-	0xc7, 0x6e, 0x22, 0x63, 0xfc, 0x15, 0xac, 0x2d, 0x8c, 0xee, 0xd2, 0x60, 0x36, 0x6f, 0xeb, 0x13,
-	0xf5, 0xdd, 0xd1, 0x45, 0x1a, 0x5b, 0x86, 0x4c, 0xc0, 0x52, 0xdf, 0x59, 0xa5, 0xde, 0x0f, 0x50,
-	0x7d, 0xc2, 0x92, 0xc4, 0x8f, 0x49, 0x0f, 0x9a, 0xfa, 0xeb, 0x48, 0x70, 0x74, 0x43, 0xd2, 0xd2,
-	0x85, 0x94, 0x8f, 0x79, 0xed, 0x25, 0x79, 0xdb, 0xd8, 0x35, 0x48, 0x27, 0xfb, 0x49, 0xca, 0x1e,
-	0x75, 0x35, 0x1b, 0xb6, 0x8b, 0x42, 0xef, 0x67, 0x19, 0xce, 0xec, 0x78, 0x72, 0xfe, 0x3f, 0x42,
-	0x3e, 0xc1, 0x79, 0xf5, 0x15, 0x66, 0xbc, 0xcc, 0xeb, 0x36, 0x29, 0x42, 0xd9, 0x3c, 0x90, 0x2f,
-	0x1c, 0x2e, 0x2f, 0x1c, 0x5e, 0x5e, 0x58, 0x1c, 0x24, 0x7a, 0x81, 0xcc, 0x08, 0x0f, 0x91, 0x93,
-	0x7b, 0xd0, 0x7c, 0x82, 0x62, 0xfe, 0xf3, 0xb4, 0x70, 0xe4, 0xf5, 0xa5, 0x3f, 0x0a, 0xf2, 0x00,
-	0x48, 0x91, 0x9d, 0x85, 0xe4, 0xc6, 0x35, 0xbb, 0x46, 0xef, 0x77, 0x03, 0x4c, 0x29, 0x93, 0x2d,
-	0xa8, 0xc9, 0xb8, 0xa8, 0x9f, 0xc4, 0xec, 0x32, 0x96, 0x72, 0x3b, 0xff, 0x66, 0xd1, 0xd8, 0x59,
-	0x91, 0x47, 0x3a, 0x42, 0x31, 0xff, 0x4f, 0xcc, 0x2d, 0xe6, 0xc0, 0x42, 0x24, 0x73, 0x07, 0x66,
-	0xec, 0x2b, 0x0f, 0x33, 0xd3, 0x3e, 0x84, 0xff, 0x17, 0xd9, 0x5f, 0x07, 0xc1, 0x4d, 0x3e, 0xe4,
-	0xb4, 0x5d, 0xa3, 0xbf, 0x05, 0x1b, 0x23, 0x16, 0x76, 0xa7, 0x2c, 0xc1, 0x38, 0x40, 0xd4, 0x04,
-	0xf9, 0x3e, 0xf6, 0x6b, 0xf2, 0x53, 0xba, 0x37, 0x30, 0x4e, 0xab, 0xea, 0xe2, 0xfc, 0xe4, 0xef,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0x41, 0xf5, 0x60, 0xaf, 0x59, 0x0f, 0x00, 0x00,
+	// 3271 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x3a, 0xcd, 0x6f, 0x1c, 0x49,
+	0xf5, 0xae, 0xe9, 0x1e, 0x67, 0xe6, 0x8d, 0x3d, 0x6e, 0x57, 0x1c, 0xa7, 0x77, 0x7e, 0xfb, 0xcb,
+	0xce, 0xf6, 0x26, 0x8b, 0x31, 0x1b, 0x27, 0x72, 0x76, 0xc9, 0x82, 0x58, 0x56, 0xb1, 0xe3, 0xc4,
+	0xce, 0x26, 0x59, 0xab, 0xc6, 0x9b, 0x1d, 0x16, 0x09, 0xa9, 0x3d, 0x5d, 0x1e, 0xb7, 0xd2, 0xd3,
+	0xd5, 0xdb, 0xdd, 0xe3, 0xd8, 0x20, 0xb8, 0xac, 0x90, 0x40, 0xe2, 0x02, 0x57, 0x4e, 0x5c, 0x38,
+	0x72, 0xe2, 0x88, 0x38, 0xc1, 0x05, 0x24, 0xfe, 0x07, 0x2e, 0xdc, 0x10, 0x12, 0x67, 0x0e, 0xa8,
+	0x3e, 0xba, 0xbb, 0xba, 0x67, 0xc6, 0x89, 0x57, 0x20, 0x71, 0xeb, 0xf7, 0x51, 0xaf, 0xab, 0xde,
+	0x7b, 0xf5, 0xbe, 0xba, 0x01, 0xa2, 0x60, 0x3c, 0xda, 0x88, 0x62, 0x96, 0x32, 0x6c, 0xf2, 0xe7,
+	0xce, 0x1b, 0x43, 0xc6, 0x86, 0x01, 0xbd, 0x25, 0x70, 0x87, 0xe3, 0xa3, 0x5b, 0xa9, 0x3f, 0xa2,
+	0x49, 0xea, 0x8e, 0x22, 0xc9, 0xe6, 0x74, 0xc0, 0xdc, 0xf7, 0xc3, 0x21, 0xc6, 0x60, 0x86, 0xee,
+	0x88, 0xda, 0xa8, 0x8b, 0xd6, 0x9a, 0x44, 0x3c, 0x3b, 0x5d, 0x30, 0xf7, 0x59, 0x38, 0xc4, 0x36,
+	0x5c, 0x1a, 0xd1, 0x24, 0x71, 0x87, 0x19, 0x39, 0x03, 0x9d, 0x2f, 0x6a, 0x50, 0xdf, 0xa5, 0x41,
+	0xc0, 0x70, 0x1b, 0x6a, 0xbe, 0x27, 0xc8, 0x8b, 0xa4, 0xe6, 0x7b, 0x5c, 0x9e, 0x1f, 0x9d, 0xbc,
+	0x6b, 0xd7, 0xa4, 0x3c, 0xfe, 0xcc, 0x71, 0x11, 0x8b, 0x53, 0xdb, 0x90, 0x38, 0xfe, 0x8c, 0x5f,
+	0x87, 0x66, 0x34, 0x3e, 0x0c, 0xfc, 0xc1, 0x47, 0xf4, 0xcc, 0x36, 0xbb, 0x68, 0x6d, 0x81, 0x14,
+	0x08, 0xbc, 0x06, 0x4b, 0x62, 0x9b, 0x03, 0x16, 0x3c, 0xa3, 0x71, 0xe2, 0xb3, 0xd0, 0xae, 0x8b,
+	0x57, 0x54, 0xd1, 0x7c, 0x8f, 0x83, 0x63, 0xd7, 0x0f, 0xf7, 0x3c, 0x7b, 0x5e, 0xee, 0x51, 0x81,
+	0x78, 0x15, 0xe6, 0x8f, 0xa9, 0x3f, 0x3c, 0x4e, 0xed, 0x4b, 0x5d, 0xb4, 0x66, 0x12, 0x05, 0xe1,
+	0x15, 0xa8, 0xc7, 0xd4, 0xf5, 0xce, 0xec, 0x46, 0x17, 0xad, 0x35, 0x88, 0x04, 0x38, 0x36, 0x64,
+	0xe1, 0x80, 0xda, 0x4d, 0xc1, 0x2c, 0x01, 0xbe, 0xcb, 0xc4, 0x1f, 0x86, 0x6e, 0x3a, 0x8e, 0xa9,
+	0x0d, 0x72, 0x97, 0x39, 0xc2, 0xf9, 0x04, 0x9a, 0xfb, 0xf9, 0x96, 0xbf, 0xac, 0x22, 0x2c, 0x30,
+	0x9e, 0xe7, 0x2a, 0xe0, 0x8f, 0xce, 0xbf, 0x9a, 0xd0, 0xdc, 0xa7, 0x34, 0xee, 0xa5, 0x6e, 0x4a,
+	0xbf, 0xb4, 0xdc, 0xaf, 0x80, 0x19, 0xb3, 0x80, 0x0a, 0xc1, 0xed, 0xcd, 0xcb, 0x1b, 0xc2, 0x45,
+	0xb6, 0x59, 0x98, 0xd0, 0x30, 0x19, 0x27, 0x84, 0x05, 0x94, 0x08, 0x06, 0xfc, 0x36, 0xb4, 0x07,
+	0x05, 0x7a, 0x1c, 0x7a, 0x42, 0xd5, 0x26, 0xa9, 0x60, 0x05, 0xdf, 0x38, 0x8e, 0x69, 0x98, 0xee,
+	0xc7, 0xfe, 0xc8, 0x8d, 0xcf, 0x84, 0xc2, 0x17, 0x49, 0x05, 0x8b, 0xef, 0x6a, 0xf2, 0xf6, 0x8f,
+	0xdd, 0x84, 0x0a, 0xfd, 0xb7, 0x37, 0x97, 0xe4, 0x16, 0xf6, 0xb7, 0x1e, 0x1c, 0x08, 0x34, 0xa9,
+	0xb0, 0xe1, 0x9b, 0x60, 0x9e, 0xb0, 0x94, 0xda, 0x8d, 0xae, 0xb1, 0xd6, 0xda, 0x7c, 0x4d, 0xb1,
+	0x67, 0x8a, 0xd8, 0x78, 0xc6, 0x52, 0xba, 0x13, 0xa6, 0xf1, 0x19, 0x11, 0x6c, 0xf8, 0x5b, 0xda,
+	0x7b, 0x04, 0x87, 0x30, 0x5d, 0x7b, 0x73, 0xa5, 0x72, 0x54, 0x41, 0x23, 0x15, 0x5e, 0xdc, 0x85,
+	0xd6, 0x61, 0xc0, 0x06, 0xcf, 0x77, 0xa5, 0x8b, 0x80, 0x38, 0xb2, 0x8e, 0xe2, 0x1c, 0x9f, 0x8f,
+	0xe9, 0x98, 0x3e, 0xa6, 0xe1, 0x30, 0x3d, 0xb6, 0x5b, 0x92, 0x43, 0x43, 0xe1, 0x6b, 0x00, 0xc7,
+	0xd4, 0x8d, 0x14, 0xc3, 0x42, 0x17, 0xad, 0x19, 0x44, 0xc3, 0x70, 0x7a, 0x4c, 0xa3, 0x71, 0xea,
+	0xa6, 0xdc, 0x81, 0x17, 0xbb, 0x68, 0x0d, 0x11, 0x0d, 0x83, 0xaf, 0xc3, 0x62, 0x42, 0x03, 0x3a,
+	0x48, 0xa9, 0xb7, 0xcd, 0xc6, 0x61, 0x6a, 0xb7, 0xc5, 0x3b, 0xca, 0x48, 0xfc, 0x75, 0x58, 0x4d,
+	0x69, 0xc8, 0x97, 0x9c, 0xd0, 0x5e, 0x89, 0x7d, 0x49, 0xb0, 0xcf, 0xa0, 0xe2, 0x9b, 0xd0, 0x3c,
+	0x3d, 0x3c, 0x4a, 0xa5, 0x09, 0x2c, 0xdd, 0x04, 0xfd, 0xdc, 0x04, 0x05, 0x07, 0x3f, 0xae, 0x00,
+	0x94, 0x6d, 0x97, 0x85, 0x6d, 0x75, 0x14, 0xde, 0x02, 0x6b, 0xc0, 0x46, 0x23, 0x3f, 0x4d, 0x29,
+	0x7d, 0x42, 0x47, 0x87, 0x34, 0x4e, 0x6c, 0x2c, 0x6c, 0xb5, 0x9a, 0xa9, 0xbc, 0x4c, 0x25, 0x13,
+	0xfc, 0x78, 0x07, 0x16, 0x06, 0x34, 0x4e, 0xfd, 0x23, 0x7f, 0xe0, 0xa6, 0x34, 0xb1, 0x2f, 0x8b,
+	0xf5, 0x6f, 0x56, 0x6d, 0xbd, 0xad, 0xf1, 0x48, 0x9b, 0x97, 0x96, 0xe1, 0x0f, 0x01, 0xd2, 0xe3,
+	0x98, 0x26, 0xc7, 0x2c, 0xf0, 0x12, 0x7b, 0x45, 0x08, 0x79, 0xa3, 0x2a, 0xe4, 0x20, 0xe7, 0x90,
+	0x22, 0xb4, 0x25, 0xf8, 0x23, 0x68, 0x17, 0x86, 0xd8, 0x62, 0xec, 0xb9, 0x7d, 0x45, 0x08, 0x79,
+	0xab, 0x2a, 0x84, 0x94, 0xb8, 0xa4, 0xa0, 0xca, 0x52, 0x1e, 0xad, 0x52, 0x96, 0xba, 0x41, 0xc1,
+	0x6b, 0xaf, 0x0a, 0x63, 0x57, 0xd1, 0xfc, 0x0e, 0x9d, 0xb8, 0x81, 0xef, 0xb9, 0x29, 0x8b, 0x9f,
+	0x8a, 0x70, 0x73, 0x55, 0xde, 0xb5, 0x32, 0xb6, 0x73, 0x17, 0x9a, 0xb9, 0xbb, 0x67, 0x11, 0x82,
+	0x87, 0x80, 0xba, 0x88, 0x10, 0x3c, 0x58, 0x9d, 0xb8, 0xc1, 0x98, 0x8a, 0x20, 0x50, 0x27, 0x12,
+	0xf8, 0x66, 0xed, 0x7d, 0xd4, 0xf9, 0x10, 0x96, 0x27, 0x74, 0x77, 0x21, 0x01, 0x1f, 0xc0, 0x52,
+	0x45, 0x6f, 0x17, 0x5a, 0x7e, 0x0f, 0x2e, 0x4f, 0xd1, 0x98, 0x2e, 0x62, 0x71, 0x8a, 0x08, 0xa4,
+	0x89, 0x70, 0x6e, 0x03, 0x6c, 0xf1, 0x6b, 0x48, 0xdc, 0x70, 0x48, 0x79, 0x68, 0x3b, 0x8a, 0xd9,
+	0x48, 0x2c, 0x35, 0x89, 0x78, 0xe6, 0x21, 0x31, 0x65, 0x62, 0xa1, 0x49, 0x6a, 0x29, 0x73, 0x7c,
+	0x68, 0xf6, 0xce, 0xc2, 0x81, 0x58, 0x85, 0xdf, 0x84, 0xba, 0xb8, 0xc5, 0x62, 0x45, 0x6b, 0xb3,
+	0x25, 0x0d, 0x2a, 0x25, 0x4a, 0x0a, 0xfe, 0x06, 0xb4, 0x34, 0x6f, 0x12, 0x82, 0x5a, 0x9b, 0x57,
+	0x75, 0x1f, 0xd6, 0x74, 0x48, 0x74, 0x5e, 0xe7, 0xb7, 0x35, 0x68, 0xf4, 0x42, 0x37, 0x4a, 0x8e,
+	0x59, 0xaa, 0x65, 0x18, 0x54, 0xca, 0x30, 0xd7, 0x39, 0xde, 0xf5, 0x68, 0xac, 0x44, 0x2f, 0x48,
+	0xd1, 0xbb, 0x02, 0x47, 0x14, 0x0d, 0xaf, 0x43, 0xc3, 0x8d, 0x22, 0x19, 0xb9, 0x0c, 0xc1, 0xd7,
+	0x96, 0x7c, 0xf7, 0x14, 0x96, 0xe4, 0x74, 0xfc, 0x68, 0xc2, 0x5d, 0x4d, 0xe1, 0xae, 0x8e, 0x5c,
+	0x91, 0xed, 0xe8, 0x95, 0xbc, 0xf5, 0x16, 0x40, 0xee, 0x6d, 0x89, 0x5d, 0x17, 0x72, 0x54, 0x60,
+	0x78, 0x96, 0xe1, 0x89, 0xc6, 0xf2, 0x9f, 0xb0, 0xe9, 0x1f, 0x10, 0x58, 0x85, 0x0c, 0x42, 0x07,
+	0x2c, 0xf6, 0x66, 0xaa, 0xef, 0x26, 0xcc, 0xd3, 0x13, 0x1a, 0xa6, 0x89, 0x5d, 0x13, 0x9b, 0xbb,
+	0x22, 0x37, 0x57, 0xac, 0xdf, 0xe1, 0x54, 0xa2, 0x98, 0xa6, 0xdc, 0x29, 0x63, 0xda, 0x9d, 0xc2,
+	0xf7, 0xc0, 0xca, 0x31, 0x9f, 0x44, 0x9e, 0x08, 0x3f, 0xa6, 0xfe, 0x82, 0x67, 0x65, 0x2a, 0x99,
+	0x60, 0x77, 0x7e, 0x5a, 0x83, 0xa5, 0xca, 0x36, 0xf8, 0x29, 0x22, 0x4a, 0xe3, 0xbd, 0x2c, 0x47,
+	0x2b, 0x88, 0x67, 0xb3, 0xf4, 0x2c, 0x92, 0xba, 0x68, 0x67, 0xd9, 0xac, 0xb2, 0xf8, 0xe0, 0x2c,
+	0xa2, 0x44, 0xb0, 0x71, 0x31, 0x87, 0xf4, 0x88, 0xc5, 0x72, 0xf7, 0x88, 0x28, 0x88, 0xeb, 0xd4,
+	0x3d, 0x4a, 0x69, 0x2c, 0xf2, 0x38, 0x22, 0x12, 0xd0, 0x54, 0x57, 0x9f, 0xa8, 0x6d, 0x44, 0x0a,
+	0x9f, 0x17, 0x68, 0x09, 0x70, 0xac, 0x47, 0x83, 0xd4, 0x15, 0x89, 0x18, 0x11, 0x09, 0x70, 0x19,
+	0x31, 0x75, 0x13, 0x16, 0x8a, 0x42, 0xa8, 0x49, 0x14, 0x84, 0x6f, 0x80, 0x99, 0x04, 0x2c, 0x15,
+	0xd9, 0xb4, 0xb5, 0xb9, 0xac, 0x3c, 0x2c, 0x70, 0x93, 0x63, 0xea, 0xf5, 0x02, 0x96, 0x12, 0x41,
+	0x76, 0xbe, 0x40, 0xd0, 0xd2, 0xb0, 0x5c, 0x5c, 0x42, 0x43, 0xee, 0xf4, 0x4a, 0x0f, 0x12, 0xd2,
+	0xb6, 0x5a, 0x9b, 0xbe, 0x55, 0xa3, 0xb2, 0xd5, 0x48, 0x24, 0x2c, 0x53, 0xec, 0x49, 0x02, 0xbc,
+	0xc8, 0x8b, 0x54, 0x5e, 0x92, 0x65, 0x60, 0x06, 0x3a, 0x21, 0xd8, 0x85, 0x4e, 0x77, 0xfd, 0x24,
+	0x65, 0xf1, 0x19, 0xa1, 0x9f, 0x8f, 0x69, 0x32, 0xdb, 0x32, 0xd7, 0x00, 0x78, 0x18, 0xd9, 0xd5,
+	0x77, 0xa5, 0x61, 0x70, 0x07, 0x1a, 0x29, 0x53, 0x54, 0xb9, 0xb9, 0x1c, 0x76, 0x3e, 0x83, 0xe5,
+	0x89, 0xf7, 0x9d, 0xe3, 0x02, 0x17, 0x71, 0x64, 0xe7, 0x5d, 0x68, 0x09, 0xc4, 0x03, 0x3f, 0xe0,
+	0x36, 0xbe, 0x01, 0x75, 0xee, 0x19, 0x89, 0x8d, 0xba, 0x46, 0x91, 0xbb, 0x0b, 0xbf, 0x91, 0x54,
+	0xe7, 0xd7, 0x08, 0x16, 0x1f, 0xb3, 0xe1, 0x90, 0x7a, 0x4f, 0x64, 0x71, 0x8e, 0xdf, 0x87, 0x66,
+	0x5e, 0xed, 0xab, 0x28, 0xd8, 0xd9, 0x90, 0xfd, 0xc0, 0x46, 0xd6, 0x0f, 0x6c, 0x1c, 0x64, 0x1c,
+	0xa4, 0x60, 0xe6, 0x35, 0x63, 0x74, 0x78, 0x94, 0xda, 0x35, 0xdd, 0xf4, 0xbc, 0x60, 0x53, 0x2a,
+	0xdd, 0x9d, 0x23, 0x82, 0x81, 0x33, 0xf2, 0xca, 0xc0, 0x36, 0x74, 0xc6, 0x7e, 0x99, 0x91, 0x33,
+	0x6c, 0x35, 0xe1, 0x52, 0x2c, 0x51, 0xce, 0x77, 0x61, 0x59, 0xed, 0xf0, 0x31, 0x1b, 0x66, 0x36,
+	0xca, 0xbd, 0x00, 0xe9, 0x5e, 0xc0, 0x15, 0xca, 0x0d, 0x2f, 0x15, 0xd7, 0x24, 0x0a, 0xe2, 0x7e,
+	0x20, 0xbd, 0x2a, 0xb1, 0x8d, 0xae, 0xc1, 0xfd, 0x40, 0x81, 0xce, 0xa7, 0xd0, 0x2e, 0x84, 0xdf,
+	0x1f, 0x8f, 0xa2, 0x19, 0x92, 0x6f, 0x41, 0x43, 0xf5, 0x30, 0x99, 0x51, 0x54, 0x65, 0x5c, 0x52,
+	0x21, 0xc9, 0x99, 0x9c, 0x5f, 0x1a, 0x50, 0x97, 0x17, 0xdd, 0x02, 0x23, 0xa1, 0x9f, 0x2b, 0x71,
+	0xfc, 0x11, 0xbf, 0x55, 0xba, 0xe2, 0x13, 0x06, 0x12, 0xc4, 0xb2, 0x35, 0x8c, 0x8b, 0x58, 0xa3,
+	0x70, 0x2b, 0xb3, 0xe4, 0x56, 0x17, 0xbe, 0xfc, 0x51, 0x5e, 0x85, 0xe7, 0x37, 0x8a, 0xb7, 0x5f,
+	0xf2, 0x0a, 0xed, 0x79, 0xe2, 0xfe, 0x2f, 0x92, 0x02, 0x91, 0x17, 0xc7, 0xf7, 0xfd, 0x21, 0x4d,
+	0x64, 0x24, 0x58, 0x20, 0x3a, 0x6a, 0x6a, 0x2d, 0x08, 0x17, 0xac, 0x05, 0xef, 0x81, 0x15, 0x97,
+	0xaf, 0x42, 0x62, 0xb7, 0xce, 0xbb, 0x28, 0x13, 0xec, 0x4e, 0x17, 0x1a, 0x8f, 0xd9, 0xf0, 0x31,
+	0x3d, 0xa1, 0x01, 0x3f, 0x68, 0xc0, 0x1f, 0x54, 0xaf, 0x2a, 0x01, 0xa7, 0x0f, 0x8d, 0x2c, 0x9f,
+	0x4e, 0xe9, 0x74, 0xd0, 0xd4, 0x4e, 0x67, 0xa2, 0x2e, 0xaf, 0x4d, 0xa9, 0xcb, 0x9d, 0x3f, 0x23,
+	0x58, 0x7c, 0x48, 0x43, 0x9a, 0xf8, 0xc9, 0x36, 0x0b, 0x8f, 0xfc, 0xa1, 0xde, 0x8b, 0xa2, 0x72,
+	0x2f, 0xba, 0x01, 0x26, 0xb7, 0xab, 0x5d, 0x7b, 0xa9, 0xfd, 0x05, 0x5f, 0x25, 0x47, 0x1b, 0x2f,
+	0xcd, 0xd1, 0xf8, 0x43, 0x58, 0x2a, 0xba, 0x29, 0x37, 0x76, 0x47, 0x89, 0x70, 0x9a, 0x5c, 0x95,
+	0xdb, 0x65, 0x22, 0xa9, 0x72, 0x3b, 0xdf, 0x81, 0x66, 0x2e, 0x79, 0xa2, 0xe7, 0x2c, 0x35, 0xeb,
+	0xb5, 0x6a, 0xb3, 0x5e, 0x6e, 0x73, 0x8c, 0x6a, 0x9b, 0xe3, 0xfc, 0x03, 0xc1, 0x52, 0x25, 0xb7,
+	0xe6, 0xd9, 0x11, 0xe9, 0xd9, 0xb1, 0xc2, 0xa4, 0x5d, 0xa2, 0x9b, 0xd0, 0xcc, 0x0f, 0xab, 0x94,
+	0x38, 0xa1, 0x8e, 0x82, 0x43, 0x44, 0x70, 0x37, 0x1e, 0xd2, 0x74, 0x4f, 0xa6, 0x97, 0x45, 0x92,
+	0xc3, 0x79, 0xff, 0x6c, 0x4e, 0xe9, 0x9f, 0xeb, 0x5a, 0xff, 0x7c, 0x1d, 0x16, 0x07, 0x2c, 0x4c,
+	0x5d, 0x3f, 0xa4, 0xf1, 0x53, 0x3e, 0x21, 0x91, 0xe3, 0x85, 0x32, 0xb2, 0x18, 0x1b, 0x5c, 0xd2,
+	0xc6, 0x06, 0xce, 0xaf, 0x10, 0x5c, 0xe9, 0xf9, 0xc3, 0x90, 0x7a, 0x93, 0xe7, 0x9e, 0x1f, 0x8b,
+	0x27, 0x1b, 0xe9, 0xe6, 0xa9, 0xb0, 0x11, 0xc5, 0x84, 0xdf, 0x07, 0xc8, 0xc7, 0x0d, 0x59, 0xb5,
+	0x62, 0x57, 0x96, 0xf4, 0x32, 0x06, 0xa2, 0xf1, 0x3e, 0x32, 0x1b, 0x35, 0xcb, 0x78, 0x64, 0x36,
+	0x0c, 0xcb, 0x24, 0x0d, 0x8e, 0xe7, 0xa1, 0x43, 0x1f, 0x5e, 0x3c, 0x05, 0x3c, 0x29, 0x82, 0x6b,
+	0x2e, 0x63, 0x56, 0xf6, 0xcf, 0xe1, 0xf2, 0x30, 0xa4, 0x56, 0x1d, 0x86, 0xf4, 0xa1, 0xb1, 0x73,
+	0xe2, 0x7b, 0x94, 0x97, 0x5a, 0x37, 0x54, 0x1e, 0x41, 0x5d, 0xa3, 0x48, 0x0f, 0x5a, 0x1e, 0x51,
+	0x59, 0xe4, 0x86, 0xca, 0x22, 0xb5, 0xae, 0x31, 0x35, 0x8b, 0xc8, 0x1c, 0xe2, 0xfc, 0x1e, 0xc1,
+	0x52, 0xc5, 0x7f, 0xf1, 0x3b, 0xb0, 0x3c, 0xa2, 0xf1, 0xf3, 0x80, 0x1e, 0xc4, 0x94, 0x66, 0x23,
+	0x22, 0xb9, 0xe1, 0x49, 0x02, 0xde, 0xd5, 0x23, 0xcd, 0x3e, 0x0b, 0xfc, 0xc1, 0x99, 0xf2, 0xa2,
+	0xd7, 0xab, 0x91, 0x46, 0x52, 0xd5, 0x2d, 0x99, 0x58, 0x85, 0xef, 0x40, 0x53, 0x46, 0x81, 0xcc,
+	0xd5, 0x73, 0x13, 0xf6, 0x32, 0xb4, 0x5a, 0x5b, 0xf0, 0x39, 0x7f, 0x41, 0xb0, 0x54, 0x21, 0x73,
+	0x65, 0xe6, 0xed, 0xa8, 0xd8, 0x38, 0x22, 0x05, 0x02, 0xaf, 0xeb, 0x1b, 0xfe, 0xb4, 0x28, 0x54,
+	0x10, 0x99, 0xc0, 0xe3, 0x1d, 0x58, 0xce, 0x43, 0x6b, 0xcf, 0xff, 0x3e, 0x25, 0xe3, 0x40, 0x16,
+	0x91, 0xed, 0x72, 0x4f, 0xa3, 0x91, 0xc9, 0xe4, 0x0a, 0xfe, 0xca, 0x91, 0x1f, 0x96, 0x58, 0x55,
+	0xde, 0x99, 0xc0, 0x3b, 0x7f, 0x42, 0xb0, 0x3a, 0x5d, 0x65, 0xd3, 0xe6, 0x89, 0xdc, 0xa9, 0xfc,
+	0x70, 0xc0, 0xcb, 0xcb, 0xac, 0x35, 0xc8, 0x61, 0x4e, 0xf3, 0xa8, 0xa2, 0xc9, 0xd0, 0x91, 0xc3,
+	0xb2, 0x6e, 0x1d, 0xb8, 0x67, 0x59, 0xed, 0x2b, 0x00, 0xfe, 0x86, 0x43, 0xce, 0x5d, 0x17, 0x48,
+	0xf1, 0xcc, 0x53, 0xe2, 0x0b, 0x3f, 0xf4, 0xd8, 0x0b, 0x95, 0xfb, 0x14, 0xc4, 0x73, 0xf6, 0xc8,
+	0x0f, 0x55, 0xdd, 0xcb, 0x1f, 0x05, 0xc6, 0x3d, 0xb5, 0x1b, 0x0a, 0xe3, 0x9e, 0x3a, 0x97, 0xa0,
+	0xbe, 0x33, 0x8a, 0xd2, 0x33, 0x67, 0x0b, 0x1a, 0x3b, 0xe1, 0x09, 0x0d, 0x58, 0x24, 0x2b, 0x4e,
+	0xf7, 0x2c, 0x60, 0xae, 0x34, 0xce, 0x02, 0xc9, 0xc0, 0x97, 0xdc, 0x82, 0xdf, 0x71, 0x53, 0xfb,
+	0xc3, 0xd0, 0x0f, 0x87, 0xba, 0xac, 0x19, 0x69, 0x61, 0xca, 0x98, 0xb3, 0x36, 0x7d, 0xcc, 0x79,
+	0x07, 0x5a, 0xaa, 0x24, 0xe1, 0x51, 0x51, 0x99, 0x57, 0xdd, 0x98, 0x27, 0x05, 0x81, 0xe8, 0x5c,
+	0x5a, 0xa1, 0x60, 0x96, 0x0a, 0x05, 0xed, 0x70, 0xf5, 0xd2, 0xe1, 0x9c, 0x1f, 0x40, 0x4b, 0xbb,
+	0xa6, 0xf8, 0x6b, 0xe5, 0x01, 0x70, 0xe9, 0x2a, 0xab, 0xb7, 0xe6, 0x33, 0xe1, 0xf3, 0x15, 0x53,
+	0xb4, 0xe5, 0xc6, 0xac, 0xb6, 0xdc, 0xf9, 0x39, 0x82, 0x05, 0xf9, 0xf6, 0x24, 0xe2, 0xd7, 0x1d,
+	0xbf, 0x03, 0xf3, 0x49, 0xea, 0xa6, 0xe3, 0xc4, 0x46, 0xfa, 0x64, 0x2f, 0xa3, 0xf7, 0x04, 0x8d,
+	0x28, 0x1e, 0x8c, 0xc1, 0x18, 0x25, 0x43, 0xf9, 0xe6, 0xdd, 0x39, 0xc2, 0x01, 0xfc, 0x1e, 0xd4,
+	0x69, 0x1c, 0xb3, 0x58, 0x29, 0xec, 0xff, 0x2b, 0xc9, 0x50, 0x05, 0x40, 0x9f, 0x85, 0xdb, 0xcc,
+	0xa3, 0xbb, 0x73, 0x44, 0x72, 0x6f, 0x35, 0x78, 0x6b, 0x94, 0x8c, 0x83, 0xd4, 0xf9, 0x05, 0x82,
+	0x96, 0x76, 0x5a, 0x5e, 0x94, 0xcb, 0x6a, 0x0a, 0x4d, 0x9f, 0x69, 0x4a, 0x6a, 0x51, 0x8a, 0xd5,
+	0x2a, 0x65, 0xad, 0x27, 0x2b, 0x2a, 0x43, 0xa8, 0x47, 0x41, 0x17, 0x2d, 0xf4, 0xb8, 0x95, 0xfa,
+	0xaf, 0x60, 0xa5, 0xfe, 0x7f, 0xcd, 0x4a, 0xfd, 0xff, 0x31, 0x2b, 0xfd, 0xd1, 0x80, 0x96, 0x76,
+	0xda, 0x19, 0x56, 0xea, 0xbf, 0xb2, 0x95, 0x8e, 0xf5, 0xe6, 0x4f, 0x41, 0x9a, 0xf5, 0xcc, 0x19,
+	0xd6, 0xab, 0x97, 0xac, 0xf7, 0x36, 0xb4, 0xf3, 0x14, 0xf0, 0x4c, 0x8c, 0x45, 0xe6, 0x45, 0xd0,
+	0xa9, 0x60, 0x45, 0x81, 0x1e, 0x33, 0x76, 0x24, 0xa2, 0xd4, 0x02, 0x91, 0xc0, 0x4b, 0x0a, 0xf4,
+	0x6d, 0xb8, 0x1c, 0xc5, 0x34, 0x72, 0x63, 0xea, 0x69, 0xa3, 0xaa, 0x72, 0xcb, 0xae, 0x11, 0xc8,
+	0x34, 0x6e, 0xbc, 0x03, 0x2b, 0x59, 0x1a, 0x28, 0x49, 0x81, 0x59, 0x52, 0xa6, 0xb2, 0xe3, 0x3d,
+	0x58, 0x15, 0x8a, 0xdb, 0x3e, 0xe6, 0x03, 0x3b, 0x5d, 0x50, 0x6b, 0x96, 0xa0, 0x19, 0x0b, 0x9c,
+	0x1f, 0x81, 0x55, 0xed, 0x1b, 0x66, 0x36, 0xd7, 0xd3, 0x8d, 0x37, 0xa9, 0x74, 0xe3, 0x7c, 0xa5,
+	0x9b, 0x9a, 0xd2, 0x79, 0x07, 0xae, 0x9f, 0xec, 0x06, 0x98, 0x03, 0x1a, 0x57, 0xca, 0x98, 0x52,
+	0x7d, 0xc2, 0xc9, 0xce, 0x3f, 0x11, 0x2c, 0x4f, 0x8c, 0x0d, 0x67, 0x4e, 0xb7, 0x2a, 0xbd, 0x55,
+	0x6d, 0xb2, 0xb7, 0xba, 0x03, 0x2d, 0x5e, 0x1e, 0x49, 0x91, 0x59, 0xf5, 0x3f, 0xa5, 0x88, 0xd2,
+	0xb9, 0xf0, 0x5d, 0x58, 0x3c, 0xcd, 0xc1, 0x94, 0x7a, 0xaa, 0xfc, 0x9f, 0xa2, 0xfc, 0x32, 0x1f,
+	0x7e, 0x0f, 0x16, 0x38, 0x22, 0xfb, 0x76, 0x60, 0xd7, 0x67, 0xad, 0x2b, 0xb1, 0x39, 0x7f, 0x47,
+	0x50, 0x97, 0x03, 0xd7, 0x62, 0xda, 0x89, 0xce, 0x99, 0x76, 0x5e, 0x03, 0xf3, 0x90, 0x79, 0x59,
+	0xd9, 0x05, 0x2a, 0xb0, 0x30, 0xef, 0x8c, 0x08, 0xfc, 0xd4, 0x86, 0xd2, 0xb8, 0x60, 0x43, 0xf9,
+	0x19, 0xbc, 0xae, 0x39, 0x96, 0x57, 0x5d, 0x61, 0x9b, 0xe7, 0xca, 0x3b, 0x77, 0xad, 0xf3, 0x37,
+	0x04, 0xf3, 0xf2, 0x48, 0x5a, 0x77, 0x64, 0x8a, 0xee, 0xe8, 0x1a, 0x80, 0x2c, 0x39, 0x09, 0x63,
+	0x99, 0x41, 0x35, 0x0c, 0xef, 0x24, 0xa2, 0x98, 0x9e, 0x08, 0x6d, 0xed, 0xba, 0xc9, 0xb1, 0x8a,
+	0xfe, 0x65, 0x64, 0xde, 0x22, 0x9a, 0xaf, 0xd8, 0x22, 0x4e, 0xad, 0x80, 0xeb, 0xb3, 0x2a, 0xe0,
+	0x35, 0xde, 0x1f, 0xaa, 0x23, 0x29, 0xcf, 0x9b, 0x17, 0xbb, 0xa8, 0xa2, 0x9d, 0x75, 0x30, 0xb9,
+	0x59, 0x78, 0xa1, 0x74, 0x70, 0x2a, 0x87, 0x24, 0x0b, 0x84, 0x3f, 0x3e, 0x32, 0x1b, 0xc8, 0xaa,
+	0x11, 0x28, 0x84, 0xaf, 0xff, 0x0c, 0x41, 0x33, 0x9f, 0x77, 0x60, 0x0b, 0x16, 0x44, 0x78, 0x55,
+	0x2a, 0xb4, 0xe6, 0xf0, 0x32, 0x2c, 0x8a, 0x03, 0xde, 0x8b, 0x22, 0x1a, 0x7a, 0xd4, 0xb3, 0x10,
+	0xb6, 0x61, 0x85, 0x14, 0x7a, 0x3e, 0x88, 0xfd, 0xe1, 0x90, 0xc6, 0xd4, 0xb3, 0x6a, 0x18, 0x43,
+	0x5b, 0x7d, 0x69, 0xca, 0x04, 0x18, 0xf8, 0x0a, 0x2c, 0x17, 0x95, 0xa7, 0x72, 0x3d, 0xcb, 0xe4,
+	0xe8, 0xa2, 0xfc, 0x94, 0x2d, 0x93, 0x67, 0xd5, 0xd7, 0x7f, 0x8c, 0xa0, 0xf5, 0xa4, 0x54, 0xff,
+	0xe0, 0x4f, 0xc2, 0xe7, 0x21, 0x7b, 0x11, 0x6a, 0x58, 0x6b, 0x0e, 0x5f, 0x86, 0x25, 0x2d, 0xa7,
+	0x0b, 0x24, 0xe2, 0xc8, 0x7e, 0x05, 0x59, 0xc3, 0xd7, 0xa0, 0x53, 0x69, 0xcd, 0x74, 0xba, 0x81,
+	0x57, 0xc0, 0x12, 0x9f, 0xc1, 0x75, 0xac, 0xb9, 0xfe, 0x40, 0xdf, 0x75, 0x56, 0x5f, 0xaf, 0x02,
+	0x7e, 0xe0, 0x9f, 0x52, 0xaf, 0x44, 0xb1, 0xe6, 0xf0, 0x6b, 0x70, 0x65, 0xe7, 0x34, 0x52, 0x73,
+	0x05, 0x9d, 0x84, 0xd6, 0x7f, 0x83, 0xf4, 0xc9, 0x7b, 0xa1, 0xe8, 0x55, 0xc0, 0x05, 0x7a, 0x4f,
+	0x55, 0xd2, 0xd6, 0x5c, 0x19, 0x7f, 0x5f, 0x55, 0xd1, 0x16, 0xe2, 0x9a, 0x2d, 0xf0, 0x8f, 0x98,
+	0x1f, 0x5a, 0x35, 0x7e, 0xdc, 0x02, 0xf7, 0x98, 0xba, 0x27, 0xfc, 0x38, 0x25, 0xe4, 0x7d, 0x5e,
+	0x6d, 0x5b, 0x26, 0x3f, 0xa3, 0xb6, 0x89, 0xd3, 0xc8, 0x8f, 0xa9, 0x55, 0x2f, 0xb3, 0x8a, 0x39,
+	0xb0, 0x35, 0xbf, 0x4e, 0xe0, 0xf2, 0x94, 0x1e, 0x9e, 0xbb, 0x41, 0x8e, 0x16, 0xaf, 0x9f, 0xe3,
+	0x5b, 0xca, 0x51, 0xf2, 0xed, 0x88, 0xbf, 0x28, 0xc7, 0x11, 0x1a, 0x05, 0xee, 0x80, 0x5a, 0xb5,
+	0xf5, 0x21, 0x34, 0xf3, 0xf2, 0x2a, 0xb3, 0x9c, 0xe6, 0x41, 0xd6, 0x9c, 0xf0, 0xbb, 0xad, 0x07,
+	0x07, 0x4f, 0xe9, 0x0b, 0x81, 0x97, 0x07, 0x16, 0x6b, 0x62, 0xba, 0x2f, 0xf3, 0x9e, 0x55, 0xc3,
+	0x4b, 0xb2, 0x90, 0xcb, 0x10, 0x06, 0x6e, 0x03, 0x70, 0x84, 0x54, 0xba, 0x65, 0xae, 0xbf, 0x80,
+	0x66, 0x5f, 0x7f, 0x51, 0x7f, 0xe2, 0x45, 0x18, 0xda, 0xfd, 0xb2, 0x58, 0xc4, 0xc5, 0xf6, 0x35,
+	0xb1, 0x35, 0x2e, 0xb6, 0x5f, 0x88, 0x35, 0x32, 0x58, 0x7a, 0xaf, 0x65, 0xf2, 0xdd, 0xf6, 0xf5,
+	0xdd, 0xd6, 0xd7, 0x9f, 0x41, 0xbb, 0xfc, 0xb1, 0x1a, 0x37, 0xc0, 0xdc, 0xf3, 0x02, 0xfe, 0x4a,
+	0xbe, 0xeb, 0xfc, 0x75, 0xfc, 0x68, 0x0b, 0xd0, 0xc8, 0xa1, 0x1a, 0x5e, 0x84, 0x66, 0x1e, 0xc9,
+	0x2d, 0x83, 0x13, 0x8b, 0x5b, 0xb2, 0xfe, 0x55, 0x68, 0x97, 0x8b, 0x30, 0xdc, 0x82, 0x4b, 0xbd,
+	0xf1, 0x60, 0x40, 0x93, 0xc4, 0x9a, 0xc3, 0x00, 0xf3, 0x0f, 0x5c, 0x3f, 0xe0, 0x52, 0xd7, 0x8f,
+	0xe0, 0xea, 0x8c, 0x72, 0x8b, 0xaf, 0xe1, 0xa1, 0xe7, 0xe3, 0x71, 0x6a, 0xcd, 0x71, 0x60, 0x2f,
+	0x14, 0x73, 0x16, 0x0b, 0xf1, 0x93, 0x6d, 0xb9, 0x9e, 0xca, 0x46, 0x52, 0xc3, 0x02, 0x96, 0xaf,
+	0xb4, 0x0c, 0x7e, 0x54, 0x71, 0xc6, 0x03, 0xc6, 0x1e, 0xb8, 0x09, 0xd7, 0xf1, 0x07, 0xb0, 0x58,
+	0xfa, 0x05, 0x81, 0x0b, 0x54, 0x97, 0x5e, 0xee, 0x68, 0xcb, 0x1d, 0x3c, 0x1f, 0x47, 0xf2, 0x3a,
+	0x56, 0x62, 0xb0, 0x55, 0xdb, 0xfc, 0x1e, 0xcc, 0x3f, 0x64, 0x49, 0xe2, 0x47, 0x78, 0x13, 0x16,
+	0xe4, 0x53, 0x2f, 0x8d, 0xa9, 0x3b, 0xc2, 0xea, 0xd3, 0x59, 0xd6, 0x73, 0x75, 0x2a, 0xf0, 0x1a,
+	0xba, 0x8d, 0x70, 0x57, 0xfd, 0xf2, 0xa2, 0xaa, 0x5a, 0xd1, 0xf4, 0x75, 0x74, 0x60, 0xf3, 0x87,
+	0xd0, 0xcc, 0xb7, 0xc7, 0xff, 0x63, 0xe8, 0xd1, 0xf8, 0x84, 0x16, 0xde, 0x37, 0x99, 0x83, 0x3b,
+	0x58, 0x47, 0xa9, 0x82, 0x38, 0x5b, 0xd8, 0xaf, 0x2e, 0xec, 0x4f, 0x2e, 0xd4, 0x2b, 0xe9, 0xcd,
+	0xbf, 0xd6, 0xb8, 0x49, 0xe2, 0x11, 0x8d, 0xf1, 0x3b, 0xb0, 0xf0, 0x90, 0xa6, 0xc5, 0x5f, 0x20,
+	0xa5, 0x3d, 0x2f, 0x55, 0x3e, 0x52, 0xe3, 0x77, 0x01, 0xeb, 0xdc, 0x4a, 0x27, 0xe7, 0xae, 0xb9,
+	0x8d, 0xf0, 0xc7, 0xb0, 0xf2, 0x90, 0xa6, 0x93, 0x9f, 0x33, 0xae, 0x55, 0x67, 0x22, 0xe5, 0xef,
+	0x2a, 0x9d, 0xab, 0x33, 0xe8, 0xf8, 0x26, 0xb4, 0x7a, 0x34, 0xcd, 0x07, 0xb2, 0xed, 0x7c, 0xb2,
+	0x2e, 0xe0, 0x4e, 0x05, 0xc6, 0x77, 0x60, 0xa9, 0x37, 0x3e, 0x4c, 0x06, 0xb1, 0x7f, 0x48, 0xe5,
+	0x3c, 0x37, 0x53, 0x94, 0xf6, 0x19, 0xa4, 0xd3, 0xd2, 0x50, 0xb7, 0x11, 0xfe, 0x36, 0x1f, 0xba,
+	0xa6, 0xc5, 0xac, 0x1f, 0x5f, 0x2d, 0x35, 0xc1, 0xc5, 0xa7, 0x85, 0xce, 0x4a, 0x95, 0xc0, 0x3f,
+	0x0b, 0x6c, 0xfe, 0xc4, 0x00, 0x93, 0x2b, 0x01, 0x5f, 0x87, 0x06, 0xf7, 0x06, 0xf1, 0xa3, 0x93,
+	0x2a, 0x47, 0x38, 0xdc, 0xc9, 0x9e, 0x59, 0x38, 0x74, 0xe6, 0xb8, 0x1d, 0x7a, 0x34, 0x2d, 0xfe,
+	0xf2, 0xc9, 0xd4, 0x98, 0x21, 0x4a, 0xfe, 0x93, 0x59, 0x2d, 0xe7, 0x9e, 0x6a, 0x81, 0x9c, 0x7a,
+	0x17, 0xae, 0xe8, 0xdc, 0xf7, 0x82, 0xe0, 0x3c, 0xc3, 0x65, 0x6c, 0xb7, 0x11, 0xbe, 0x0d, 0xcd,
+	0x87, 0x34, 0x15, 0x99, 0x35, 0xc1, 0x96, 0xde, 0xa3, 0xf1, 0x40, 0x95, 0xad, 0xc8, 0x3f, 0x89,
+	0xdf, 0x46, 0xf8, 0x1e, 0x5c, 0xe9, 0x8d, 0x0f, 0x47, 0x7e, 0x5a, 0x9d, 0x47, 0xfe, 0x9f, 0xe2,
+	0x9d, 0x36, 0xac, 0x2c, 0x9f, 0xed, 0x26, 0xb4, 0xa5, 0x88, 0x7c, 0xca, 0x97, 0xdd, 0x31, 0x05,
+	0x97, 0xd9, 0xdf, 0xcc, 0x7e, 0x10, 0x6b, 0x65, 0xe5, 0x60, 0x10, 0xb0, 0x8e, 0x0e, 0x6c, 0x5d,
+	0x87, 0xd5, 0x01, 0x1b, 0x6d, 0x9c, 0xb1, 0x84, 0x46, 0x01, 0xa5, 0x92, 0x14, 0x51, 0x1a, 0x6f,
+	0x35, 0xf8, 0x23, 0xb7, 0xd2, 0x3e, 0x3a, 0x9c, 0x17, 0x15, 0xd0, 0x9d, 0x7f, 0x0f, 0x00, 0x81,
+	0xaa, 0x15, 0xbc, 0xe4, 0x26, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ context.Context
-var _ grpc.ClientConn
+var _ grpc.ClientConnInterface
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+const _ = grpc.SupportPackageIsVersion6
 
 // GossipClient is the client API for Gossip service.
 //
@@ -1572,10 +3672,10 @@ type GossipClient interface {
 }
 
 type gossipClient struct {
-	cc *grpc.ClientConn
+	cc grpc.ClientConnInterface
 }
 
-func NewGossipClient(cc *grpc.ClientConn) GossipClient {
+func NewGossipClient(cc grpc.ClientConnInterface) GossipClient {
 	return &gossipClient{cc}
 }
 
@@ -1713,10 +3813,10 @@ type ConsensusClient interface {
 }
 
 type consensusClient struct {
-	cc *grpc.ClientConn
+	cc grpc.ClientConnInterface
 }
 
-func NewConsensusClient(cc *grpc.ClientConn) ConsensusClient {
+func NewConsensusClient(cc grpc.ClientConnInterface) ConsensusClient {
 	return &consensusClient{cc}
 }
 
@@ -1818,13 +3918,17 @@ var _Consensus_serviceDesc = grpc.ServiceDesc{
 type FarmerClient interface {
 	GetPeerState(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PeerState, error)
 	GetPeerStateStream(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Farmer_GetPeerStateStreamClient, error)
+	GetReputationHistory(ctx context.Context, in *ReputationHistoryRequest, opts ...grpc.CallOption) (*ReputationHistory, error)
+	SetLogLevel(ctx context.Context, in *LogLevel, opts ...grpc.CallOption) (*LogLevel, error)
+	SubscribeEvents(ctx context.Context, in *EventFilter, opts ...grpc.CallOption) (Farmer_SubscribeEventsClient, error)
+	GetMessageLog(ctx context.Context, in *MessageLogRequest, opts ...grpc.CallOption) (*MessageLogDump, error)
 }
 
 type farmerClient struct {
-	cc *grpc.ClientConn
+	cc grpc.ClientConnInterface
 }
 
-func NewFarmerClient(cc *grpc.ClientConn) FarmerClient {
+func NewFarmerClient(cc grpc.ClientConnInterface) FarmerClient {
 	return &farmerClient{cc}
 }
 
@@ -1869,10 +3973,73 @@ func (x *farmerGetPeerStateStreamClient) Recv() (*PeerState, error) {
 	return m, nil
 }
 
+func (c *farmerClient) GetReputationHistory(ctx context.Context, in *ReputationHistoryRequest, opts ...grpc.CallOption) (*ReputationHistory, error) {
+	out := new(ReputationHistory)
+	err := c.cc.Invoke(ctx, "/plum.Farmer/GetReputationHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *farmerClient) SetLogLevel(ctx context.Context, in *LogLevel, opts ...grpc.CallOption) (*LogLevel, error) {
+	out := new(LogLevel)
+	err := c.cc.Invoke(ctx, "/plum.Farmer/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *farmerClient) SubscribeEvents(ctx context.Context, in *EventFilter, opts ...grpc.CallOption) (Farmer_SubscribeEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Farmer_serviceDesc.Streams[1], "/plum.Farmer/SubscribeEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &farmerSubscribeEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Farmer_SubscribeEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type farmerSubscribeEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *farmerSubscribeEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *farmerClient) GetMessageLog(ctx context.Context, in *MessageLogRequest, opts ...grpc.CallOption) (*MessageLogDump, error) {
+	out := new(MessageLogDump)
+	err := c.cc.Invoke(ctx, "/plum.Farmer/GetMessageLog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FarmerServer is the server API for Farmer service.
 type FarmerServer interface {
 	GetPeerState(context.Context, *Empty) (*PeerState, error)
 	GetPeerStateStream(*Empty, Farmer_GetPeerStateStreamServer) error
+	GetReputationHistory(context.Context, *ReputationHistoryRequest) (*ReputationHistory, error)
+	SetLogLevel(context.Context, *LogLevel) (*LogLevel, error)
+	SubscribeEvents(*EventFilter, Farmer_SubscribeEventsServer) error
+	GetMessageLog(context.Context, *MessageLogRequest) (*MessageLogDump, error)
 }
 
 // UnimplementedFarmerServer can be embedded to have forward compatible implementations.
@@ -1885,6 +4052,18 @@ func (*UnimplementedFarmerServer) GetPeerState(ctx context.Context, req *Empty)
 func (*UnimplementedFarmerServer) GetPeerStateStream(req *Empty, srv Farmer_GetPeerStateStreamServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetPeerStateStream not implemented")
 }
+func (*UnimplementedFarmerServer) GetReputationHistory(ctx context.Context, req *ReputationHistoryRequest) (*ReputationHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReputationHistory not implemented")
+}
+func (*UnimplementedFarmerServer) SetLogLevel(ctx context.Context, req *LogLevel) (*LogLevel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+func (*UnimplementedFarmerServer) SubscribeEvents(req *EventFilter, srv Farmer_SubscribeEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeEvents not implemented")
+}
+func (*UnimplementedFarmerServer) GetMessageLog(ctx context.Context, req *MessageLogRequest) (*MessageLogDump, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMessageLog not implemented")
+}
 
 func RegisterFarmerServer(s *grpc.Server, srv FarmerServer) {
 	s.RegisterService(&_Farmer_serviceDesc, srv)
@@ -1929,6 +4108,81 @@ func (x *farmerGetPeerStateStreamServer) Send(m *PeerState) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Farmer_GetReputationHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReputationHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FarmerServer).GetReputationHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/plum.Farmer/GetReputationHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FarmerServer).GetReputationHistory(ctx, req.(*ReputationHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Farmer_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogLevel)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FarmerServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/plum.Farmer/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FarmerServer).SetLogLevel(ctx, req.(*LogLevel))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Farmer_SubscribeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FarmerServer).SubscribeEvents(m, &farmerSubscribeEventsServer{stream})
+}
+
+type Farmer_SubscribeEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type farmerSubscribeEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *farmerSubscribeEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Farmer_GetMessageLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MessageLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FarmerServer).GetMessageLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/plum.Farmer/GetMessageLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FarmerServer).GetMessageLog(ctx, req.(*MessageLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Farmer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "plum.Farmer",
 	HandlerType: (*FarmerServer)(nil),
@@ -1937,6 +4191,18 @@ var _Farmer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetPeerState",
 			Handler:    _Farmer_GetPeerState_Handler,
 		},
+		{
+			MethodName: "GetReputationHistory",
+			Handler:    _Farmer_GetReputationHistory_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _Farmer_SetLogLevel_Handler,
+		},
+		{
+			MethodName: "GetMessageLog",
+			Handler:    _Farmer_GetMessageLog_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1944,6 +4210,11 @@ var _Farmer_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Farmer_GetPeerStateStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "SubscribeEvents",
+			Handler:       _Farmer_SubscribeEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "plum.proto",
 }
@@ -1956,13 +4227,17 @@ type PeerClient interface {
 	SetPublicKey(ctx context.Context, in *PublicKey, opts ...grpc.CallOption) (*Empty, error)
 	GetPublicKey(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PublicKey, error)
 	GetPublicKeyAllStream(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Peer_GetPublicKeyAllStreamClient, error)
+	GetBlocks(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (Peer_GetBlocksClient, error)
+	SubmitValidatorUpdate(ctx context.Context, in *SignedValidatorUpdate, opts ...grpc.CallOption) (*Empty, error)
+	SubmitEvidence(ctx context.Context, in *Evidence, opts ...grpc.CallOption) (*Empty, error)
+	Hello(ctx context.Context, in *Hello, opts ...grpc.CallOption) (*Hello, error)
 }
 
 type peerClient struct {
-	cc *grpc.ClientConn
+	cc grpc.ClientConnInterface
 }
 
-func NewPeerClient(cc *grpc.ClientConn) PeerClient {
+func NewPeerClient(cc grpc.ClientConnInterface) PeerClient {
 	return &peerClient{cc}
 }
 
@@ -2025,12 +4300,75 @@ func (x *peerGetPublicKeyAllStreamClient) Recv() (*PublicKey, error) {
 	return m, nil
 }
 
+func (c *peerClient) GetBlocks(ctx context.Context, in *BlockRange, opts ...grpc.CallOption) (Peer_GetBlocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Peer_serviceDesc.Streams[1], "/plum.Peer/GetBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &peerGetBlocksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Peer_GetBlocksClient interface {
+	Recv() (*SyncBlock, error)
+	grpc.ClientStream
+}
+
+type peerGetBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerGetBlocksClient) Recv() (*SyncBlock, error) {
+	m := new(SyncBlock)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *peerClient) SubmitValidatorUpdate(ctx context.Context, in *SignedValidatorUpdate, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/plum.Peer/SubmitValidatorUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) SubmitEvidence(ctx context.Context, in *Evidence, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/plum.Peer/SubmitEvidence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) Hello(ctx context.Context, in *Hello, opts ...grpc.CallOption) (*Hello, error) {
+	out := new(Hello)
+	err := c.cc.Invoke(ctx, "/plum.Peer/Hello", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PeerServer is the server API for Peer service.
 type PeerServer interface {
 	PingPong(context.Context, *Ping) (*Pong, error)
 	SetPublicKey(context.Context, *PublicKey) (*Empty, error)
 	GetPublicKey(context.Context, *Empty) (*PublicKey, error)
 	GetPublicKeyAllStream(*Empty, Peer_GetPublicKeyAllStreamServer) error
+	GetBlocks(*BlockRange, Peer_GetBlocksServer) error
+	SubmitValidatorUpdate(context.Context, *SignedValidatorUpdate) (*Empty, error)
+	SubmitEvidence(context.Context, *Evidence) (*Empty, error)
+	Hello(context.Context, *Hello) (*Hello, error)
 }
 
 // UnimplementedPeerServer can be embedded to have forward compatible implementations.
@@ -2049,6 +4387,18 @@ func (*UnimplementedPeerServer) GetPublicKey(ctx context.Context, req *Empty) (*
 func (*UnimplementedPeerServer) GetPublicKeyAllStream(req *Empty, srv Peer_GetPublicKeyAllStreamServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetPublicKeyAllStream not implemented")
 }
+func (*UnimplementedPeerServer) GetBlocks(req *BlockRange, srv Peer_GetBlocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetBlocks not implemented")
+}
+func (*UnimplementedPeerServer) SubmitValidatorUpdate(ctx context.Context, req *SignedValidatorUpdate) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitValidatorUpdate not implemented")
+}
+func (*UnimplementedPeerServer) SubmitEvidence(ctx context.Context, req *Evidence) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitEvidence not implemented")
+}
+func (*UnimplementedPeerServer) Hello(ctx context.Context, req *Hello) (*Hello, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hello not implemented")
+}
 
 func RegisterPeerServer(s *grpc.Server, srv PeerServer) {
 	s.RegisterService(&_Peer_serviceDesc, srv)
@@ -2129,6 +4479,81 @@ func (x *peerGetPublicKeyAllStreamServer) Send(m *PublicKey) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Peer_GetBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BlockRange)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PeerServer).GetBlocks(m, &peerGetBlocksServer{stream})
+}
+
+type Peer_GetBlocksServer interface {
+	Send(*SyncBlock) error
+	grpc.ServerStream
+}
+
+type peerGetBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerGetBlocksServer) Send(m *SyncBlock) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Peer_SubmitValidatorUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignedValidatorUpdate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).SubmitValidatorUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/plum.Peer/SubmitValidatorUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).SubmitValidatorUpdate(ctx, req.(*SignedValidatorUpdate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_SubmitEvidence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Evidence)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).SubmitEvidence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/plum.Peer/SubmitEvidence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).SubmitEvidence(ctx, req.(*Evidence))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Hello)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).Hello(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/plum.Peer/Hello",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).Hello(ctx, req.(*Hello))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Peer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "plum.Peer",
 	HandlerType: (*PeerServer)(nil),
@@ -2145,6 +4570,18 @@ var _Peer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetPublicKey",
 			Handler:    _Peer_GetPublicKey_Handler,
 		},
+		{
+			MethodName: "SubmitValidatorUpdate",
+			Handler:    _Peer_SubmitValidatorUpdate_Handler,
+		},
+		{
+			MethodName: "SubmitEvidence",
+			Handler:    _Peer_SubmitEvidence_Handler,
+		},
+		{
+			MethodName: "Hello",
+			Handler:    _Peer_Hello_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -2152,6 +4589,11 @@ var _Peer_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Peer_GetPublicKeyAllStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetBlocks",
+			Handler:       _Peer_GetBlocks_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "plum.proto",
 }
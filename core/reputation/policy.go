@@ -0,0 +1,173 @@
+package reputation
+
+import (
+	"fmt"
+	"github.com/yoseplee/plum/core/plum"
+	"math"
+)
+
+//names of the built-in policies, used in the consensus parameters of the genesis
+const (
+	Linear           = "linear"
+	ExponentialDecay = "exponential-decay"
+	Windowed         = "windowed"
+	Bounded          = "bounded"
+)
+
+//defaults of the parameters, which are taken by a policy whose parameter is zero
+const (
+	defaultIncrease = 0.01
+	defaultDecrease = 0.09
+	defaultDecay    = 0.99
+	defaultBase     = 1.0
+	defaultWindow   = 100
+)
+
+//Policy decides how reputation of a validator changes by the blocks of the chain.
+//every peer should apply the same policy, therefore it is chosen by the genesis
+type Policy interface {
+	//Name returns the name of the policy
+	Name() string
+	//Reward returns the reputation of a committee member whose block is appended
+	Reward(r float64) float64
+	//Penalize returns the reputation of a committee member which has failed to append its block in a round
+	Penalize(r float64) float64
+	//Decay returns the reputation of a validator after a block is appended, whether it is in the committee or not
+	Decay(r float64) float64
+	//Window returns for how many heights a reward or penalty lasts. 0 means it lasts forever
+	Window() uint64
+}
+
+//linear adds a fixed amount as reward and multiplies a fixed factor as penalty, which is how plum has started
+type linear struct {
+	increase float64
+	decrease float64
+}
+
+func (l linear) Name() string {
+	return Linear
+}
+
+func (l linear) Reward(r float64) float64 {
+	return r + l.increase
+}
+
+func (l linear) Penalize(r float64) float64 {
+	return r * l.decrease
+}
+
+func (l linear) Decay(r float64) float64 {
+	return r
+}
+
+func (l linear) Window() uint64 {
+	return 0
+}
+
+//exponentialDecay is linear, except that reputation is pulled back to the base by the decay factor every block.
+//old rewards and penalties therefore fade away exponentially
+type exponentialDecay struct {
+	linear
+	decay float64
+	base  float64
+}
+
+func (e exponentialDecay) Name() string {
+	return ExponentialDecay
+}
+
+func (e exponentialDecay) Decay(r float64) float64 {
+	return e.base + (r-e.base)*e.decay
+}
+
+//windowed is linear, except that a reward or penalty is reverted once it gets older than the window
+type windowed struct {
+	linear
+	window uint64
+}
+
+func (w windowed) Name() string {
+	return Windowed
+}
+
+func (w windowed) Window() uint64 {
+	return w.window
+}
+
+//bounded is linear, except that reputation is kept within the range of min and max
+type bounded struct {
+	linear
+	min float64
+	max float64
+}
+
+func (b bounded) Name() string {
+	return Bounded
+}
+
+func (b bounded) Reward(r float64) float64 {
+	return b.clamp(b.linear.Reward(r))
+}
+
+func (b bounded) Penalize(r float64) float64 {
+	return b.clamp(b.linear.Penalize(r))
+}
+
+func (b bounded) Decay(r float64) float64 {
+	return b.clamp(r)
+}
+
+func (b bounded) clamp(r float64) float64 {
+	return math.Max(b.min, math.Min(b.max, r))
+}
+
+func orDefault(v, d float64) float64 {
+	if v == 0 {
+		return d
+	}
+	return v
+}
+
+//New makes the policy of the parameters. nil or empty parameters result in the linear policy with the default units
+func New(params *plum.ReputationPolicyParams) (Policy, error) {
+	if params.GetIncrease() < 0 {
+		return nil, fmt.Errorf("negative increase of reputation: %v", params.GetIncrease())
+	}
+	if params.GetDecrease() < 0 || params.GetDecrease() > 1 {
+		return nil, fmt.Errorf("decrease of reputation should be a factor between 0 and 1: %v", params.GetDecrease())
+	}
+	l := linear{
+		increase: orDefault(params.GetIncrease(), defaultIncrease),
+		decrease: orDefault(params.GetDecrease(), defaultDecrease),
+	}
+
+	switch params.GetName() {
+	case "", Linear:
+		return l, nil
+	case ExponentialDecay:
+		if params.GetDecay() < 0 || params.GetDecay() >= 1 {
+			return nil, fmt.Errorf("decay should be a factor between 0 and 1: %v", params.GetDecay())
+		}
+		if params.GetBase() < 0 {
+			return nil, fmt.Errorf("negative base of reputation: %v", params.GetBase())
+		}
+		return exponentialDecay{
+			linear: l,
+			decay:  orDefault(params.GetDecay(), defaultDecay),
+			base:   orDefault(params.GetBase(), defaultBase),
+		}, nil
+	case Windowed:
+		window := params.GetWindow()
+		if window == 0 {
+			window = defaultWindow
+		}
+		return windowed{linear: l, window: window}, nil
+	case Bounded:
+		if params.GetMin() < 0 || params.GetMax() <= params.GetMin() {
+			return nil, fmt.Errorf("invalid bound of reputation: [%v, %v]", params.GetMin(), params.GetMax())
+		}
+		return bounded{linear: l, min: params.GetMin(), max: params.GetMax()}, nil
+	default:
+		return nil, fmt.Errorf("unknown reputation policy: %s", params.GetName())
+	}
+}
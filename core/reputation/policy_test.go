@@ -0,0 +1,103 @@
+package reputation
+
+import (
+	"github.com/yoseplee/plum/core/plum"
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestNew_Default(t *testing.T) {
+	p, err := New(nil)
+	if err != nil {
+		t.Fatalf("could not make the default policy: %v", err)
+	}
+	if p.Name() != Linear {
+		t.Errorf("invalid default policy. got: %s, want: %s", p.Name(), Linear)
+	}
+
+	//the default keeps how reputation has changed before policies
+	if got := p.Reward(1.0); !almostEqual(got, 1.01) {
+		t.Errorf("invalid reward. got: %v, want: %v", got, 1.01)
+	}
+	if got := p.Penalize(1.0); !almostEqual(got, 0.09) {
+		t.Errorf("invalid penalty. got: %v, want: %v", got, 0.09)
+	}
+	if got := p.Decay(1.5); got != 1.5 {
+		t.Errorf("linear policy should not decay. got: %v", got)
+	}
+	if p.Window() != 0 {
+		t.Errorf("linear policy should have no window")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []*plum.ReputationPolicyParams{
+		{Name: "unknown"},
+		{Increase: -0.1},
+		{Decrease: 1.5},
+		{Name: ExponentialDecay, Decay: 1.0},
+		{Name: ExponentialDecay, Base: -1.0},
+		{Name: Bounded},
+		{Name: Bounded, Min: 2.0, Max: 1.0},
+	}
+	for _, params := range tests {
+		if _, err := New(params); err == nil {
+			t.Errorf("invalid parameters should be rejected: %v", params)
+		}
+	}
+}
+
+func TestExponentialDecay(t *testing.T) {
+	p, err := New(&plum.ReputationPolicyParams{Name: ExponentialDecay, Decay: 0.5, Base: 1.0})
+	if err != nil {
+		t.Fatalf("could not make the policy: %v", err)
+	}
+
+	//reputation approaches the base from both sides
+	if got := p.Decay(3.0); !almostEqual(got, 2.0) {
+		t.Errorf("invalid decay. got: %v, want: %v", got, 2.0)
+	}
+	if got := p.Decay(0.0); !almostEqual(got, 0.5) {
+		t.Errorf("invalid decay. got: %v, want: %v", got, 0.5)
+	}
+	if got := p.Decay(1.0); got != 1.0 {
+		t.Errorf("reputation at the base should not change. got: %v", got)
+	}
+}
+
+func TestWindowed(t *testing.T) {
+	p, err := New(&plum.ReputationPolicyParams{Name: Windowed})
+	if err != nil {
+		t.Fatalf("could not make the policy: %v", err)
+	}
+	if p.Window() != defaultWindow {
+		t.Errorf("invalid default window. got: %d, want: %d", p.Window(), defaultWindow)
+	}
+
+	p, _ = New(&plum.ReputationPolicyParams{Name: Windowed, Window: 10})
+	if p.Window() != 10 {
+		t.Errorf("invalid window. got: %d, want: %d", p.Window(), 10)
+	}
+}
+
+func TestBounded(t *testing.T) {
+	p, err := New(&plum.ReputationPolicyParams{Name: Bounded, Increase: 0.5, Min: 0.5, Max: 2.0})
+	if err != nil {
+		t.Fatalf("could not make the policy: %v", err)
+	}
+
+	r := 1.0
+	for i := 0; i < 5; i++ {
+		r = p.Reward(r)
+	}
+	if r != 2.0 {
+		t.Errorf("reputation should be kept under the max. got: %v", r)
+	}
+	if got := p.Penalize(r); got != 0.5 {
+		t.Errorf("reputation should be kept over the min. got: %v", got)
+	}
+}
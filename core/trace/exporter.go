@@ -0,0 +1,85 @@
+package trace
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+//Exporter writes spans which have ended
+type Exporter interface {
+	Export(d SpanData) error
+	Close() error
+}
+
+var (
+	exporter      Exporter
+	exporterMutex = &sync.RWMutex{}
+)
+
+//SetExporter turns tracing on with the exporter. nil turns it off
+func SetExporter(e Exporter) {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	exporter = e
+}
+
+//Enabled reports whether spans are recorded
+func Enabled() bool {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	return exporter != nil
+}
+
+//Shutdown closes the exporter and turns tracing off
+func Shutdown() error {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	if exporter == nil {
+		return nil
+	}
+	err := exporter.Close()
+	exporter = nil
+	return err
+}
+
+func export(d SpanData) {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	if exporter != nil {
+		exporter.Export(d)
+	}
+}
+
+//FileExporter writes a span per line in json to a local file.
+//spans are written as they end, so the ones of a peer which has crashed are kept
+type FileExporter struct {
+	f     *os.File
+	mutex *sync.Mutex
+}
+
+//NewFileExporter opens the file to append spans to it
+func NewFileExporter(path string) (*FileExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileExporter{f: f, mutex: &sync.Mutex{}}, nil
+}
+
+func (e *FileExporter) Export(d SpanData) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	_, err = e.f.Write(append(b, '\n'))
+	return err
+}
+
+func (e *FileExporter) Close() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.f.Close()
+}
@@ -0,0 +1,204 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"google.golang.org/grpc/metadata"
+	"strings"
+	"sync"
+	"time"
+)
+
+//metadataKey carries the span context over grpc in the format of w3c trace context, so that other tools can read it
+const metadataKey = "traceparent"
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+//SpanContext identifies a span across peers
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+//IsValid reports whether the span context has both of the ids
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+//String returns the span context in the format of traceparent header
+func (sc SpanContext) String() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+//ParseSpanContext reads the span context from the traceparent header
+func ParseSpanContext(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(s, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return sc, fmt.Errorf("invalid traceparent: %s", s)
+	}
+	t, err := hex.DecodeString(parts[1])
+	if err != nil || len(t) != len(sc.TraceID) {
+		return sc, fmt.Errorf("invalid trace id: %s", parts[1])
+	}
+	sp, err := hex.DecodeString(parts[2])
+	if err != nil || len(sp) != len(sc.SpanID) {
+		return sc, fmt.Errorf("invalid span id: %s", parts[2])
+	}
+	copy(sc.TraceID[:], t)
+	copy(sc.SpanID[:], sp)
+	return sc, nil
+}
+
+//RoundTraceID returns the id of the trace of a round at the height.
+//every peer of the chain derives the same, so the spans of a round are joined even if a peer starts it before hearing from others
+func RoundTraceID(chainID string, height, round uint64) TraceID {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], height)
+	binary.BigEndian.PutUint64(b[8:], round)
+	h := sha256.Sum256(append([]byte(chainID), b...))
+
+	var id TraceID
+	copy(id[:], h[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	if _, err := rand.Read(id[:]); err != nil {
+		binary.BigEndian.PutUint64(id[:], uint64(time.Now().UnixNano()))
+	}
+	return id
+}
+
+//Inject puts the span context into the outgoing metadata of the context
+func Inject(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, metadataKey, sc.String())
+}
+
+//Extract reads the span context from the incoming metadata of the context
+func Extract(ctx context.Context) (SpanContext, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(metadataKey)) == 0 {
+		return SpanContext{}, false
+	}
+	sc, err := ParseSpanContext(md.Get(metadataKey)[0])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+//Attributes describe a span, such as the height and round of it
+type Attributes map[string]interface{}
+
+//Span is a timed operation in a trace. a nil span is valid and does nothing, which is what Start returns if tracing is off
+type Span struct {
+	sc     SpanContext
+	parent SpanID
+	name   string
+	start  time.Time
+	attrs  Attributes
+	links  []SpanContext
+	mutex  *sync.Mutex
+	ended  bool
+}
+
+//SpanData is a span which has ended, as written by exporters
+type SpanData struct {
+	TraceID      string     `json:"traceId"`
+	SpanID       string     `json:"spanId"`
+	ParentSpanID string     `json:"parentSpanId,omitempty"`
+	Name         string     `json:"name"`
+	Start        time.Time  `json:"start"`
+	End          time.Time  `json:"end"`
+	DurationMs   float64    `json:"durationMs"`
+	Attributes   Attributes `json:"attributes,omitempty"`
+	Links        []string   `json:"links,omitempty"`
+}
+
+//Start starts a span of the trace under the parent, which is the root of the trace if parent is zero
+func Start(name string, traceID TraceID, parent SpanID, attrs Attributes) *Span {
+	if !Enabled() {
+		return nil
+	}
+	s := &Span{
+		sc:     SpanContext{TraceID: traceID, SpanID: newSpanID()},
+		parent: parent,
+		name:   name,
+		start:  time.Now(),
+		attrs:  make(Attributes),
+		mutex:  &sync.Mutex{},
+	}
+	for k, v := range attrs {
+		s.attrs[k] = v
+	}
+	return s
+}
+
+//Context returns the span context to be propagated to other peers
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+func (s *Span) SetAttribute(k string, v interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attrs[k] = v
+}
+
+//AddLink relates the span to a span of another peer, such as the one which has sent the message starting this span
+func (s *Span) AddLink(sc SpanContext) {
+	if s == nil || !sc.IsValid() {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.links = append(s.links, sc)
+}
+
+//End ends the span and exports it. a span is exported only once
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	end := time.Now()
+	d := SpanData{
+		TraceID:    hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:     hex.EncodeToString(s.sc.SpanID[:]),
+		Name:       s.name,
+		Start:      s.start,
+		End:        end,
+		DurationMs: float64(end.Sub(s.start)) / float64(time.Millisecond),
+		Attributes: s.attrs,
+	}
+	if s.parent != (SpanID{}) {
+		d.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	for _, l := range s.links {
+		d.Links = append(d.Links, l.String())
+	}
+	s.mutex.Unlock()
+
+	export(d)
+}
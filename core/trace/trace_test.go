@@ -0,0 +1,129 @@
+package trace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"google.golang.org/grpc/metadata"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+//memoryExporter keeps spans in memory for tests
+type memoryExporter struct {
+	spans []SpanData
+	mutex sync.Mutex
+}
+
+func (e *memoryExporter) Export(d SpanData) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, d)
+	return nil
+}
+
+func (e *memoryExporter) Close() error {
+	return nil
+}
+
+func TestSpanContext_Propagation(t *testing.T) {
+	sc := SpanContext{TraceID: RoundTraceID("plum", 3, 1), SpanID: newSpanID()}
+
+	out := Inject(context.Background(), sc)
+	md, _ := metadata.FromOutgoingContext(out)
+	in := metadata.NewIncomingContext(context.Background(), md)
+
+	got, ok := Extract(in)
+	if !ok || got != sc {
+		t.Errorf("invalid propagated span context. got: %v, want: %v", got, sc)
+	}
+
+	if _, ok := Extract(context.Background()); ok {
+		t.Errorf("context without metadata should have no span context")
+	}
+	if _, err := ParseSpanContext("00-invalid-01"); err == nil {
+		t.Errorf("invalid traceparent should be rejected")
+	}
+}
+
+func TestRoundTraceID(t *testing.T) {
+	if RoundTraceID("plum", 3, 1) != RoundTraceID("plum", 3, 1) {
+		t.Errorf("every peer should derive the same trace id of a round")
+	}
+	if RoundTraceID("plum", 3, 1) == RoundTraceID("plum", 3, 2) {
+		t.Errorf("rounds should have different trace ids")
+	}
+}
+
+func TestSpan(t *testing.T) {
+	//spans are not recorded without an exporter
+	if s := Start("prepare", TraceID{}, SpanID{}, nil); s != nil {
+		t.Fatalf("span should not be recorded if tracing is off")
+	}
+	var s *Span
+	s.SetAttribute("k", "v")
+	s.End()
+
+	e := &memoryExporter{}
+	SetExporter(e)
+	defer Shutdown()
+
+	root := Start("round", RoundTraceID("plum", 1, 0), SpanID{}, Attributes{"height": 1})
+	child := Start("prepare", root.Context().TraceID, root.Context().SpanID, nil)
+	child.AddLink(SpanContext{TraceID: root.Context().TraceID, SpanID: newSpanID()})
+	child.End()
+	child.End()
+	root.End()
+
+	if len(e.spans) != 2 {
+		t.Fatalf("a span should be exported once when it ends. got: %d spans", len(e.spans))
+	}
+	if e.spans[0].ParentSpanID != e.spans[1].SpanID || e.spans[0].TraceID != e.spans[1].TraceID {
+		t.Errorf("child span should be under the root: %v", e.spans)
+	}
+	if len(e.spans[0].Links) != 1 || e.spans[1].ParentSpanID != "" {
+		t.Errorf("invalid spans: %v", e.spans)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatalf("could not make a temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "trace.jsonl")
+	e, err := NewFileExporter(path)
+	if err != nil {
+		t.Fatalf("could not open the exporter: %v", err)
+	}
+	SetExporter(e)
+	Start("commit", RoundTraceID("plum", 1, 0), SpanID{}, nil).End()
+	Start("select", RoundTraceID("plum", 1, 0), SpanID{}, nil).End()
+	if err := Shutdown(); err != nil {
+		t.Fatalf("could not close the exporter: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("could not open the trace file: %v", err)
+	}
+	defer f.Close()
+
+	var names []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var d SpanData
+		if err := json.Unmarshal(sc.Bytes(), &d); err != nil {
+			t.Fatalf("invalid line of the trace file: %v", err)
+		}
+		names = append(names, d.Name)
+	}
+	if len(names) != 2 || names[0] != "commit" || names[1] != "select" {
+		t.Errorf("invalid spans in the trace file: %v", names)
+	}
+}
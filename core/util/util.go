@@ -1,14 +1,15 @@
 package util
 
 import (
+	"crypto/ed25519"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/yoseplee/plum/core/ledger/block"
+	"github.com/yoseplee/plum/core/logger"
 	"github.com/yoseplee/plum/core/plum"
 	"io/ioutil"
-	"log"
 	"net"
 	"os"
 	"strings"
@@ -86,53 +87,99 @@ func MakeString(in interface{}) string {
 		s += fmt.Sprintf("%-15s | %s\n", "PrevBlockHash: ", hex.EncodeToString(r.Header.PrevBlockHash))
 		s += fmt.Sprintf("%-15s | %s\n", "Time: ", r.Header.Time)
 		s += fmt.Sprintf("%s\n", "=== === === BODY === === ===")
-		s += fmt.Sprintf("%s\n", r.Body.MerkleTree.Root.String())
+		s += fmt.Sprintf("%-15s | %d\n", "Txs: ", len(r.GetBody().GetTxs()))
 		//s += fmt.Sprintf("%s\n", "=== === === TXS === === ===")
 		//for i, tx := range r.Body.Txs {
 		//	s += fmt.Sprintf("%d | %v\n", i, tx)
 		//}
-	case *plum.MerkleNode:
-		if r.L == nil && r.R == nil {
-			return fmt.Sprintf("TERMINAL NODE (left = %v, right = %v, data = %s", nil, nil, hex.EncodeToString(r.D))
-		} else {
-			return fmt.Sprintf("NODE (left = %v,\nright = %v,\ndata = %s\n", r.L, r.R, hex.EncodeToString(r.D))
-		}
 	default:
 		s = fmt.Sprintf("%s", in)
 	}
 	return s
 }
 
-func StoreNewGenesisBlock() {
+//StoreNewGenesisBlock stores a genesis block without configuration into gbp.
+//to make a genesis block with validators and consensus parameters, use the genesis command instead
+func StoreNewGenesisBlock(gbp string) {
 	g := block.NewGenesisBlock()
 	//marshal
 	mg, err := proto.Marshal(g)
 	if err != nil {
-		log.Fatalln("could not marshal message properly")
+		logger.Fatalf("could not marshal message properly: %v", err)
 	}
 
 	//file store
-	fErr := ioutil.WriteFile("/Users/yosep/Documents/github/plum/core/genesis.block", mg, os.FileMode(777))
+	fErr := ioutil.WriteFile(gbp, mg, os.FileMode(777))
 	if fErr != nil {
-		log.Fatalf("could not save genesis block properly: %v", fErr)
+		logger.Fatalf("could not save genesis block properly: %v", fErr)
+	}
+}
+
+//StorePrivateKey writes the private key into the file in hex
+func StorePrivateKey(k ed25519.PrivateKey, kp string) error {
+	return ioutil.WriteFile(kp, []byte(hex.EncodeToString(k)), os.FileMode(0600))
+}
+
+//LoadPrivateKey reads the private key written by StorePrivateKey
+func LoadPrivateKey(kp string) (ed25519.PrivateKey, error) {
+	raw, err := ioutil.ReadFile(kp)
+	if err != nil {
+		return nil, fmt.Errorf("could not read private key: %v", err)
+	}
+
+	k, err := hex.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(k) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key")
 	}
+	return k, nil
 }
 
-func DebugMsg(msg ...string) {
-	log.Println(fmt.Sprintf("[DEBUG] %s", msg))
+//ProtocolVersion is the version of the consensus protocol written into every signature
+const ProtocolVersion uint32 = 1
+
+//SigningBytes wraps a consensus message, a validator update or a hello into the signing envelope of the chain and marshals it.
+//this is what is actually signed and verified for the message
+func SigningBytes(chainID string, m proto.Message) ([]byte, error) {
+	e := &plum.SigningEnvelope{
+		ChainId:         chainID,
+		ProtocolVersion: ProtocolVersion,
+	}
+
+	switch cm := m.(type) {
+	case *plum.PBFTMessage:
+		e.MessageType = plum.MessageType_PBFTMessageType
+		e.Height = cm.GetHeight()
+	case *plum.XBFTMessage:
+		e.MessageType = plum.MessageType_XBFTMessageType
+		e.Height = cm.GetHeight()
+	case *plum.ValidatorUpdate:
+		e.MessageType = plum.MessageType_ValidatorUpdateMessageType
+	case *plum.Hello:
+		e.MessageType = plum.MessageType_HelloMessageType
+	default:
+		return nil, fmt.Errorf("%T is not a consensus message", m)
+	}
+
+	payload, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal the message: %v", err)
+	}
+	e.Payload = payload
+
+	return proto.Marshal(e)
 }
 
-func PrintCommitteeMembers(cms []*plum.CommitteeMembers) {
+//CommitteeMembersString returns the committee members in a table to be logged
+func CommitteeMembersString(cms []*plum.CommitteeMembers) string {
 	var s string
 	var r uint64
 	if len(cms) == 0 {
-		log.Println("EMPTY COMMITTEE MEMBERS")
-		return
+		return "EMPTY COMMITTEE MEMBERS"
 	}
 	r = cms[0].GetRound()
 	s += fmt.Sprintf("PEER ID | SELECTION VALUE\n")
 	for _, cm := range cms {
 		s += fmt.Sprintf("%7d | %v\n", cm.PeerId, cm.SelectionValue)
 	}
-	log.Println(fmt.Sprintf("\n=== COMMITTEE MEMBERS AT ROUND %d ===\n%s", r, s))
+	return fmt.Sprintf("\n=== COMMITTEE MEMBERS AT ROUND %d ===\n%s", r, s)
 }
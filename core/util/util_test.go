@@ -1,7 +1,11 @@
 package util
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"github.com/yoseplee/plum/core/plum"
+	"io/ioutil"
+	"os"
 	"testing"
 )
 
@@ -117,3 +121,25 @@ func TestMakeString(t *testing.T) {
 		t.Errorf("invalid stringify on PBFTRequest message. want: %v, got: %v", want, got)
 	}
 }
+
+func TestLoadPrivateKey(t *testing.T) {
+	f, err := ioutil.TempFile("", "plum-*.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	_, want, _ := ed25519.GenerateKey(nil)
+	if err := StorePrivateKey(want, f.Name()); err != nil {
+		t.Fatalf("could not store private key: %v", err)
+	}
+
+	got, err := LoadPrivateKey(f.Name())
+	if err != nil {
+		t.Fatalf("could not load private key: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("loaded key is different from the stored one")
+	}
+}
@@ -52,9 +52,9 @@ func main() {
 		prevRound := 0
 		for {
 			for _, fc := range farmerClients {
-				ctx, _ := context.WithTimeout(context.Background(), time.Second)
-
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 				r, err := fc.GetPeerState(ctx, &plum.Empty{})
+				cancel()
 				if err != nil {
 					log.Printf("could not get the state of peer: %v", err)
 					continue
@@ -190,10 +190,24 @@ func formatPeerState(p *plum.PeerState) string {
 	s += fmt.Sprintf("|%-17s| %-20s |\n", "Role", p.Role.String())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Round", p.GetConsensusRound())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Current Primary", p.GetCurrentPrimary())
-	s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus Phase", p.GetConsensusPhase().String())
-	s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Prepare]", p.Vote[int32(plum.PBFTPhase_PBFTPrepare)])
-	s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Commit]", p.Vote[int32(plum.PBFTPhase_PBFTPrepare)])
-	s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[RoundChange]", p.Vote[int32(plum.PBFTPhase_PBFTRoundChange)])
+	//only XBFT has thresholds in the state
+	if len(p.GetThresholds()) == 0 {
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus Phase", p.GetConsensusPhase().String())
+		s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Prepare]", p.Vote[int32(plum.PBFTPhase_PBFTPrepare)])
+		s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[Commit]", p.Vote[int32(plum.PBFTPhase_PBFTCommit)])
+		s += fmt.Sprintf("|%-17s| %-20d |\n", "Vote[RoundChange]", p.Vote[int32(plum.PBFTPhase_PBFTRoundChange)])
+	} else {
+		var committee []uint32
+		for _, cm := range p.GetCommitteeMembers() {
+			committee = append(committee, cm.GetPeerId())
+		}
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus Phase", p.GetXbftPhase().String())
+		s += fmt.Sprintf("|%-17s| %-20v |\n", "Committee", committee)
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Cert[Prepare]", formatCertificate(p, plum.XBFTPhase_XBFTPrepare))
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Cert[Commit]", formatCertificate(p, plum.XBFTPhase_XBFTCommit))
+		s += fmt.Sprintf("|%-17s| %-20s |\n", "Cert[RoundChange]", formatCertificate(p, plum.XBFTPhase_XBFTRoundChange))
+		s += fmt.Sprintf("|%-17s| %-20f |\n", "Total Reputation", p.GetTotalReputation())
+	}
 	s += fmt.Sprintf("|%-17s| %-20s |\n", "Consensus State", p.GetConsensusState().String())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Block Height", p.GetBlockHeight())
 	s += fmt.Sprintf("|%-17s| %-20d |\n", "Queue Length", p.GetQueueLength())
@@ -205,6 +219,11 @@ func formatPeerState(p *plum.PeerState) string {
 	return s
 }
 
+//formatCertificate returns the number of the messages collected for the phase against its threshold
+func formatCertificate(p *plum.PeerState, ph plum.XBFTPhase) string {
+	return fmt.Sprintf("%d / %d", p.GetCertificates()[int32(ph)], p.GetThresholds()[int32(ph)])
+}
+
 func makeFarmerClients(clientConns []*grpc.ClientConn) []plum.FarmerClient {
 	var peerClients []plum.FarmerClient
 	for _, c := range clientConns {
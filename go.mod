@@ -7,6 +7,7 @@ require (
 	github.com/golang/protobuf v1.4.3
 	github.com/joho/godotenv v1.3.0
 	github.com/labstack/gommon v0.3.0
+	github.com/prometheus/client_golang v1.7.1
 	github.com/yoseplee/vrf v0.0.0-20201119045737-ddb6a18f05df
 	google.golang.org/grpc v1.33.2
 	gopkg.in/yaml.v2 v2.3.0